@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+)
+
+const (
+	resultsPath    = "/api/v1/results"
+	rulesPath      = "/api/v1/rules"
+	namespacesPath = "/api/v1/namespaces/"
+	evaluatePath   = "/api/v1/evaluate"
+)
+
+// API serves the report of the last completed evaluation as JSON and allows
+// triggering an evaluation.
+type API struct {
+	mutex       sync.RWMutex
+	report      *reports.Report
+	evaluatedAt time.Time
+	evaluate    chan struct{}
+}
+
+// Results is the response of the results endpoints.
+type Results struct {
+	EvaluatedAt time.Time       `json:"evaluatedAt"`
+	Summary     reports.Summary `json:"summary"`
+	Violations  []reports.Entry `json:"violations"`
+	Exempted    []reports.Entry `json:"exempted"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func New() *API {
+	return &API{evaluate: make(chan struct{}, 1)}
+}
+
+// Update replaces the report served by the API with the report of a completed
+// evaluation.
+func (a *API) Update(report reports.Report, evaluatedAt time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.report = &report
+	a.evaluatedAt = evaluatedAt
+}
+
+// Evaluate returns the channel that receives a value when an evaluation is
+// requested. Requests made while one is pending are merged into it.
+func (a *API) Evaluate() <-chan struct{} {
+	return a.evaluate
+}
+
+// Register adds the endpoints of the API to the mux.
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc(resultsPath, a.resultsHandler)
+	mux.HandleFunc(rulesPath, a.rulesHandler)
+	mux.HandleFunc(namespacesPath, a.namespaceViolationsHandler)
+	mux.HandleFunc(evaluatePath, a.evaluateHandler)
+}
+
+func (a *API) lastReport() (*reports.Report, time.Time) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.report, a.evaluatedAt
+}
+
+func (a *API) resultsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	a.writeResults(w, newQuery(r, ""))
+}
+
+// namespaceViolationsHandler serves /api/v1/namespaces/{ns}/violations.
+func (a *API) namespaceViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, namespacesPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "violations" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	a.writeResults(w, newQuery(r, parts[0]))
+}
+
+func (a *API) writeResults(w http.ResponseWriter, query query) {
+	report, evaluatedAt := a.lastReport()
+	if report == nil {
+		writeError(w, http.StatusServiceUnavailable, "no evaluation has completed yet")
+		return
+	}
+	results := Results{
+		EvaluatedAt: evaluatedAt,
+		Summary:     reports.Summary{Rules: report.Summary.Rules, ViolationsBySeverity: make(map[string]int)},
+		Violations:  query.filter(report.Violations),
+		Exempted:    query.filter(report.Exempted),
+	}
+	for _, violation := range results.Violations {
+		results.Summary.ViolationsBySeverity[violation.Severity]++
+	}
+	results.Summary.Violations = len(results.Violations)
+	results.Summary.Exempted = len(results.Exempted)
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (a *API) rulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	report, _ := a.lastReport()
+	if report == nil {
+		writeError(w, http.StatusServiceUnavailable, "no evaluation has completed yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, report.Rules)
+}
+
+func (a *API) evaluateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	select {
+	case a.evaluate <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// query holds the filters of a results request, empty filters match every
+// entry.
+type query struct {
+	rule      string
+	namespace string
+	kind      string
+	severity  string
+}
+
+func newQuery(r *http.Request, namespace string) query {
+	values := r.URL.Query()
+	if namespace == "" {
+		namespace = values.Get("namespace")
+	}
+	return query{
+		rule:      values.Get("rule"),
+		namespace: namespace,
+		kind:      values.Get("kind"),
+		severity:  values.Get("severity"),
+	}
+}
+
+func (q query) matches(entry reports.Entry) bool {
+	return (q.rule == "" || q.rule == entry.RuleName) &&
+		(q.namespace == "" || q.namespace == entry.Namespace) &&
+		(q.kind == "" || q.kind == entry.Kind) &&
+		(q.severity == "" || q.severity == entry.Severity)
+}
+
+func (q query) filter(entries []reports.Entry) []reports.Entry {
+	filtered := []reports.Entry{}
+	for _, entry := range entries {
+		if q.matches(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
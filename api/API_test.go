@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReport() reports.Report {
+	return reports.Report{
+		Summary: reports.Summary{Rules: 2, Violations: 3, Exempted: 1, ViolationsBySeverity: map[string]int{"high": 1, "medium": 2}},
+		Rules: []reports.Rule{
+			{Name: "app label", Type: "pod_rules_labels_filled_in", Kind: "Pod", Severity: "high"},
+			{Name: "replicas minimum", Type: "deployment_rules_replicas_minimum", Kind: "Deployment", Severity: "medium"},
+		},
+		Violations: []reports.Entry{
+			{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"},
+			{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "default", Name: "api", Reason: "Deployment replicas below the minimum: 2"},
+			{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "testing", Name: "web", Reason: "Deployment replicas below the minimum: 2"},
+		},
+		Exempted: []reports.Entry{
+			{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "testing", Name: "legacy", Reason: "Labels: [app] are not filled in", ExemptionReason: "Legacy workload"},
+		},
+	}
+}
+
+func newAPI() *API {
+	api := New()
+	api.Update(newReport(), time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC))
+	return api
+}
+
+func serve(api *API, method, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	api.Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+	return rr
+}
+
+func decodeResults(t *testing.T, rr *httptest.ResponseRecorder) Results {
+	results := Results{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestAPI_Results(t *testing.T) {
+	rr := serve(newAPI(), "GET", "/api/v1/results")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	results := decodeResults(t, rr)
+	assert.Equal(t, time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC), results.EvaluatedAt)
+	assert.Len(t, results.Violations, 3)
+	assert.Len(t, results.Exempted, 1)
+	assert.Equal(t, 3, results.Summary.Violations)
+	assert.Equal(t, 2, results.Summary.Rules)
+}
+
+func TestAPI_Results_Filters(t *testing.T) {
+	rr := serve(newAPI(), "GET", "/api/v1/results?rule=replicas+minimum&namespace=testing&kind=Deployment&severity=medium")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	results := decodeResults(t, rr)
+	assert.Len(t, results.Violations, 1)
+	assert.Equal(t, "web", results.Violations[0].Name)
+	assert.Len(t, results.Exempted, 0)
+	assert.Equal(t, 1, results.Summary.Violations)
+	assert.Equal(t, map[string]int{"medium": 1}, results.Summary.ViolationsBySeverity)
+}
+
+func TestAPI_Results_NoEvaluation(t *testing.T) {
+	rr := serve(New(), "GET", "/api/v1/results")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "no evaluation has completed yet")
+}
+
+func TestAPI_Results_MethodNotAllowed(t *testing.T) {
+	rr := serve(newAPI(), "POST", "/api/v1/results")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestAPI_Rules(t *testing.T) {
+	rr := serve(newAPI(), "GET", "/api/v1/rules")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var rules []reports.Rule
+	if err := json.Unmarshal(rr.Body.Bytes(), &rules); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, newReport().Rules, rules)
+}
+
+func TestAPI_NamespaceViolations(t *testing.T) {
+	rr := serve(newAPI(), "GET", "/api/v1/namespaces/default/violations?kind=Pod")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	results := decodeResults(t, rr)
+	assert.Len(t, results.Violations, 1)
+	assert.Equal(t, "foo", results.Violations[0].Name)
+	assert.Len(t, results.Exempted, 0)
+}
+
+func TestAPI_NamespaceViolations_NotFound(t *testing.T) {
+	for _, target := range []string{"/api/v1/namespaces/default", "/api/v1/namespaces/default/violations/pods", "/api/v1/namespaces/default/pods"} {
+		rr := serve(newAPI(), "GET", target)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code, target)
+	}
+}
+
+func TestAPI_Evaluate(t *testing.T) {
+	api := New()
+
+	rr := serve(api, "POST", "/api/v1/evaluate")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	rr = serve(api, "POST", "/api/v1/evaluate")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	assert.Len(t, api.Evaluate(), 1)
+}
+
+func TestAPI_Evaluate_MethodNotAllowed(t *testing.T) {
+	rr := serve(New(), "GET", "/api/v1/evaluate")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
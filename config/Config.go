@@ -2,18 +2,42 @@ package config
 
 import (
 	"fmt"
+	"github.com/stijndehaes/kube-conformity/filters"
+	"github.com/stijndehaes/kube-conformity/notify"
+	"github.com/stijndehaes/kube-conformity/reports"
 	"github.com/stijndehaes/kube-conformity/rules"
 	"time"
 )
 
 type Config struct {
 	Interval                       time.Duration                          `yaml:"interval"`
+	ClusterName                    string                                 `yaml:"cluster_name"`
 	PodRulesLabelsFilledIn         []rules.PodRuleLabelsFilledIn          `yaml:"pod_rules_labels_filled_in"`
 	PodRulesLimitsFilledIn         []rules.PodRuleLimitsFilledIn          `yaml:"pod_rules_limits_filled_in"`
 	PodRulesRequestsFilledIn       []rules.PodRuleRequestsFilledIn        `yaml:"pod_rules_requests_filled_in"`
 	DeploymentRuleReplicasMinimum  []rules.DeploymentRuleReplicasMinimum  `yaml:"deployment_rules_replicas_minimum"`
 	StatefulSetRuleReplicasMinimum []rules.StatefulSetRuleReplicasMinimum `yaml:"stateful_set_rules_replicas_minimum"`
+	RegoRules                      []rules.RegoRule                       `yaml:"rego_rules"`
+	ResourceRules                  []rules.ResourceRule                   `yaml:"resource_rules"`
+	FieldRules                     []rules.FieldRule                      `yaml:"field_rules"`
 	EmailConfig                    EmailConfig                            `yaml:"email_config"`
+	Notifications                  NotificationConfig                     `yaml:"notifications"`
+	Notifiers                      []notify.NotifierConfig                `yaml:"notifiers"`
+	Routing                        *notify.RoutingConfig                  `yaml:"routing"`
+	Events                         EventConfig                            `yaml:"events"`
+	PolicyReports                  PolicyReportConfig                     `yaml:"policy_reports"`
+	DefaultFilter                  filters.ObjectFilter                   `yaml:"default_filter"`
+	PodDefaults                    filters.PodFilter                      `yaml:"pod_defaults"`
+	DeploymentDefaults             filters.DeploymentFilter               `yaml:"deployment_defaults"`
+	StatefulSetDefaults            filters.StatefulsetFilter              `yaml:"stateful_set_defaults"`
+	Outputs                        []reports.Output                       `yaml:"outputs"`
+}
+
+// RuleFilter is the effective filter of a rule after the defaults are applied.
+type RuleFilter struct {
+	Type   string      `yaml:"type"`
+	Name   string      `yaml:"name"`
+	Filter interface{} `yaml:"filter"`
 }
 
 func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -24,5 +48,159 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Interval == 0 {
 		return fmt.Errorf("missing interval in config")
 	}
+	if err := c.checkRuleNames(nil); err != nil {
+		return err
+	}
+	c.applyDefaults()
+	if err := c.checkNotifierNames(); err != nil {
+		return err
+	}
+	return c.validateRouting()
+}
+
+// checkNotifierNames checks that every notifier has its own name, the
+// notification state and the routes of a notifier are kept by name.
+func (c *Config) checkNotifierNames() error {
+	names := make(map[string]bool)
+	for _, notifier := range c.Notifiers {
+		if names[notifier.Name] {
+			return fmt.Errorf("duplicate notifier name %s", notifier.Name)
+		}
+		names[notifier.Name] = true
+	}
 	return nil
 }
+
+// validateRouting checks that the routes send to configured notifiers, and
+// falls back to the to address of the email config when no fallback is set.
+func (c *Config) validateRouting() error {
+	if c.Routing == nil {
+		return nil
+	}
+	notifiers := make(map[string]bool)
+	for _, notifier := range c.Notifiers {
+		notifiers[notifier.Name] = true
+	}
+	for _, name := range c.Routing.Notifiers() {
+		if !notifiers[name] {
+			return fmt.Errorf("unknown notifier %s in routing", name)
+		}
+	}
+	if c.Routing.Fallback == nil && len(c.EmailConfig.To) > 0 {
+		c.Routing.Fallback = &notify.Receiver{Email: append([]string{}, c.EmailConfig.To...)}
+	}
+	return nil
+}
+
+// kindDefaults returns the defaults for objects of the kind, the per-kind
+// defaults merged with the default filter.
+func (c Config) kindDefaults(kind string) filters.Filter {
+	switch kind {
+	case "Pod":
+		return c.PodDefaults.Filter.WithDefaults(c.DefaultFilter.Filter)
+	case "Deployment":
+		return c.DeploymentDefaults.Filter.WithDefaults(c.DefaultFilter.Filter)
+	case "StatefulSet":
+		return c.StatefulSetDefaults.Filter.WithDefaults(c.DefaultFilter.Filter)
+	}
+	return c.DefaultFilter.Filter
+}
+
+// applyDefaults replaces the filter of every rule by its effective filter.
+func (c *Config) applyDefaults() {
+	podDefaults := filters.PodFilter{Filter: c.kindDefaults("Pod"), ExcludeJobs: c.PodDefaults.ExcludeJobs}
+	for idx := range c.PodRulesLabelsFilledIn {
+		c.PodRulesLabelsFilledIn[idx].Filter = c.PodRulesLabelsFilledIn[idx].Filter.WithDefaults(podDefaults)
+	}
+	for idx := range c.PodRulesLimitsFilledIn {
+		c.PodRulesLimitsFilledIn[idx].Filter = c.PodRulesLimitsFilledIn[idx].Filter.WithDefaults(podDefaults)
+	}
+	for idx := range c.PodRulesRequestsFilledIn {
+		c.PodRulesRequestsFilledIn[idx].Filter = c.PodRulesRequestsFilledIn[idx].Filter.WithDefaults(podDefaults)
+	}
+	for idx := range c.DeploymentRuleReplicasMinimum {
+		filter := &c.DeploymentRuleReplicasMinimum[idx].Filter.Filter
+		*filter = filter.WithDefaults(c.kindDefaults("Deployment"))
+	}
+	for idx := range c.StatefulSetRuleReplicasMinimum {
+		filter := &c.StatefulSetRuleReplicasMinimum[idx].Filter.Filter
+		*filter = filter.WithDefaults(c.kindDefaults("StatefulSet"))
+	}
+	for idx := range c.RegoRules {
+		filter := &c.RegoRules[idx].Filter.Filter
+		*filter = filter.WithDefaults(c.kindDefaults(c.RegoRules[idx].Kind))
+	}
+	for idx := range c.FieldRules {
+		filter := &c.FieldRules[idx].Filter.Filter
+		*filter = filter.WithDefaults(c.kindDefaults(c.FieldRules[idx].Kind))
+	}
+	for idx := range c.ResourceRules {
+		filter := &c.ResourceRules[idx].Filter.Filter
+		*filter = filter.WithDefaults(c.kindDefaults(c.ResourceRules[idx].Resource.Kind))
+	}
+}
+
+// checkRuleNames checks that no two rules share a name, as the results,
+// reports and notifications identify a rule by its name. The names that are
+// already taken are in used.
+func (c Config) checkRuleNames(used map[string]string) error {
+	types := make(map[string]string)
+	for name, ruleType := range used {
+		types[name] = ruleType
+	}
+	for _, ruleInfo := range c.RuleInfos() {
+		if ruleType, ok := types[ruleInfo.Name]; ok {
+			return fmt.Errorf("duplicate rule name %s in %s and %s", ruleInfo.Name, ruleType, ruleInfo.Type)
+		}
+		types[ruleInfo.Name] = ruleInfo.Type
+	}
+	return nil
+}
+
+// RuleInfo describes a configured rule.
+type RuleInfo struct {
+	Type     string
+	Name     string
+	Kind     string
+	Severity rules.Severity
+	Filter   interface{}
+}
+
+// RuleInfos lists every configured rule.
+func (c Config) RuleInfos() []RuleInfo {
+	var ruleInfos []RuleInfo
+	for _, rule := range c.PodRulesLabelsFilledIn {
+		ruleInfos = append(ruleInfos, RuleInfo{"pod_rules_labels_filled_in", rule.Name, "Pod", rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.PodRulesLimitsFilledIn {
+		ruleInfos = append(ruleInfos, RuleInfo{"pod_rules_limits_filled_in", rule.Name, "Pod", rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.PodRulesRequestsFilledIn {
+		ruleInfos = append(ruleInfos, RuleInfo{"pod_rules_requests_filled_in", rule.Name, "Pod", rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.DeploymentRuleReplicasMinimum {
+		ruleInfos = append(ruleInfos, RuleInfo{"deployment_rules_replicas_minimum", rule.Name, "Deployment", rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.StatefulSetRuleReplicasMinimum {
+		ruleInfos = append(ruleInfos, RuleInfo{"stateful_set_rules_replicas_minimum", rule.Name, "StatefulSet", rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.RegoRules {
+		ruleInfos = append(ruleInfos, RuleInfo{"rego_rules", rule.Name, rule.Kind, rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.ResourceRules {
+		ruleInfos = append(ruleInfos, RuleInfo{"resource_rules", rule.Name, rule.Resource.Kind, rule.Severity.OrDefault(), rule.Filter})
+	}
+	for _, rule := range c.FieldRules {
+		ruleInfos = append(ruleInfos, RuleInfo{"field_rules", rule.Name, rule.Kind, rule.Severity.OrDefault(), rule.Filter})
+	}
+	return ruleInfos
+}
+
+// RuleFilters lists the effective filter of every rule.
+func (c Config) RuleFilters() []RuleFilter {
+	var ruleFilters []RuleFilter
+	for _, ruleInfo := range c.RuleInfos() {
+		ruleFilters = append(ruleFilters, RuleFilter{ruleInfo.Type, ruleInfo.Name, ruleInfo.Filter})
+	}
+	return ruleFilters
+}
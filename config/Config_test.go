@@ -2,6 +2,9 @@ package config
 
 import (
 	"testing"
+	"github.com/stijndehaes/kube-conformity/filters"
+	"github.com/stijndehaes/kube-conformity/notify"
+	"github.com/stijndehaes/kube-conformity/rules"
 	"gopkg.in/yaml.v2"
 	"github.com/stretchr/testify/assert"
 	"time"
@@ -100,6 +103,55 @@ stateful_set_rules_replicas_minimum:
 	assert.Len(t, config.StatefulSetRuleReplicasMinimum, 1)
 }
 
+func TestKubeConformityConfig_UnmarshalYAML_RegoRules(t *testing.T) {
+	test := `
+interval: 1h
+rego_rules:
+- name: required labels
+  kind: Pod
+  modules:
+  - policies/required_labels.rego`
+
+	config := Config{}
+
+	yaml.Unmarshal([]byte(test), &config)
+	assert.Len(t, config.RegoRules, 1)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_ResourceRules(t *testing.T) {
+	test := `
+interval: 1h
+resource_rules:
+- name: certificates have an owner
+  resource:
+    group: cert-manager.io
+    version: v1
+    kind: Certificate
+  labels:
+  - owner`
+
+	config := Config{}
+
+	yaml.Unmarshal([]byte(test), &config)
+	assert.Len(t, config.ResourceRules, 1)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_FieldRules(t *testing.T) {
+	test := `
+interval: 1h
+field_rules:
+- name: priority class set
+  kind: Deployment
+  fields:
+  - path: spec.template.spec.priorityClassName
+    operator: exists`
+
+	config := Config{}
+
+	yaml.Unmarshal([]byte(test), &config)
+	assert.Len(t, config.FieldRules, 1)
+}
+
 func TestKubeConformityConfig_UnmarshalYAML_Error(t *testing.T) {
 	test := `random`
 
@@ -110,4 +162,188 @@ func TestKubeConformityConfig_UnmarshalYAML_Error(t *testing.T) {
 	if err == nil {
 		assert.Fail(t, "Should have failed")
 	}
-}
\ No newline at end of file
+}
+func TestKubeConformityConfig_UnmarshalYAML_DefaultFilters(t *testing.T) {
+	test := `
+interval: 1h
+default_filter:
+  exclude_namespaces:
+  - kube-system
+pod_defaults:
+  exclude_jobs: true
+  exclude_namespaces:
+  - kube-public
+deployment_defaults:
+  include_selector: app
+pod_rules_limits_filled_in:
+- name: limits filled in
+  filter:
+    exclude_namespaces:
+    - monitoring
+pod_rules_requests_filled_in:
+- name: requests filled in
+  filter:
+    defaults: override
+    include_namespaces:
+    - kube-system
+deployment_rules_replicas_minimum:
+- name: replicas minimum
+  minimum_replicas: 2
+resource_rules:
+- name: certificates labelled
+  resource:
+    group: cert-manager.io
+    version: v1
+    kind: Certificate
+  labels:
+  - app`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.Nil(t, err)
+	podFilter := config.PodRulesLimitsFilledIn[0].Filter
+	assert.Equal(t, []string{"kube-system", "kube-public", "monitoring"}, podFilter.ExcludeNamespaces)
+	assert.True(t, podFilter.ExcludeJobs)
+	overrideFilter := config.PodRulesRequestsFilledIn[0].Filter
+	assert.Empty(t, overrideFilter.ExcludeNamespaces)
+	assert.False(t, overrideFilter.ExcludeJobs)
+	deploymentFilter := config.DeploymentRuleReplicasMinimum[0].Filter
+	assert.Equal(t, []string{"kube-system"}, deploymentFilter.ExcludeNamespaces)
+	assert.Equal(t, "app", deploymentFilter.IncludeSelector)
+	assert.Equal(t, []string{"kube-system"}, config.ResourceRules[0].Filter.ExcludeNamespaces)
+}
+
+func TestKubeConformityConfig_RuleFilters(t *testing.T) {
+	test := `
+interval: 1h
+default_filter:
+  exclude_namespaces:
+  - kube-system
+pod_rules_limits_filled_in:
+- name: limits filled in`
+
+	config := Config{}
+
+	yaml.Unmarshal([]byte(test), &config)
+	ruleFilters := config.RuleFilters()
+	assert.Len(t, ruleFilters, 1)
+	assert.Equal(t, "pod_rules_limits_filled_in", ruleFilters[0].Type)
+	assert.Equal(t, "limits filled in", ruleFilters[0].Name)
+	assert.Equal(t, []string{"kube-system"}, ruleFilters[0].Filter.(filters.PodFilter).ExcludeNamespaces)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_Outputs(t *testing.T) {
+	test := `
+interval: 1h
+outputs:
+- format: junit
+  destination: /reports/conformity.xml
+- format: json`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.Nil(t, err)
+	assert.Len(t, config.Outputs, 2)
+	assert.Equal(t, "junit", config.Outputs[0].Format)
+	assert.Equal(t, "/reports/conformity.xml", config.Outputs[0].Destination)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_Notifiers(t *testing.T) {
+	test := `
+interval: 1h
+notifiers:
+- name: platform
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXXX
+  min_severity: high`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.Nil(t, err)
+	assert.Len(t, config.Notifiers, 1)
+	assert.Equal(t, notify.NotifierSlack, config.Notifiers[0].Type)
+	assert.Equal(t, rules.SeverityHigh, config.Notifiers[0].MinSeverity)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_DuplicateNotifierName(t *testing.T) {
+	test := `
+interval: 1h
+notifiers:
+- name: platform
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXXX
+- name: platform
+  type: teams
+  url: https://example.webhook.office.com/webhookb2/XXXX`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.EqualError(t, err, "duplicate notifier name platform")
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_Routing(t *testing.T) {
+	test := `
+interval: 1h
+email_config:
+  to: admin@example.com
+  host: smtp.example.com
+notifiers:
+- name: payments
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXXX
+routing:
+  routes:
+  - namespace_selector: team=payments
+    notifiers: [payments]`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, notify.DefaultOwnerAnnotation, config.Routing.OwnerAnnotation)
+	assert.Equal(t, &notify.Receiver{Email: []string{"admin@example.com"}}, config.Routing.Fallback)
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_RoutingUnknownNotifier(t *testing.T) {
+	test := `
+interval: 1h
+routing:
+  routes:
+  - rules: [app label]
+    notifiers: [payments]`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.EqualError(t, err, "unknown notifier payments in routing")
+}
+
+func TestKubeConformityConfig_UnmarshalYAML_DuplicateRuleName(t *testing.T) {
+	test := `
+interval: 1h
+pod_rules_labels_filled_in:
+- name: app
+  labels: [app]
+resource_rules:
+- name: app
+  resource:
+    version: v1
+    kind: Service
+  labels: [app]`
+
+	config := Config{}
+
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.EqualError(t, err, "duplicate rule name app in pod_rules_labels_filled_in and resource_rules")
+}
@@ -3,12 +3,12 @@ package config
 import (
 	"fmt"
 	"bytes"
-	"net/smtp"
 	"github.com/stijndehaes/kube-conformity/rules"
 	"html/template"
-	"strconv"
-	"encoding/base64"
+	"mime"
+	texttemplate "text/template"
 	"os"
+	"time"
 )
 
 var (
@@ -16,22 +16,30 @@ var (
 		Enabled:  false,
 		Subject:  "kube-conformity",
 		Template: "mailtemplate.html",
-		Port:     24,
 		From:     "no-reply@kube-conformity.com",
+		TLS:      TLSStartTLS,
 	}
 )
 
 type EmailConfig struct {
-	Enabled      bool   `yaml:"enabled"`
-	To           string `yaml:"to"`
-	From         string `yaml:"from"`
-	Host         string `yaml:"host"`
-	Port         int    `yaml:"port"`
-	Subject      string `yaml:"subject"`
-	AuthUsername string `yaml:"auth_username"`
-	AuthPassword string `yaml:"auth_password"`
-	AuthIdentity string `yaml:"auth_identity"`
-	Template     string `yaml:"template"`
+	Enabled            bool           `yaml:"enabled"`
+	To                 Addresses      `yaml:"to"`
+	Cc                 Addresses      `yaml:"cc"`
+	Bcc                Addresses      `yaml:"bcc"`
+	From               string         `yaml:"from"`
+	Host               string         `yaml:"host"`
+	Port               int            `yaml:"port"`
+	TLS                string         `yaml:"tls"`
+	CAFile             string         `yaml:"ca_file"`
+	InsecureSkipVerify bool           `yaml:"insecure_skip_verify"`
+	Subject            string         `yaml:"subject"`
+	Auth               string         `yaml:"auth"`
+	AuthUsername       string         `yaml:"auth_username"`
+	AuthPassword       string         `yaml:"auth_password"`
+	AuthIdentity       string         `yaml:"auth_identity"`
+	Template           string         `yaml:"template"`
+	TextTemplate       string         `yaml:"text_template"`
+	MinSeverity        rules.Severity `yaml:"min_severity"`
 }
 
 func (emailConfig *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -40,12 +48,25 @@ func (emailConfig *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error)
 	if err := unmarshal((*plain)(emailConfig)); err != nil {
 		return err
 	}
-	if emailConfig.To == "" {
+	if len(emailConfig.To) == 0 {
 		return fmt.Errorf("missing to address in email config")
 	}
 	if emailConfig.Host == "" {
 		return fmt.Errorf("missing host in email config")
 	}
+	if _, err := (Addresses{emailConfig.From}).Envelope(); err != nil {
+		return fmt.Errorf("invalid from address in email config: %v", err)
+	}
+	switch emailConfig.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return fmt.Errorf("unknown tls mode %s in email config, must be %s, %s or %s", emailConfig.TLS, TLSStartTLS, TLSImplicit, TLSNone)
+	}
+	switch emailConfig.Auth {
+	case "", AuthNone, AuthPlain, AuthLogin, AuthCRAMMD5:
+	default:
+		return fmt.Errorf("unknown auth mechanism %s in email config, must be %s, %s, %s or %s", emailConfig.Auth, AuthNone, AuthPlain, AuthLogin, AuthCRAMMD5)
+	}
 	authPassword := os.Getenv("CONFORMITY_EMAIL_AUTH_PASSWORD")
 	if authPassword != "" {
 		emailConfig.AuthPassword = authPassword
@@ -53,14 +74,12 @@ func (emailConfig *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error)
 	return nil
 }
 
-func (emailConfig EmailConfig) RenderTemplate(podRuleResults []rules.PodRuleResult, deploymentResults []rules.DeploymentRuleResult) (string, error) {
-	templateData := struct {
-		PodRuleResults []rules.PodRuleResult
-		DeploymentRuleResults []rules.DeploymentRuleResult
-	}{
-		PodRuleResults: podRuleResults,
-		DeploymentRuleResults: deploymentResults,
-	}
+// RenderTemplate renders the html template with the data.
+func (emailConfig EmailConfig) RenderTemplate(data MailData) (string, error) {
+	return emailConfig.renderTemplate(data)
+}
+
+func (emailConfig EmailConfig) renderTemplate(templateData MailData) (string, error) {
 	t, err := template.ParseFiles(emailConfig.Template)
 	if err != nil {
 		return "", err
@@ -72,40 +91,69 @@ func (emailConfig EmailConfig) RenderTemplate(podRuleResults []rules.PodRuleResu
 	return buf.String(), nil
 }
 
-func(emailConfig EmailConfig) GetMailHeaders() map[string]string {
-	headers := make(map[string]string)
-	headers["From"] = emailConfig.From
-	headers["To"] = emailConfig.To
-	headers["Subject"] = emailConfig.Subject + "!"
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=\"utf-8\""
-	headers["Content-Transfer-Encoding"] = "base64"
-	return headers
+// renderText renders the text template, or turns the rendered html into text
+// when there is no text template.
+func (emailConfig EmailConfig) renderText(templateData MailData, html string) (string, error) {
+	if emailConfig.TextTemplate == "" {
+		return htmlToText(html), nil
+	}
+	t, err := texttemplate.ParseFiles(emailConfig.TextTemplate)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err = t.Execute(buf, templateData); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
-func ConstructHeadersString(headers map[string]string) string {
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+// GetMailHeaders returns the headers of a mail sent at the date, Bcc
+// recipients are left out.
+func (emailConfig EmailConfig) GetMailHeaders(date time.Time, messageID string) Headers {
+	headers := Headers{
+		{"From", emailConfig.From},
+		{"To", emailConfig.To.String()},
 	}
-	return message
+	if len(emailConfig.Cc) > 0 {
+		headers = append(headers, Header{"Cc", emailConfig.Cc.String()})
+	}
+	return append(headers,
+		Header{"Subject", mime.QEncoding.Encode("utf-8", emailConfig.Subject+"!")},
+		Header{"Date", date.Format(time.RFC1123Z)},
+		Header{"Message-ID", messageID},
+		Header{"MIME-Version", "1.0"},
+	)
+}
+
+func (emailConfig EmailConfig) ConstructEmailBody(data MailData) ([]byte, error) {
+	return emailConfig.constructMessage(data, time.Now())
 }
 
-func (emailConfig EmailConfig) ConstructEmailBody(podRuleResults []rules.PodRuleResult, deploymentResults []rules.DeploymentRuleResult) ([]byte, error) {
-	headers := ConstructHeadersString(emailConfig.GetMailHeaders())
-	body, err := emailConfig.RenderTemplate(podRuleResults, deploymentResults)
+// constructMessage renders the templates with the data into a multipart mail
+// with a text and an html part.
+func (emailConfig EmailConfig) constructMessage(templateData MailData, date time.Time) ([]byte, error) {
+	html, err := emailConfig.renderTemplate(templateData)
+	if err != nil {
+		return []byte{}, err
+	}
+	text, err := emailConfig.renderText(templateData, html)
+	if err != nil {
+		return []byte{}, err
+	}
+	body := new(bytes.Buffer)
+	contentType, err := multipartBody(body, text, html)
 	if err != nil {
 		return []byte{}, err
 	}
-	return []byte(headers + "\n" + base64.StdEncoding.EncodeToString([]byte(body))), nil
+	headers := append(emailConfig.GetMailHeaders(date, newMessageID(emailConfig.From, date)), Header{"Content-Type", contentType})
+	return append([]byte(ConstructHeadersString(headers)+"\r\n"), body.Bytes()...), nil
 }
 
-func (emailConfig EmailConfig) SendMail(podRuleResults []rules.PodRuleResult, deploymentRuleResults []rules.DeploymentRuleResult) error {
-	msg, err := emailConfig.ConstructEmailBody(podRuleResults, deploymentRuleResults)
+func (emailConfig EmailConfig) SendMail(data MailData) error {
+	msg, err := emailConfig.ConstructEmailBody(data)
 	if err != nil {
 		return err
 	}
-	auth := smtp.PlainAuth(emailConfig.AuthIdentity, emailConfig.AuthUsername, emailConfig.AuthPassword, emailConfig.Host)
-	err = smtp.SendMail(emailConfig.Host+":"+strconv.Itoa(emailConfig.Port), auth, emailConfig.From, []string{emailConfig.To}, msg)
-	return err
+	return emailConfig.send(msg)
 }
@@ -4,8 +4,12 @@ import (
 	"testing"
 	"gopkg.in/yaml.v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stijndehaes/kube-conformity/reports"
 	"github.com/stijndehaes/kube-conformity/rules"
 	"os"
+	"time"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestEmailConfig_UnmarshalYAML_FailMissingHost(t *testing.T) {
@@ -48,7 +52,8 @@ to: test@gmail.com`
 	}
 
 	assert.Equal(t, "mailtemplate.html", config.Template)
-	assert.Equal(t, 24, config.Port)
+	assert.Equal(t, 587, config.port())
+	assert.Equal(t, TLSStartTLS, config.TLS)
 	assert.Equal(t, "kube-conformity", config.Subject)
 	assert.Equal(t, "no-reply@kube-conformity.com", config.From)
 	assert.False(t, config.Enabled)
@@ -70,31 +75,60 @@ to: test@gmail.com`
 	assert.Equal(t, "secret", config.AuthPassword)
 }
 
-var podRuleResults = []rules.PodRuleResult{
-	{
-		Reason:   "A reason",
-		RuleName: "A rule name",
+var mailReport = reports.Report{
+	Summary: reports.Summary{Rules: 2, Violations: 2, Exempted: 1, ViolationsBySeverity: map[string]int{"high": 1, "medium": 1}},
+	Rules: []reports.Rule{
+		{Name: "app label", Type: "pod_rules_labels_filled_in", Kind: "Pod", Severity: "high"},
+		{Name: "replicas minimum", Type: "stateful_set_rules_replicas_minimum", Kind: "StatefulSet", Severity: "medium"},
+	},
+	Violations: []reports.Entry{
+		{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"},
+		{RuleName: "replicas minimum", Severity: "medium", Kind: "StatefulSet", Namespace: "default", Name: "database", Reason: "StatefulSet replicas below the minimum: 2"},
+	},
+	Exempted: []reports.Entry{
+		{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "exempted-pod", Reason: "Labels: [app] are not filled in", ExemptionReason: "Migrating to a new chart"},
 	},
 }
 
-var deploymentRuleResults = []rules.DeploymentRuleResult{
-{
-Reason:   "A reason",
-RuleName: "A rule name",
-},
-}
+var mailData = NewMailData(mailReport, "production", time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC))
 
 func TestEmailConfig_RenderTemplate(t *testing.T) {
 	eConfig := DefaultEmailConfig
 	eConfig.Enabled = true
 	eConfig.Template = "../mailtemplate.html"
 
-	template, err := eConfig.RenderTemplate(podRuleResults, deploymentRuleResults)
+	template, err := eConfig.RenderTemplate(mailData)
 
 	if err != nil {
 		assert.Fail(t, "Template should render correctly")
 	}
-	assert.NotEqual(t, "", template)
+	assert.Contains(t, template, "Kube conformity for production")
+	assert.Contains(t, template, "Run at 2019-06-15 12:00:00 UTC: 2 violations and 1 exempted objects for 2 rules.")
+	assert.Contains(t, template, "<tr><td>high</td><td class=\"number\">1</td></tr>")
+	assert.Contains(t, template, "<tr><td>replicas minimum</td><td>StatefulSet</td><td>medium</td><td class=\"number\">1</td><td class=\"number\">0</td></tr>")
+	assert.Contains(t, template, "<td>StatefulSet</td><td>default</td><td>database</td>")
+	assert.Contains(t, template, "exempted-pod")
+	assert.Contains(t, template, "Migrating to a new chart")
+	assert.NotContains(t, template, "New violations")
+}
+
+func TestEmailConfig_RenderTemplate_LegacyResults(t *testing.T) {
+	eConfig := DefaultEmailConfig
+	eConfig.Template = "legacy.html"
+	file, err := os.Create(eConfig.Template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(eConfig.Template)
+	file.WriteString(`{{ range .PodRuleResults }}{{ .RuleName }}: {{ range .Pods }}{{ .Name }}{{ end }}{{ end }}`)
+	file.Close()
+	data := mailData
+	data.PodRuleResults = []rules.PodRuleResult{{RuleName: "app label", Pods: []v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}}}}
+
+	template, err := eConfig.RenderTemplate(data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "app label: foo", template)
 }
 
 func TestEmailConfig_ConstructEmailBody(t *testing.T) {
@@ -102,7 +136,7 @@ func TestEmailConfig_ConstructEmailBody(t *testing.T) {
 	eConfig.Enabled = true
 	eConfig.Template = "../mailtemplate.html"
 
-	body, err := eConfig.ConstructEmailBody(podRuleResults, deploymentRuleResults)
+	body, err := eConfig.ConstructEmailBody(mailData)
 
 	if err != nil {
 		assert.Fail(t, "Body should render correctly")
@@ -114,7 +148,7 @@ func TestEmailConfig_ConstructEmailBody_TemplateNoExist(t *testing.T) {
 	eConfig := DefaultEmailConfig
 	eConfig.Enabled = true
 	eConfig.Template = "test.html"
-	body, err := eConfig.ConstructEmailBody(nil, nil)
+	body, err := eConfig.ConstructEmailBody(MailData{})
 	assert.NotEqual(t, nil, err, "Should fail because template does not exist")
 	assert.Equal(t, []byte{}, body)
 }
@@ -133,23 +167,78 @@ func TestEmailConfig_UnmarshalYAML_Error(t *testing.T) {
 
 func TestEmailConfig_GetMailHeaders(t *testing.T) {
 	eConfig := DefaultEmailConfig
-	eConfig.To = "test@mail.com"
-	headers := eConfig.GetMailHeaders()
-
-	assert.Equal(t, "no-reply@kube-conformity.com", headers["From"])
-	assert.Equal(t, "test@mail.com", headers["To"])
-	assert.Equal(t, "kube-conformity!", headers["Subject"])
-	assert.Equal(t, "1.0", headers["MIME-Version"])
-	assert.Equal(t, "text/html; charset=\"utf-8\"", headers["Content-Type"])
-	assert.Equal(t, "base64", headers["Content-Transfer-Encoding"])
+	eConfig.To = Addresses{"test@mail.com", "other@mail.com"}
+	eConfig.Cc = Addresses{"cc@mail.com"}
+	eConfig.Bcc = Addresses{"bcc@mail.com"}
+	headers := eConfig.GetMailHeaders(time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC), "<1@kube-conformity.com>")
+
+	assert.Equal(t, Headers{
+		{"From", "no-reply@kube-conformity.com"},
+		{"To", "test@mail.com, other@mail.com"},
+		{"Cc", "cc@mail.com"},
+		{"Subject", "kube-conformity!"},
+		{"Date", "Sat, 15 Jun 2019 12:00:00 +0000"},
+		{"Message-ID", "<1@kube-conformity.com>"},
+		{"MIME-Version", "1.0"},
+	}, headers)
+	assert.Equal(t, "cc@mail.com", headers.Get("Cc"))
 }
 
-func TestConstructHeadersString(t *testing.T) {
-	headers := make(map[string]string)
-	headers["Test1"] = "test1"
-	headers["Test2"] = "test2"
+func TestEmailConfig_UnmarshalYAML_Recipients(t *testing.T) {
+	test := `
+host: smtp.example.com
+to: [a@example.com, b@example.com]
+cc: lead@example.com
+bcc: [audit@example.com]
+tls: tls
+ca_file: /etc/ssl/smtp-ca.pem
+auth: login`
 
-	string := ConstructHeadersString(headers)
+	config := EmailConfig{}
 
-	assert.True(t, "Test1: test1\r\nTest2: test2\r\n" == string || "Test2: test2\r\nTest1: test1\r\n" == string)
+	err := yaml.Unmarshal([]byte(test), &config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Addresses{"a@example.com", "b@example.com"}, config.To)
+	assert.Equal(t, Addresses{"lead@example.com"}, config.Cc)
+	assert.Equal(t, Addresses{"audit@example.com"}, config.Bcc)
+	assert.Equal(t, TLSImplicit, config.TLS)
+	assert.Equal(t, 465, config.port())
+	assert.Equal(t, AuthLogin, config.Auth)
+}
+
+func TestEmailConfig_UnmarshalYAML_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown tls mode ssl":         "host: smtp.example.com\nto: a@example.com\ntls: ssl",
+		"unknown auth mechanism xoauth": "host: smtp.example.com\nto: a@example.com\nauth: xoauth",
+		"invalid from address":         "host: smtp.example.com\nto: a@example.com\nfrom: nobody",
+		"invalid mail address":         "host: smtp.example.com\nto: [a@example.com, nobody]",
+	}
+	for message, test := range tests {
+		config := EmailConfig{}
+
+		err := yaml.Unmarshal([]byte(test), &config)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+}
+
+func TestEmailConfig_RenderTemplate_Diff(t *testing.T) {
+	eConfig := DefaultEmailConfig
+	eConfig.Enabled = true
+	eConfig.Template = "../mailtemplate.html"
+	data := mailData
+	data.Diff = true
+	data.NewViolations = []reports.Entry{{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "new-pod", Reason: "Labels: [app] are not filled in"}}
+	data.ResolvedViolations = []reports.Entry{{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "fixed-pod", Reason: "Labels: [app] are not filled in"}}
+
+	template, err := eConfig.RenderTemplate(data)
+
+	assert.Nil(t, err)
+	assert.Contains(t, template, "New violations")
+	assert.Contains(t, template, "new-pod")
+	assert.Contains(t, template, "Resolved violations")
+	assert.Contains(t, template, "fixed-pod")
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	DefaultEventConfig = EventConfig{
+		Enabled:  false,
+		Interval: time.Hour,
+		QPS:      1,
+		Burst:    25,
+	}
+)
+
+// EventConfig enables Warning events on the objects that violate a rule. An
+// event of an object and rule is only recorded again once the interval has
+// passed, and events are recorded at no more than qps per second with bursts
+// of at most burst events.
+type EventConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	QPS      float32       `yaml:"qps"`
+	Burst    int           `yaml:"burst"`
+}
+
+func (eventConfig *EventConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*eventConfig = DefaultEventConfig
+	type plain EventConfig
+	if err := unmarshal((*plain)(eventConfig)); err != nil {
+		return err
+	}
+	if eventConfig.Interval <= 0 {
+		return fmt.Errorf("interval of the event config must be positive")
+	}
+	if eventConfig.QPS <= 0 || eventConfig.Burst <= 0 {
+		return fmt.Errorf("qps and burst of the event config must be positive")
+	}
+	return nil
+}
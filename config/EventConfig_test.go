@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestEventConfig_UnmarshalYAML_Defaults(t *testing.T) {
+	eventConfig := EventConfig{}
+
+	err := yaml.Unmarshal([]byte("enabled: true"), &eventConfig)
+
+	assert.Nil(t, err)
+	assert.Equal(t, EventConfig{Enabled: true, Interval: time.Hour, QPS: 1, Burst: 25}, eventConfig)
+}
+
+func TestEventConfig_UnmarshalYAML(t *testing.T) {
+	eventConfig := EventConfig{}
+
+	err := yaml.Unmarshal([]byte("enabled: true\ninterval: 30m\nqps: 0.5\nburst: 10"), &eventConfig)
+
+	assert.Nil(t, err)
+	assert.Equal(t, EventConfig{Enabled: true, Interval: 30 * time.Minute, QPS: 0.5, Burst: 10}, eventConfig)
+}
+
+func TestEventConfig_UnmarshalYAML_Error(t *testing.T) {
+	eventConfig := EventConfig{}
+
+	assert.EqualError(t, yaml.Unmarshal([]byte("enabled: true\ninterval: -1m"), &eventConfig), "interval of the event config must be positive")
+	assert.EqualError(t, yaml.Unmarshal([]byte("enabled: true\nburst: 0"), &eventConfig), "qps and burst of the event config must be positive")
+}
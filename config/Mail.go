@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// base64LineLength is the maximum length of the lines of a base64 encoded
+// body, see RFC 2045.
+const base64LineLength = 76
+
+// Addresses is a list of mail addresses. In the config it is a list or a
+// single string with the addresses separated by commas.
+type Addresses []string
+
+func (a *Addresses) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		var value string
+		if err := unmarshal(&value); err != nil {
+			return err
+		}
+		list = strings.Split(value, ",")
+	}
+	*a = nil
+	for _, address := range list {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid mail address %s: %v", address, err)
+		}
+		*a = append(*a, address)
+	}
+	return nil
+}
+
+func (a Addresses) String() string {
+	return strings.Join(a, ", ")
+}
+
+// Envelope returns the bare addresses without display names, as used in the
+// SMTP envelope.
+func (a Addresses) Envelope() ([]string, error) {
+	var envelope []string
+	for _, address := range a {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mail address %s: %v", address, err)
+		}
+		envelope = append(envelope, parsed.Address)
+	}
+	return envelope, nil
+}
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Headers are the headers of a mail in the order they are written.
+type Headers []Header
+
+// Get returns the value of the first header with the name.
+func (h Headers) Get(name string) string {
+	for _, header := range h {
+		if header.Name == name {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+func ConstructHeadersString(headers Headers) string {
+	message := ""
+	for _, header := range headers {
+		message += fmt.Sprintf("%s: %s\r\n", header.Name, header.Value)
+	}
+	return message
+}
+
+// newMessageID returns a unique message id in the domain of the sender.
+func newMessageID(from string, date time.Time) string {
+	domain := "kube-conformity"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(address.Address, "@"); at >= 0 {
+			domain = address.Address[at+1:]
+		}
+	}
+	random := make([]byte, 8)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", date.UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// encodeBase64 encodes the content in lines of at most 76 characters.
+func encodeBase64(content []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	lines := make([]string, 0, len(encoded)/base64LineLength+1)
+	for len(encoded) > base64LineLength {
+		lines = append(lines, encoded[:base64LineLength])
+		encoded = encoded[base64LineLength:]
+	}
+	lines = append(lines, encoded)
+	return strings.Join(lines, "\r\n")
+}
+
+// multipartBody writes the text and html as alternative parts of a body and
+// returns the content type of the body.
+func multipartBody(buf *bytes.Buffer, text string, html string) (string, error) {
+	writer := multipart.NewWriter(buf)
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=\"utf-8\"", text},
+		{"text/html; charset=\"utf-8\"", html},
+	}
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err := partWriter.Write([]byte(encodeBase64([]byte(part.content)) + "\r\n")); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": writer.Boundary()}), nil
+}
+
+var (
+	htmlComments   = regexp.MustCompile(`(?s)<!--.*?-->|<!DOCTYPE[^>]*>|<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	whitespace     = regexp.MustCompile(`\s+`)
+	htmlParagraphs = regexp.MustCompile(`(?i)</?(p|h[1-6]|ul|ol|table)(\s[^>]*)?>`)
+	htmlLineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(li|tr|div)>`)
+	htmlListItems  = regexp.MustCompile(`(?i)<li(\s[^>]*)?>`)
+	htmlCellEnds   = regexp.MustCompile(`(?i)</t[dh]>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText turns a rendered html template into readable plain text, for the
+// text part of mails without a text template. Like a browser it ignores the
+// line breaks of the source and breaks lines on paragraphs, list items and
+// table rows.
+func htmlToText(content string) string {
+	content = htmlComments.ReplaceAllString(content, "")
+	content = whitespace.ReplaceAllString(content, " ")
+	content = htmlParagraphs.ReplaceAllString(content, "\n\n")
+	content = htmlLineBreaks.ReplaceAllString(content, "\n")
+	content = htmlListItems.ReplaceAllString(content, "- ")
+	content = htmlCellEnds.ReplaceAllString(content, " ")
+	content = htmlTags.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	content = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(content) + "\n"
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"sort"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+)
+
+// MailData is what the mail templates render. Violations and Exempted hold
+// the objects of every kind, the rule results per kind are kept for templates
+// written before them. In a diff mail NewViolations and ResolvedViolations
+// hold the violations that changed since the last mail.
+type MailData struct {
+	Summary                MailSummary
+	Violations             []reports.Entry
+	Exempted               []reports.Entry
+	PodRuleResults         []rules.PodRuleResult
+	DeploymentRuleResults  []rules.DeploymentRuleResult
+	StatefulSetRuleResults []rules.StatefulSetRuleResult
+	ExemptedResults        []rules.ExemptedResult
+	Diff                   bool
+	NewViolations          []reports.Entry
+	ResolvedViolations     []reports.Entry
+}
+
+// MailSummary sums up a run, the rules with the most violations first and
+// the severities from critical to info.
+type MailSummary struct {
+	ClusterName string
+	RunAt       time.Time
+	Rules       int
+	Violations  int
+	Exempted    int
+	ByRule      []RuleCount
+	BySeverity  []SeverityCount
+}
+
+type RuleCount struct {
+	Rule       reports.Rule
+	Violations int
+	Exempted   int
+}
+
+type SeverityCount struct {
+	Severity   string
+	Violations int
+}
+
+// NewMailData returns the data of a mail with all violations of the report.
+func NewMailData(report reports.Report, clusterName string, runAt time.Time) MailData {
+	summary := MailSummary{
+		ClusterName: clusterName,
+		RunAt:       runAt,
+		Rules:       report.Summary.Rules,
+		Violations:  report.Summary.Violations,
+		Exempted:    report.Summary.Exempted,
+	}
+	for _, rule := range report.Rules {
+		count := RuleCount{Rule: rule, Violations: len(report.ViolationsOf(rule.Name))}
+		for _, exempted := range report.Exempted {
+			if exempted.RuleName == rule.Name {
+				count.Exempted++
+			}
+		}
+		if count.Violations > 0 || count.Exempted > 0 {
+			summary.ByRule = append(summary.ByRule, count)
+		}
+	}
+	sort.SliceStable(summary.ByRule, func(i, j int) bool {
+		return summary.ByRule[i].Violations > summary.ByRule[j].Violations
+	})
+	for idx := len(rules.Severities) - 1; idx >= 0; idx-- {
+		severity := string(rules.Severities[idx])
+		summary.BySeverity = append(summary.BySeverity, SeverityCount{Severity: severity, Violations: report.Summary.ViolationsBySeverity[severity]})
+	}
+	return MailData{
+		Summary:    summary,
+		Violations: report.Violations,
+		Exempted:   report.Exempted,
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMailData(t *testing.T) {
+	runAt := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	data := NewMailData(mailReport, "production", runAt)
+
+	assert.Equal(t, MailSummary{
+		ClusterName: "production",
+		RunAt:       runAt,
+		Rules:       2,
+		Violations:  2,
+		Exempted:    1,
+		ByRule: []RuleCount{
+			{Rule: mailReport.Rules[0], Violations: 1, Exempted: 1},
+			{Rule: mailReport.Rules[1], Violations: 1},
+		},
+		BySeverity: []SeverityCount{
+			{Severity: "critical"},
+			{Severity: "high", Violations: 1},
+			{Severity: "medium", Violations: 1},
+			{Severity: "low"},
+			{Severity: "info"},
+		},
+	}, data.Summary)
+	assert.Equal(t, mailReport.Violations, data.Violations)
+	assert.Equal(t, mailReport.Exempted, data.Exempted)
+	assert.False(t, data.Diff)
+}
+
+func TestNewMailData_RulesWithoutViolationsLeftOut(t *testing.T) {
+	report := reports.Report{
+		Summary: reports.Summary{Rules: 2, Violations: 2, ViolationsBySeverity: map[string]int{"low": 2}},
+		Rules:   []reports.Rule{{Name: "passing"}, {Name: "failing"}},
+		Violations: []reports.Entry{
+			{RuleName: "failing", Severity: "low"},
+			{RuleName: "failing", Severity: "low"},
+		},
+	}
+
+	data := NewMailData(report, "", time.Time{})
+
+	assert.Equal(t, []RuleCount{{Rule: reports.Rule{Name: "failing"}, Violations: 2}}, data.Summary.ByRule)
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAddresses_UnmarshalYAML(t *testing.T) {
+	var list, single Addresses
+
+	assert.Nil(t, yaml.Unmarshal([]byte(`[a@example.com, Team B <b@example.com>]`), &list))
+	assert.Nil(t, yaml.Unmarshal([]byte(`a@example.com, b@example.com`), &single))
+
+	assert.Equal(t, Addresses{"a@example.com", "Team B <b@example.com>"}, list)
+	assert.Equal(t, Addresses{"a@example.com", "b@example.com"}, single)
+}
+
+func TestAddresses_UnmarshalYAML_Invalid(t *testing.T) {
+	var addresses Addresses
+
+	err := yaml.Unmarshal([]byte(`[not an address]`), &addresses)
+
+	assert.Error(t, err)
+}
+
+func TestAddresses_Envelope(t *testing.T) {
+	envelope, err := Addresses{"a@example.com", "Team B <b@example.com>"}.Envelope()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, envelope)
+}
+
+func TestConstructHeadersString(t *testing.T) {
+	headers := Headers{{"Test2", "test2"}, {"Test1", "test1"}}
+
+	assert.Equal(t, "Test2: test2\r\nTest1: test1\r\n", ConstructHeadersString(headers))
+}
+
+func TestNewMessageID(t *testing.T) {
+	date := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	messageID := newMessageID("Kube conformity <no-reply@kube-conformity.com>", date)
+
+	assert.True(t, strings.HasPrefix(messageID, "<1560600000000000000."), messageID)
+	assert.True(t, strings.HasSuffix(messageID, "@kube-conformity.com>"), messageID)
+	assert.NotEqual(t, messageID, newMessageID("no-reply@kube-conformity.com", date))
+}
+
+func TestEncodeBase64(t *testing.T) {
+	content := []byte(strings.Repeat("kube-conformity ", 10))
+
+	encoded := encodeBase64(content)
+
+	lines := strings.Split(encoded, "\r\n")
+	assert.Len(t, lines, 3)
+	assert.Len(t, lines[0], 76)
+	assert.Len(t, lines[1], 76)
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, content, decoded)
+}
+
+func TestHTMLToText(t *testing.T) {
+	html := `<!DOCTYPE html>
+<html><body>
+<h2>New violations</h2>
+<ul>
+    <li>Pod name: foo, reason: Labels &amp; more</li>
+    <li>Pod name: bar</li>
+</ul>
+<table><tr><th>Rule</th><th>Count</th></tr><tr><td>labels</td><td>2</td></tr></table>
+</body></html>`
+
+	assert.Equal(t, "New violations\n\n- Pod name: foo, reason: Labels & more\n- Pod name: bar\n\nRule Count\nlabels 2\n", htmlToText(html))
+}
+
+func TestEmailConfig_ConstructMessage(t *testing.T) {
+	eConfig := DefaultEmailConfig
+	eConfig.Template = "../mailtemplate.html"
+	eConfig.To = Addresses{"a@example.com", "b@example.com"}
+	eConfig.Bcc = Addresses{"audit@example.com"}
+	date := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	msg, err := eConfig.constructMessage(MailData{Diff: true}, date)
+
+	assert.Nil(t, err)
+	headerNames := []string{}
+	for _, line := range strings.Split(strings.SplitN(string(msg), "\r\n\r\n", 2)[0], "\r\n") {
+		headerNames = append(headerNames, strings.SplitN(line, ":", 2)[0])
+	}
+	assert.Equal(t, []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}, headerNames)
+	message, err := mail.ReadMessage(bytes.NewReader(msg))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "a@example.com, b@example.com", message.Header.Get("To"))
+	assert.Equal(t, "Sat, 15 Jun 2019 12:00:00 +0000", message.Header.Get("Date"))
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var contentTypes, bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		assert.Equal(t, "base64", part.Header.Get("Content-Transfer-Encoding"))
+		encoded, _ := ioutil.ReadAll(part)
+		for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+			assert.True(t, len(line) <= 76)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.Replace(string(encoded), "\r\n", "", -1))
+		assert.Nil(t, err)
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+		bodies = append(bodies, string(decoded))
+	}
+	assert.Equal(t, []string{`text/plain; charset="utf-8"`, `text/html; charset="utf-8"`}, contentTypes)
+	if assert.Len(t, bodies, 2) {
+		assert.Contains(t, bodies[0], "New violations\n\nNone\n\nResolved violations")
+		assert.NotContains(t, bodies[0], "<h2>")
+		assert.Contains(t, bodies[1], "<h2>New violations</h2>")
+	}
+}
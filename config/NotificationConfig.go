@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/rules"
+)
+
+const (
+	NotifyFull = "full"
+	NotifyDiff = "diff"
+)
+
+// NotificationConfig decides what the notifications of a run contain. In full
+// mode every run sends all violations, in diff mode only the violations that
+// are new or resolved since the last notification, with all violations sent
+// as a digest once every digest interval. The violations of the last
+// notification are kept in a state file or config map to survive restarts.
+type NotificationConfig struct {
+	Mode           string                    `yaml:"mode"`
+	DigestInterval time.Duration             `yaml:"digest_interval"`
+	StateFile      string                    `yaml:"state_file"`
+	StateConfigMap *rules.ConfigMapReference `yaml:"state_config_map"`
+}
+
+func (n *NotificationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain NotificationConfig
+	if err := unmarshal((*plain)(n)); err != nil {
+		return err
+	}
+	switch n.Mode {
+	case "", NotifyFull:
+		n.Mode = NotifyFull
+	case NotifyDiff:
+		if n.StateFile == "" && n.StateConfigMap == nil {
+			return fmt.Errorf("missing state_file or state_config_map for diff notifications")
+		}
+	default:
+		return fmt.Errorf("unknown notification mode %s, must be %s or %s", n.Mode, NotifyFull, NotifyDiff)
+	}
+	if n.StateConfigMap != nil && (n.StateConfigMap.Namespace == "" || n.StateConfigMap.Name == "") {
+		return fmt.Errorf("missing namespace or name for state_config_map")
+	}
+	return nil
+}
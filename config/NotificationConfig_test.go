@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNotificationConfig_UnmarshalYAML_Default(t *testing.T) {
+	notifications := NotificationConfig{}
+
+	err := yaml.Unmarshal([]byte(`digest_interval: 24h`), &notifications)
+
+	assert.Nil(t, err)
+	assert.Equal(t, NotifyFull, notifications.Mode)
+	assert.Equal(t, 24*time.Hour, notifications.DigestInterval)
+}
+
+func TestNotificationConfig_UnmarshalYAML_Diff(t *testing.T) {
+	test := `
+mode: diff
+digest_interval: 24h
+state_config_map:
+  namespace: kube-conformity
+  name: kube-conformity-state`
+
+	notifications := NotificationConfig{}
+
+	err := yaml.Unmarshal([]byte(test), &notifications)
+
+	assert.Nil(t, err)
+	assert.Equal(t, NotifyDiff, notifications.Mode)
+	assert.Equal(t, "kube-conformity-state", notifications.StateConfigMap.Name)
+}
+
+func TestNotificationConfig_UnmarshalYAML_DiffMissingState(t *testing.T) {
+	notifications := NotificationConfig{}
+
+	err := yaml.Unmarshal([]byte(`mode: diff`), &notifications)
+
+	assert.NotNil(t, err)
+}
+
+func TestNotificationConfig_UnmarshalYAML_UnknownMode(t *testing.T) {
+	notifications := NotificationConfig{}
+
+	err := yaml.Unmarshal([]byte(`mode: weekly`), &notifications)
+
+	assert.NotNil(t, err)
+}
+
+func TestNotificationConfig_UnmarshalYAML_StateConfigMapMissingName(t *testing.T) {
+	test := `
+mode: diff
+state_config_map:
+  namespace: kube-conformity`
+
+	notifications := NotificationConfig{}
+
+	err := yaml.Unmarshal([]byte(test), &notifications)
+
+	assert.NotNil(t, err)
+}
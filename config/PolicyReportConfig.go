@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+)
+
+var (
+	DefaultPolicyReportConfig = PolicyReportConfig{
+		Enabled: false,
+		Name:    "kube-conformity",
+	}
+)
+
+// PolicyReportConfig enables publishing the results as wgpolicyk8s.io
+// PolicyReports, one per namespace with the given name, and a
+// ClusterPolicyReport for the objects without a namespace.
+type PolicyReportConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Name    string `yaml:"name"`
+}
+
+func (policyReportConfig *PolicyReportConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*policyReportConfig = DefaultPolicyReportConfig
+	type plain PolicyReportConfig
+	if err := unmarshal((*plain)(policyReportConfig)); err != nil {
+		return err
+	}
+	if policyReportConfig.Name == "" {
+		return fmt.Errorf("missing name in policy report config")
+	}
+	return nil
+}
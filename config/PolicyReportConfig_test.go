@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPolicyReportConfig_UnmarshalYAML_Defaults(t *testing.T) {
+	policyReportConfig := PolicyReportConfig{}
+
+	err := yaml.Unmarshal([]byte("enabled: true"), &policyReportConfig)
+
+	assert.Nil(t, err)
+	assert.Equal(t, PolicyReportConfig{Enabled: true, Name: "kube-conformity"}, policyReportConfig)
+}
+
+func TestPolicyReportConfig_UnmarshalYAML_Error(t *testing.T) {
+	policyReportConfig := PolicyReportConfig{}
+
+	err := yaml.Unmarshal([]byte("enabled: true\nname: \"\""), &policyReportConfig)
+
+	assert.EqualError(t, err, "missing name in policy report config")
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	configReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kube_conformity_config_last_reload_successful",
+		Help: "Whether the last reload of the config succeeded.",
+	})
+	configReloadSuccessTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kube_conformity_config_last_reload_success_timestamp_seconds",
+		Help: "Timestamp of the last successful reload of the config.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(configReloadSuccessful, configReloadSuccessTimestamp)
+}
+
+// Reloader reloads the config file when its content changes, on SIGHUP and on
+// a POST to the reload endpoint. A config that fails to load or that has
+// problems Validate reports is not swapped in, the old config stays in use
+// until the file is fixed.
+type Reloader struct {
+	location string
+	parse    func(content []byte) (Config, error)
+	logger   log.FieldLogger
+
+	mutex    sync.Mutex
+	current  Config
+	checksum [sha256.Size]byte
+	reloaded chan Config
+}
+
+// NewReloader returns a reloader of the file at the location, the initial
+// config is the one loaded from it at startup. Parse turns the content of the
+// file into a config once Validate found no problems in it.
+func NewReloader(location string, initial Config, parse func(content []byte) (Config, error), logger log.FieldLogger) *Reloader {
+	reloader := &Reloader{
+		location: location,
+		parse:    parse,
+		logger:   logger,
+		current:  initial,
+		reloaded: make(chan Config, 1),
+	}
+	if content, err := ioutil.ReadFile(location); err == nil {
+		reloader.checksum = sha256.Sum256(content)
+	}
+	configReloadSuccessful.Set(1)
+	configReloadSuccessTimestamp.SetToCurrentTime()
+	return reloader
+}
+
+// Config returns the config that was loaded last.
+func (r *Reloader) Config() Config {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.current
+}
+
+// Reloaded returns the channel that receives every config that is loaded,
+// a config that is not received before the next one is loaded is dropped.
+func (r *Reloader) Reloaded() <-chan Config {
+	return r.reloaded
+}
+
+// Reload loads the config, also when the file did not change.
+func (r *Reloader) Reload() error {
+	return r.reload(true)
+}
+
+// Watch checks the file for changes every interval and reloads on SIGHUP,
+// until the stop channel is closed. With an interval of 0 only SIGHUP
+// reloads.
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-signals:
+			r.logger.Info("Received SIGHUP, reloading the config")
+			r.reload(true)
+		case <-tick:
+			r.reload(false)
+		}
+	}
+}
+
+// ServeHTTP reloads the config on a POST, it answers 500 with the error when
+// the config fails to load.
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.Reload(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to reload the config: %v", err), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintln(w, "OK")
+}
+
+// reload loads the config when the content of the file changed or when
+// forced, a file that cannot be read is retried on the next check.
+func (r *Reloader) reload(force bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	content, err := ioutil.ReadFile(r.location)
+	if err != nil {
+		r.checksum = [sha256.Size]byte{}
+		return r.failed(err)
+	}
+	checksum := sha256.Sum256(content)
+	if !force && checksum == r.checksum {
+		return nil
+	}
+	r.checksum = checksum
+	loaded, err := Load(content, r.parse)
+	if err != nil {
+		return r.failed(err)
+	}
+	r.current = loaded
+	select {
+	case <-r.reloaded:
+	default:
+	}
+	r.reloaded <- loaded
+	configReloadSuccessful.Set(1)
+	configReloadSuccessTimestamp.SetToCurrentTime()
+	r.logger.Infof("Reloaded the config from %s", r.location)
+	return nil
+}
+
+// Load validates the content and parses that same content when Validate
+// reports no problems, the problems are returned as one error.
+func Load(content []byte, parse func(content []byte) (Config, error)) (Config, error) {
+	if problems := Validate(content); len(problems) > 0 {
+		return Config{}, problemsError(problems)
+	}
+	return parse(content)
+}
+
+func problemsError(problems []Problem) error {
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	return fmt.Errorf("invalid config: %s", strings.Join(messages, "; "))
+}
+
+func (r *Reloader) failed(err error) error {
+	configReloadSuccessful.Set(0)
+	r.logger.Errorf("Reloading the config from %s failed, keeping the old config: %v", r.location, err)
+	return err
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+var reloaderLogOutput = bytes.NewBuffer([]byte{})
+var reloaderLogger = &log.Logger{
+	Out:       reloaderLogOutput,
+	Formatter: &log.TextFormatter{DisableTimestamp: true},
+	Hooks:     make(log.LevelHooks),
+	Level:     log.InfoLevel,
+}
+
+// newTestReloader writes the config to a file and returns a reloader of it.
+func newTestReloader(t *testing.T, content string) (*Reloader, string) {
+	file, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(content)
+	file.Close()
+	parse := func(content []byte) (Config, error) {
+		loaded := Config{}
+		return loaded, yaml.Unmarshal(content, &loaded)
+	}
+	initial, err := Load([]byte(content), parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaderLogOutput.Reset()
+	return NewReloader(file.Name(), initial, parse, reloaderLogger), file.Name()
+}
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	metric := &dto.Metric{}
+	if err := gauge.Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestReloader_Reload_Changed(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+
+	assert.Nil(t, reloader.reload(false))
+	assert.Empty(t, reloader.Reloaded(), "an unchanged file should not be reloaded")
+
+	ioutil.WriteFile(location, []byte("interval: 5m"), 0644)
+	assert.Nil(t, reloader.reload(false))
+
+	assert.Equal(t, 5*time.Minute, reloader.Config().Interval)
+	select {
+	case reloaded := <-reloader.Reloaded():
+		assert.Equal(t, 5*time.Minute, reloaded.Interval)
+	default:
+		assert.Fail(t, "the reloaded config should be sent")
+	}
+	assert.Equal(t, float64(1), gaugeValue(t, configReloadSuccessful))
+}
+
+func TestReloader_Reload_Invalid(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+
+	ioutil.WriteFile(location, []byte("interval: 0s"), 0644)
+	err := reloader.reload(false)
+
+	assert.EqualError(t, err, "invalid config: 1:1: missing interval in config")
+	assert.Equal(t, time.Hour, reloader.Config().Interval)
+	assert.Empty(t, reloader.Reloaded())
+	assert.Equal(t, float64(0), gaugeValue(t, configReloadSuccessful))
+	assert.Contains(t, reloaderLogOutput.String(), "keeping the old config: invalid config: 1:1: missing interval in config")
+
+	ioutil.WriteFile(location, []byte("interval: 2h"), 0644)
+	assert.Nil(t, reloader.reload(false))
+	assert.Equal(t, 2*time.Hour, reloader.Config().Interval)
+	assert.Equal(t, float64(1), gaugeValue(t, configReloadSuccessful))
+}
+
+func TestReloader_Reload_Problems(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+
+	ioutil.WriteFile(location, []byte("interval: 2h\nintervall: 3h"), 0644)
+	err := reloader.reload(false)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid config: 2:1: intervall: unknown key intervall")
+	}
+	assert.Equal(t, time.Hour, reloader.Config().Interval)
+	assert.Empty(t, reloader.Reloaded())
+	assert.Equal(t, float64(0), gaugeValue(t, configReloadSuccessful))
+}
+
+func TestReloader_Reload_OnlyLatestKept(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+
+	ioutil.WriteFile(location, []byte("interval: 2h"), 0644)
+	reloader.Reload()
+	ioutil.WriteFile(location, []byte("interval: 3h"), 0644)
+	reloader.Reload()
+
+	assert.Len(t, reloader.Reloaded(), 1)
+	assert.Equal(t, 3*time.Hour, (<-reloader.Reloaded()).Interval)
+}
+
+func TestReloader_Reload_MissingFile(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	os.Remove(location)
+
+	assert.Error(t, reloader.reload(false))
+	assert.Equal(t, time.Hour, reloader.Config().Interval)
+
+	ioutil.WriteFile(location, []byte("interval: 1h"), 0644)
+	defer os.Remove(location)
+	assert.Nil(t, reloader.reload(false))
+	assert.Len(t, reloader.Reloaded(), 1, "the config should be reloaded once the file is back")
+}
+
+func TestReloader_ServeHTTP(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+
+	recorder := httptest.NewRecorder()
+	reloader.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/-/reload", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	reloader.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, reloader.Reloaded(), 1, "a reload through the endpoint is forced")
+
+	ioutil.WriteFile(location, []byte("interval: 1h\nunknown: ["), 0644)
+	recorder = httptest.NewRecorder()
+	reloader.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "failed to reload the config")
+}
+
+func TestReloader_Watch(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+	stop := make(chan struct{})
+	defer close(stop)
+	go reloader.Watch(10*time.Millisecond, stop)
+
+	ioutil.WriteFile(location, []byte("interval: 4h"), 0644)
+
+	select {
+	case reloaded := <-reloader.Reloaded():
+		assert.Equal(t, 4*time.Hour, reloaded.Interval)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the changed file should be reloaded")
+	}
+}
+
+func TestReloader_Watch_SIGHUP(t *testing.T) {
+	reloader, location := newTestReloader(t, "interval: 1h")
+	defer os.Remove(location)
+	stop := make(chan struct{})
+	defer close(stop)
+	go reloader.Watch(0, stop)
+	time.Sleep(50 * time.Millisecond)
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+	select {
+	case reloaded := <-reloader.Reloaded():
+		assert.Equal(t, time.Hour, reloaded.Interval)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "SIGHUP should reload the config")
+	}
+}
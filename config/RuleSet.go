@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/stijndehaes/kube-conformity/rules"
+	"gopkg.in/yaml.v2"
+)
+
+// RuleSet holds the rules of a ConformityRule or ClusterConformityRule, the
+// spec has the same rule lists as the config file. The namespace is the one of
+// the ConformityRule, empty for a ClusterConformityRule.
+type RuleSet struct {
+	Name                           string                                 `yaml:"-"`
+	Namespace                      string                                 `yaml:"-"`
+	PodRulesLabelsFilledIn         []rules.PodRuleLabelsFilledIn          `yaml:"pod_rules_labels_filled_in"`
+	PodRulesLimitsFilledIn         []rules.PodRuleLimitsFilledIn          `yaml:"pod_rules_limits_filled_in"`
+	PodRulesRequestsFilledIn       []rules.PodRuleRequestsFilledIn        `yaml:"pod_rules_requests_filled_in"`
+	DeploymentRuleReplicasMinimum  []rules.DeploymentRuleReplicasMinimum  `yaml:"deployment_rules_replicas_minimum"`
+	StatefulSetRuleReplicasMinimum []rules.StatefulSetRuleReplicasMinimum `yaml:"stateful_set_rules_replicas_minimum"`
+	RegoRules                      []rules.RegoRule                       `yaml:"rego_rules"`
+	ResourceRules                  []rules.ResourceRule                   `yaml:"resource_rules"`
+	FieldRules                     []rules.FieldRule                      `yaml:"field_rules"`
+}
+
+// ParseRuleSet parses the spec of a ConformityRule, keys that are not a rule
+// list or a field of a rule are errors. The rego rules of a set with a
+// namespace can not read modules from disk and their ConfigMap is always the
+// one in that namespace.
+func ParseRuleSet(namespace string, spec map[string]interface{}) (RuleSet, error) {
+	ruleSet := RuleSet{}
+	if namespace != "" {
+		spec = withConfigMapNamespace(spec, namespace)
+	}
+	specBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return ruleSet, err
+	}
+	if err := yaml.UnmarshalStrict(specBytes, &ruleSet); err != nil {
+		return ruleSet, err
+	}
+	ruleSet.Namespace = namespace
+	if ruleSet.Rules() == 0 {
+		return ruleSet, fmt.Errorf("missing rules in spec")
+	}
+	if err := (Config{}).ruleSetConfig(ruleSet).checkRuleNames(nil); err != nil {
+		return ruleSet, err
+	}
+	if namespace == "" {
+		return ruleSet, nil
+	}
+	for _, rule := range ruleSet.RegoRules {
+		if len(rule.Modules) > 0 {
+			return ruleSet, fmt.Errorf("modules are not allowed in a ConformityRule, use a config_map for RegoRule %s", rule.Name)
+		}
+	}
+	return ruleSet, nil
+}
+
+// withConfigMapNamespace returns a copy of the spec with the ConfigMaps of the
+// rego rules in the namespace, a ConformityRule can only use the ConfigMaps of
+// its own namespace.
+func withConfigMapNamespace(spec map[string]interface{}, namespace string) map[string]interface{} {
+	regoRules, ok := spec["rego_rules"].([]interface{})
+	if !ok {
+		return spec
+	}
+	forced := make(map[string]interface{}, len(spec))
+	for key, value := range spec {
+		forced[key] = value
+	}
+	var forcedRules []interface{}
+	for _, regoRule := range regoRules {
+		rule, ok := regoRule.(map[string]interface{})
+		configMap, hasConfigMap := rule["config_map"].(map[string]interface{})
+		if !ok || !hasConfigMap {
+			forcedRules = append(forcedRules, regoRule)
+			continue
+		}
+		forcedRule := make(map[string]interface{}, len(rule))
+		for key, value := range rule {
+			forcedRule[key] = value
+		}
+		forcedConfigMap := map[string]interface{}{"namespace": namespace}
+		for key, value := range configMap {
+			if key != "namespace" {
+				forcedConfigMap[key] = value
+			}
+		}
+		forcedRule["config_map"] = forcedConfigMap
+		forcedRules = append(forcedRules, forcedRule)
+	}
+	forced["rego_rules"] = forcedRules
+	return forced
+}
+
+// Rules returns the number of rules in the set.
+func (r RuleSet) Rules() int {
+	return len(r.PodRulesLabelsFilledIn) + len(r.PodRulesLimitsFilledIn) + len(r.PodRulesRequestsFilledIn) +
+		len(r.DeploymentRuleReplicasMinimum) + len(r.StatefulSetRuleReplicasMinimum) +
+		len(r.RegoRules) + len(r.ResourceRules) + len(r.FieldRules)
+}
+
+// WithRuleSets returns the config with the rules of the sets added. The
+// defaults of the config apply to the added rules, the rules of a set with a
+// namespace only check objects in that namespace whatever their filter says,
+// and their names are prefixed with the namespace. A set with a rule named
+// like a rule that is already in the config is left out, with an error.
+func (c Config) WithRuleSets(ruleSets []RuleSet) (Config, []error) {
+	merged := c
+	merged.PodRulesLabelsFilledIn = append([]rules.PodRuleLabelsFilledIn{}, c.PodRulesLabelsFilledIn...)
+	merged.PodRulesLimitsFilledIn = append([]rules.PodRuleLimitsFilledIn{}, c.PodRulesLimitsFilledIn...)
+	merged.PodRulesRequestsFilledIn = append([]rules.PodRuleRequestsFilledIn{}, c.PodRulesRequestsFilledIn...)
+	merged.DeploymentRuleReplicasMinimum = append([]rules.DeploymentRuleReplicasMinimum{}, c.DeploymentRuleReplicasMinimum...)
+	merged.StatefulSetRuleReplicasMinimum = append([]rules.StatefulSetRuleReplicasMinimum{}, c.StatefulSetRuleReplicasMinimum...)
+	merged.RegoRules = append([]rules.RegoRule{}, c.RegoRules...)
+	merged.ResourceRules = append([]rules.ResourceRule{}, c.ResourceRules...)
+	merged.FieldRules = append([]rules.FieldRule{}, c.FieldRules...)
+	used := make(map[string]string)
+	for _, ruleInfo := range c.RuleInfos() {
+		used[ruleInfo.Name] = ruleInfo.Type
+	}
+	var errs []error
+	for _, ruleSet := range ruleSets {
+		added := c.RuleSetConfig(ruleSet)
+		if err := added.checkRuleNames(used); err != nil {
+			errs = append(errs, fmt.Errorf("skipping the rules of %s: %v", ruleSet.Key(), err))
+			continue
+		}
+		for _, ruleInfo := range added.RuleInfos() {
+			used[ruleInfo.Name] = ruleInfo.Type
+		}
+		merged.PodRulesLabelsFilledIn = append(merged.PodRulesLabelsFilledIn, added.PodRulesLabelsFilledIn...)
+		merged.PodRulesLimitsFilledIn = append(merged.PodRulesLimitsFilledIn, added.PodRulesLimitsFilledIn...)
+		merged.PodRulesRequestsFilledIn = append(merged.PodRulesRequestsFilledIn, added.PodRulesRequestsFilledIn...)
+		merged.DeploymentRuleReplicasMinimum = append(merged.DeploymentRuleReplicasMinimum, added.DeploymentRuleReplicasMinimum...)
+		merged.StatefulSetRuleReplicasMinimum = append(merged.StatefulSetRuleReplicasMinimum, added.StatefulSetRuleReplicasMinimum...)
+		merged.RegoRules = append(merged.RegoRules, added.RegoRules...)
+		merged.ResourceRules = append(merged.ResourceRules, added.ResourceRules...)
+		merged.FieldRules = append(merged.FieldRules, added.FieldRules...)
+	}
+	return merged, errs
+}
+
+// Key returns the namespace and name of the object the set comes from.
+func (r RuleSet) Key() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return r.Namespace + "/" + r.Name
+}
+
+// RuleSetConfig returns a config with only the rules of the set, added the
+// way WithRuleSets adds them.
+func (c Config) RuleSetConfig(ruleSet RuleSet) Config {
+	added := c.ruleSetConfig(ruleSet)
+	added.restrictToNamespace(ruleSet.Namespace)
+	return added
+}
+
+// ruleSetConfig returns a config with the rules of the set and the defaults
+// of this config applied to them.
+func (c Config) ruleSetConfig(ruleSet RuleSet) Config {
+	added := Config{
+		PodRulesLabelsFilledIn:         append([]rules.PodRuleLabelsFilledIn{}, ruleSet.PodRulesLabelsFilledIn...),
+		PodRulesLimitsFilledIn:         append([]rules.PodRuleLimitsFilledIn{}, ruleSet.PodRulesLimitsFilledIn...),
+		PodRulesRequestsFilledIn:       append([]rules.PodRuleRequestsFilledIn{}, ruleSet.PodRulesRequestsFilledIn...),
+		DeploymentRuleReplicasMinimum:  append([]rules.DeploymentRuleReplicasMinimum{}, ruleSet.DeploymentRuleReplicasMinimum...),
+		StatefulSetRuleReplicasMinimum: append([]rules.StatefulSetRuleReplicasMinimum{}, ruleSet.StatefulSetRuleReplicasMinimum...),
+		RegoRules:                      append([]rules.RegoRule{}, ruleSet.RegoRules...),
+		ResourceRules:                  append([]rules.ResourceRule{}, ruleSet.ResourceRules...),
+		FieldRules:                     append([]rules.FieldRule{}, ruleSet.FieldRules...),
+		DefaultFilter:                  c.DefaultFilter,
+		PodDefaults:                    c.PodDefaults,
+		DeploymentDefaults:             c.DeploymentDefaults,
+		StatefulSetDefaults:            c.StatefulSetDefaults,
+	}
+	added.applyDefaults()
+	return added
+}
+
+// restrictToNamespace makes every rule only include objects in the
+// namespace, the rules of cluster wide sets are left as they are.
+func (c *Config) restrictToNamespace(namespace string) {
+	if namespace == "" {
+		return
+	}
+	include := []string{namespace}
+	for idx := range c.PodRulesLabelsFilledIn {
+		c.PodRulesLabelsFilledIn[idx].Name = namespace + "/" + c.PodRulesLabelsFilledIn[idx].Name
+		c.PodRulesLabelsFilledIn[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.PodRulesLimitsFilledIn {
+		c.PodRulesLimitsFilledIn[idx].Name = namespace + "/" + c.PodRulesLimitsFilledIn[idx].Name
+		c.PodRulesLimitsFilledIn[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.PodRulesRequestsFilledIn {
+		c.PodRulesRequestsFilledIn[idx].Name = namespace + "/" + c.PodRulesRequestsFilledIn[idx].Name
+		c.PodRulesRequestsFilledIn[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.DeploymentRuleReplicasMinimum {
+		c.DeploymentRuleReplicasMinimum[idx].Name = namespace + "/" + c.DeploymentRuleReplicasMinimum[idx].Name
+		c.DeploymentRuleReplicasMinimum[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.StatefulSetRuleReplicasMinimum {
+		c.StatefulSetRuleReplicasMinimum[idx].Name = namespace + "/" + c.StatefulSetRuleReplicasMinimum[idx].Name
+		c.StatefulSetRuleReplicasMinimum[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.RegoRules {
+		c.RegoRules[idx].Name = namespace + "/" + c.RegoRules[idx].Name
+		c.RegoRules[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.ResourceRules {
+		c.ResourceRules[idx].Name = namespace + "/" + c.ResourceRules[idx].Name
+		c.ResourceRules[idx].Filter.IncludeNamespaces = include
+	}
+	for idx := range c.FieldRules {
+		c.FieldRules[idx].Name = namespace + "/" + c.FieldRules[idx].Name
+		c.FieldRules[idx].Filter.IncludeNamespaces = include
+	}
+}
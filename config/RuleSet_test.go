@@ -0,0 +1,151 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/filters"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRuleSet(t *testing.T) {
+	spec := map[string]interface{}{
+		"pod_rules_labels_filled_in": []interface{}{
+			map[string]interface{}{"name": "app label", "labels": []interface{}{"app"}},
+		},
+		"deployment_rules_replicas_minimum": []interface{}{
+			map[string]interface{}{"name": "replicas", "minimum_replicas": int64(2)},
+		},
+	}
+
+	ruleSet, err := ParseRuleSet("payments", spec)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "payments", ruleSet.Namespace)
+	assert.Equal(t, 2, ruleSet.Rules())
+	assert.Equal(t, []string{"app"}, ruleSet.PodRulesLabelsFilledIn[0].Labels)
+	assert.Equal(t, int32(2), ruleSet.DeploymentRuleReplicasMinimum[0].MinimumReplicas)
+}
+
+func TestParseRuleSet_Errors(t *testing.T) {
+	_, err := ParseRuleSet("payments", map[string]interface{}{"pod_rules_labels_fill_in": []interface{}{}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "field pod_rules_labels_fill_in not found")
+	}
+
+	_, err = ParseRuleSet("payments", map[string]interface{}{
+		"pod_rules_labels_filled_in": []interface{}{map[string]interface{}{"name": "app label"}},
+	})
+	assert.EqualError(t, err, "missing labels for PodRuleLabelsFilledIn")
+
+	_, err = ParseRuleSet("payments", map[string]interface{}{})
+	assert.EqualError(t, err, "missing rules in spec")
+
+	_, err = ParseRuleSet("payments", map[string]interface{}{
+		"pod_rules_labels_filled_in": []interface{}{map[string]interface{}{"name": "app", "labels": []interface{}{"app"}}},
+		"pod_rules_limits_filled_in": []interface{}{map[string]interface{}{"name": "app"}},
+	})
+	assert.EqualError(t, err, "duplicate rule name app in pod_rules_labels_filled_in and pod_rules_limits_filled_in")
+}
+
+func TestParseRuleSet_RegoRules(t *testing.T) {
+	spec := map[string]interface{}{
+		"rego_rules": []interface{}{map[string]interface{}{
+			"name":       "policies",
+			"kind":       "Pod",
+			"config_map": map[string]interface{}{"namespace": "kube-system", "name": "policies"},
+		}},
+	}
+
+	ruleSet, err := ParseRuleSet("payments", spec)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &rules.ConfigMapReference{Namespace: "payments", Name: "policies"}, ruleSet.RegoRules[0].ConfigMap)
+	assert.Equal(t, "kube-system", spec["rego_rules"].([]interface{})[0].(map[string]interface{})["config_map"].(map[string]interface{})["namespace"], "the spec should be left alone")
+
+	delete(spec["rego_rules"].([]interface{})[0].(map[string]interface{})["config_map"].(map[string]interface{}), "namespace")
+	ruleSet, err = ParseRuleSet("payments", spec)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "payments", ruleSet.RegoRules[0].ConfigMap.Namespace)
+
+	modules := map[string]interface{}{
+		"rego_rules": []interface{}{map[string]interface{}{
+			"name":    "policies",
+			"kind":    "Pod",
+			"modules": []interface{}{"/etc/kube-conformity/policies"},
+		}},
+	}
+	_, err = ParseRuleSet("payments", modules)
+
+	assert.EqualError(t, err, "modules are not allowed in a ConformityRule, use a config_map for RegoRule policies")
+
+	_, err = ParseRuleSet("", modules)
+
+	assert.Nil(t, err)
+}
+
+func TestConfig_WithRuleSets(t *testing.T) {
+	kubeConfig := Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "app label", Labels: []string{"app"}}},
+		DefaultFilter:          filters.ObjectFilter{Filter: filters.Filter{ExcludeNamespaces: []string{"kube-system"}}},
+	}
+	ruleSets := []RuleSet{
+		{
+			Namespace: "payments",
+			PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{
+				Name:   "team label",
+				Labels: []string{"team"},
+				Filter: filters.PodFilter{Filter: filters.Filter{IncludeNamespaces: []string{"*"}, Defaults: filters.DefaultsOverride}},
+			}},
+		},
+		{
+			StatefulSetRuleReplicasMinimum: []rules.StatefulSetRuleReplicasMinimum{{Name: "replicas", MinimumReplicas: 3}},
+		},
+	}
+
+	merged, errs := kubeConfig.WithRuleSets(ruleSets)
+
+	assert.Empty(t, errs)
+	assert.Len(t, kubeConfig.PodRulesLabelsFilledIn, 1)
+	if assert.Len(t, merged.PodRulesLabelsFilledIn, 2) {
+		assert.Equal(t, "app label", merged.PodRulesLabelsFilledIn[0].Name)
+		assert.Equal(t, "payments/team label", merged.PodRulesLabelsFilledIn[1].Name)
+		assert.Equal(t, []string{"payments"}, merged.PodRulesLabelsFilledIn[1].Filter.IncludeNamespaces)
+	}
+	if assert.Len(t, merged.StatefulSetRuleReplicasMinimum, 1) {
+		assert.Equal(t, "replicas", merged.StatefulSetRuleReplicasMinimum[0].Name)
+		assert.Equal(t, []string{"kube-system"}, merged.StatefulSetRuleReplicasMinimum[0].Filter.ExcludeNamespaces)
+	}
+}
+
+func TestConfig_WithRuleSets_DuplicateRuleName(t *testing.T) {
+	kubeConfig := Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "app label", Labels: []string{"app"}}},
+	}
+	ruleSets := []RuleSet{
+		{
+			Name:                   "labels",
+			PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "team label", Labels: []string{"team"}}},
+		},
+		{
+			Name:                          "replicas",
+			DeploymentRuleReplicasMinimum: []rules.DeploymentRuleReplicasMinimum{{Name: "replicas", MinimumReplicas: 2}},
+			FieldRules:                    []rules.FieldRule{{Name: "app label"}},
+		},
+		{
+			Name:                   "more labels",
+			Namespace:              "payments",
+			PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "team label", Labels: []string{"team"}}},
+		},
+	}
+
+	merged, errs := kubeConfig.WithRuleSets(ruleSets)
+
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "skipping the rules of replicas: duplicate rule name app label in pod_rules_labels_filled_in and field_rules")
+	}
+	assert.Len(t, merged.PodRulesLabelsFilledIn, 3)
+	assert.Empty(t, merged.DeploymentRuleReplicasMinimum)
+	assert.Empty(t, merged.FieldRules)
+}
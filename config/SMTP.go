@@ -0,0 +1,176 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+
+	AuthNone    = "none"
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+
+	smtpTimeout = 30 * time.Second
+)
+
+// port returns the configured port, or the default port of the TLS mode.
+func (emailConfig EmailConfig) port() int {
+	if emailConfig.Port != 0 {
+		return emailConfig.Port
+	}
+	switch emailConfig.TLS {
+	case TLSImplicit:
+		return 465
+	case TLSNone:
+		return 25
+	}
+	return 587
+}
+
+func (emailConfig EmailConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: emailConfig.Host, InsecureSkipVerify: emailConfig.InsecureSkipVerify}
+	if emailConfig.CAFile != "" {
+		ca, err := ioutil.ReadFile(emailConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading the ca file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in the ca file %s", emailConfig.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// smtpAuth returns the auth mechanism, mails are sent without authentication
+// when no username is configured.
+func (emailConfig EmailConfig) smtpAuth() smtp.Auth {
+	switch emailConfig.Auth {
+	case AuthNone:
+		return nil
+	case AuthLogin:
+		return loginAuth{username: emailConfig.AuthUsername, password: emailConfig.AuthPassword, host: emailConfig.Host}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(emailConfig.AuthUsername, emailConfig.AuthPassword)
+	}
+	if emailConfig.AuthUsername == "" {
+		return nil
+	}
+	return smtp.PlainAuth(emailConfig.AuthIdentity, emailConfig.AuthUsername, emailConfig.AuthPassword, emailConfig.Host)
+}
+
+// send delivers the message to the recipients through the SMTP server.
+func (emailConfig EmailConfig) send(msg []byte) error {
+	var recipients []string
+	for _, addresses := range []Addresses{emailConfig.To, emailConfig.Cc, emailConfig.Bcc} {
+		envelope, err := addresses.Envelope()
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, envelope...)
+	}
+	tlsConfig, err := emailConfig.tlsConfig()
+	if err != nil {
+		return err
+	}
+	address := net.JoinHostPort(emailConfig.Host, strconv.Itoa(emailConfig.port()))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	if emailConfig.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	client, err := smtp.NewClient(conn, emailConfig.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if emailConfig.TLS == TLSStartTLS || emailConfig.TLS == "" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS, set tls to none to send without encryption", address)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if auth := emailConfig.smtpAuth(); auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support authentication", address)
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	from, err := Addresses{emailConfig.From}.Envelope()
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from[0]); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// loginAuth implements the LOGIN mechanism, like PLAIN it refuses to send the
+// credentials over an unencrypted connection to another host.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" && server.Name != "::1" {
+		return "", nil, fmt.Errorf("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, fmt.Errorf("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
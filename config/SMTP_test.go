@@ -0,0 +1,351 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpServer is an in-process stand-in for an SMTP server. It records the
+// envelope, the credentials and the data of every mail it receives.
+type smtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool
+	caFile    string
+
+	mutex sync.Mutex
+	mails []receivedMail
+}
+
+type receivedMail struct {
+	tls        bool
+	auth       string
+	username   string
+	password   string
+	from       string
+	recipients []string
+	data       string
+}
+
+// newSMTPServer starts a server, with implicit TLS or offering STARTTLS. The
+// certificate of the server is written to a ca file for the clients.
+func newSMTPServer(t *testing.T, implicitTLS bool, startTLS bool) *smtpServer {
+	certificateServer := httptest.NewTLSServer(nil)
+	certificateServer.Close()
+	caFile, err := ioutil.TempFile("", "smtp-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: certificateServer.Certificate().Raw})
+	caFile.Close()
+	server := &smtpServer{
+		tlsConfig: &tls.Config{Certificates: certificateServer.TLS.Certificates},
+		startTLS:  startTLS,
+		caFile:    caFile.Name(),
+	}
+	server.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if implicitTLS {
+		server.listener = tls.NewListener(server.listener, server.tlsConfig)
+	}
+	go server.serve()
+	return server
+}
+
+func (s *smtpServer) Close() {
+	s.listener.Close()
+	os.Remove(s.caFile)
+}
+
+func (s *smtpServer) port() int {
+	port, _ := strconv.Atoi(strings.Split(s.listener.Addr().String(), ":")[1])
+	return port
+}
+
+func (s *smtpServer) received() []receivedMail {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]receivedMail{}, s.mails...)
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	_, isTLS := conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	mail := receivedMail{tls: isTLS}
+	text.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		argument := ""
+		if parts := strings.SplitN(line, " ", 2); len(parts) == 2 {
+			argument = parts[1]
+		}
+		switch command {
+		case "EHLO", "HELO":
+			extensions := []string{"localhost", "AUTH PLAIN LOGIN CRAM-MD5", "8BITMIME"}
+			if s.startTLS && !mail.tls {
+				extensions = append(extensions, "STARTTLS")
+			}
+			for idx, extension := range extensions {
+				separator := "-"
+				if idx == len(extensions)-1 {
+					separator = " "
+				}
+				text.PrintfLine("250%s%s", separator, extension)
+			}
+		case "STARTTLS":
+			text.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			mail.tls = true
+		case "AUTH":
+			if !s.authenticate(text, &mail, argument) {
+				text.PrintfLine("535 Authentication failed")
+				continue
+			}
+			text.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			mail.from = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+			if idx := strings.Index(mail.from, ">"); idx >= 0 {
+				mail.from = mail.from[:idx]
+			}
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			mail.recipients = append(mail.recipients, strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := ioutil.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.mutex.Lock()
+			s.mails = append(s.mails, mail)
+			s.mutex.Unlock()
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+// authenticate runs the exchange of the mechanism and records the
+// credentials, CRAM-MD5 only accepts the password "secret".
+func (s *smtpServer) authenticate(text *textproto.Conn, mail *receivedMail, argument string) bool {
+	parts := strings.SplitN(argument, " ", 2)
+	mail.auth = parts[0]
+	challenge := func(prompt string) string {
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, _ := text.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+	switch mail.auth {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+		credentials := strings.Split(string(decoded), "\x00")
+		if len(credentials) != 3 {
+			return false
+		}
+		mail.username, mail.password = credentials[1], credentials[2]
+	case "LOGIN":
+		mail.username = challenge("Username:")
+		mail.password = challenge("Password:")
+	case "CRAM-MD5":
+		nonce := "<1896.697170952@localhost>"
+		response := strings.SplitN(challenge(nonce), " ", 2)
+		hash := hmac.New(md5.New, []byte("secret"))
+		hash.Write([]byte(nonce))
+		if len(response) != 2 || response[1] != hex.EncodeToString(hash.Sum(nil)) {
+			return false
+		}
+		mail.username = response[0]
+	default:
+		return false
+	}
+	return true
+}
+
+func newTestEmailConfig(server *smtpServer, tlsMode string) EmailConfig {
+	emailConfig := DefaultEmailConfig
+	emailConfig.Enabled = true
+	emailConfig.Host = "127.0.0.1"
+	emailConfig.Port = server.port()
+	emailConfig.TLS = tlsMode
+	emailConfig.CAFile = server.caFile
+	emailConfig.Template = "../mailtemplate.html"
+	emailConfig.To = Addresses{"Platform team <platform@example.com>", "oncall@example.com"}
+	emailConfig.Cc = Addresses{"lead@example.com"}
+	emailConfig.Bcc = Addresses{"audit@example.com"}
+	return emailConfig
+}
+
+func TestEmailConfig_SendMail_StartTLS(t *testing.T) {
+	server := newSMTPServer(t, false, true)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSStartTLS)
+	emailConfig.AuthUsername = "user"
+	emailConfig.AuthPassword = "password"
+
+	err := emailConfig.SendMail(mailData)
+
+	assert.Nil(t, err)
+	mails := server.received()
+	if assert.Len(t, mails, 1) {
+		assert.True(t, mails[0].tls)
+		assert.Equal(t, "PLAIN", mails[0].auth)
+		assert.Equal(t, "user", mails[0].username)
+		assert.Equal(t, "password", mails[0].password)
+		assert.Equal(t, "no-reply@kube-conformity.com", mails[0].from)
+		assert.Equal(t, []string{"platform@example.com", "oncall@example.com", "lead@example.com", "audit@example.com"}, mails[0].recipients)
+		assert.Contains(t, mails[0].data, "To: Platform team <platform@example.com>, oncall@example.com\n")
+		assert.Contains(t, mails[0].data, "Cc: lead@example.com\n")
+		assert.NotContains(t, mails[0].data, "audit@example.com")
+	}
+}
+
+func TestEmailConfig_SendMail_StartTLSNotSupported(t *testing.T) {
+	server := newSMTPServer(t, false, false)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSStartTLS)
+
+	err := emailConfig.SendMail(mailData)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not support STARTTLS")
+	}
+	assert.Empty(t, server.received())
+}
+
+func TestEmailConfig_SendMail_ImplicitTLS(t *testing.T) {
+	server := newSMTPServer(t, true, false)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSImplicit)
+	emailConfig.Auth = AuthLogin
+	emailConfig.AuthUsername = "user"
+	emailConfig.AuthPassword = "password"
+
+	err := emailConfig.SendMail(mailData)
+
+	assert.Nil(t, err)
+	mails := server.received()
+	if assert.Len(t, mails, 1) {
+		assert.True(t, mails[0].tls)
+		assert.Equal(t, "LOGIN", mails[0].auth)
+		assert.Equal(t, "user", mails[0].username)
+		assert.Equal(t, "password", mails[0].password)
+	}
+}
+
+func TestEmailConfig_SendMail_UnknownCA(t *testing.T) {
+	server := newSMTPServer(t, true, false)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSImplicit)
+	emailConfig.CAFile = ""
+
+	err := emailConfig.SendMail(mailData)
+
+	assert.Error(t, err)
+	assert.Empty(t, server.received())
+}
+
+func TestEmailConfig_SendMail_NoTLS(t *testing.T) {
+	server := newSMTPServer(t, false, true)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSNone)
+	emailConfig.Auth = AuthCRAMMD5
+	emailConfig.AuthUsername = "user"
+	emailConfig.AuthPassword = "secret"
+
+	err := emailConfig.SendMail(MailData{Diff: true})
+
+	assert.Nil(t, err)
+	mails := server.received()
+	if assert.Len(t, mails, 1) {
+		assert.False(t, mails[0].tls)
+		assert.Equal(t, "CRAM-MD5", mails[0].auth)
+		assert.Equal(t, "user", mails[0].username)
+	}
+}
+
+func TestEmailConfig_SendMail_NoAuth(t *testing.T) {
+	server := newSMTPServer(t, false, true)
+	defer server.Close()
+	emailConfig := newTestEmailConfig(server, TLSStartTLS)
+
+	err := emailConfig.SendMail(mailData)
+
+	assert.Nil(t, err)
+	mails := server.received()
+	if assert.Len(t, mails, 1) {
+		assert.Equal(t, "", mails[0].auth)
+	}
+}
+
+func TestEmailConfig_Port(t *testing.T) {
+	assert.Equal(t, 587, EmailConfig{TLS: TLSStartTLS}.port())
+	assert.Equal(t, 465, EmailConfig{TLS: TLSImplicit}.port())
+	assert.Equal(t, 25, EmailConfig{TLS: TLSNone}.port())
+	assert.Equal(t, 1025, EmailConfig{TLS: TLSNone, Port: 1025}.port())
+}
+
+func TestLoginAuth_UnencryptedRemote(t *testing.T) {
+	_, _, err := loginAuth{host: "smtp.example.com"}.Start(&smtp.ServerInfo{Name: "smtp.example.com", Auth: []string{"LOGIN"}})
+
+	assert.EqualError(t, err, "unencrypted connection")
+}
+
+func TestEmailConfig_TLSConfig_InvalidCA(t *testing.T) {
+	caFile, err := ioutil.TempFile("", "smtp-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(caFile.Name())
+	fmt.Fprint(caFile, "not a certificate")
+	caFile.Close()
+
+	_, err = EmailConfig{CAFile: caFile.Name()}.tlsConfig()
+
+	assert.Error(t, err)
+}
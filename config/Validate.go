@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var errorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// Problem is a problem found in a config file, at the line and column of the
+// value it is about. Path is the location of the value in the config, like
+// field_rules[1](replicas).fields[0].operator.
+type Problem struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+}
+
+// Validate strictly decodes the config and returns every problem in it,
+// sorted on line. Unlike loading the config it does not stop at the first
+// problem: keys that are not a field are problems, every rule is decoded on
+// its own and the templates of the email config have to exist and parse. The
+// problems of an anchored value are reported once, not once per alias.
+func Validate(content []byte) []Problem {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &document); err != nil {
+		return syntaxProblems(err)
+	}
+	if len(document.Content) == 0 {
+		return []Problem{{Line: 1, Column: 1, Message: "empty config"}}
+	}
+	root := resolveAliases(document.Content[0])
+	if root.Kind != yamlv3.MappingNode {
+		return []Problem{nodeProblem(root, "", "config must be a mapping")}
+	}
+	validator := &validator{}
+	validator.checkKeys(root, reflect.TypeOf(Config{}), "")
+	validator.decodeFields(root)
+	validator.decodeConfig(root)
+	sort.SliceStable(validator.problems, func(i, j int) bool {
+		if validator.problems[i].Line != validator.problems[j].Line {
+			return validator.problems[i].Line < validator.problems[j].Line
+		}
+		return validator.problems[i].Column < validator.problems[j].Column
+	})
+	var problems []Problem
+	for _, problem := range validator.problems {
+		if len(problems) == 0 || problems[len(problems)-1] != problem {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(node *yamlv3.Node, path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, nodeProblem(node, path, fmt.Sprintf(format, args...)))
+}
+
+// checkKeys reports the keys of the mappings in the node that are not a field
+// of the type and removes them, so they are not reported again when decoding.
+func (v *validator) checkKeys(node *yamlv3.Node, fieldType reflect.Type, path string) {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch {
+	case node.Kind == yamlv3.SequenceNode && fieldType.Kind() == reflect.Slice:
+		for idx, item := range node.Content {
+			v.checkKeys(item, fieldType.Elem(), itemPath(path, idx, item))
+		}
+	case node.Kind == yamlv3.MappingNode && fieldType.Kind() == reflect.Map:
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			v.checkKeys(node.Content[idx+1], fieldType.Elem(), joinPath(path, node.Content[idx].Value))
+		}
+	case node.Kind == yamlv3.MappingNode && fieldType.Kind() == reflect.Struct:
+		fields := yamlFields(fieldType)
+		var known []*yamlv3.Node
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key, value := node.Content[idx], node.Content[idx+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				v.add(key, joinPath(path, key.Value), "unknown key %s, valid keys are %v", key.Value, sortedKeys(fields))
+				continue
+			}
+			v.checkKeys(value, field, joinPath(path, key.Value))
+			known = append(known, key, value)
+		}
+		node.Content = known
+	}
+}
+
+// decodeFields decodes every field of the config on its own and every rule
+// of a list on its own, the values that fail are removed so the config can
+// be decoded without them.
+func (v *validator) decodeFields(root *yamlv3.Node) {
+	fields := yamlFields(reflect.TypeOf(Config{}))
+	var decoded []*yamlv3.Node
+	for idx := 0; idx+1 < len(root.Content); idx += 2 {
+		key, value := root.Content[idx], root.Content[idx+1]
+		fieldType := fields[key.Value]
+		if value.Kind == yamlv3.SequenceNode && fieldType.Kind() == reflect.Slice {
+			var items []*yamlv3.Node
+			for itemIdx, item := range value.Content {
+				if v.decode(item, fieldType.Elem(), itemPath(key.Value, itemIdx, item)) {
+					items = append(items, item)
+				}
+			}
+			value.Content = items
+			decoded = append(decoded, key, value)
+			continue
+		}
+		if v.decode(value, fieldType, key.Value) {
+			decoded = append(decoded, key, value)
+		}
+		if key.Value == "email_config" {
+			v.checkTemplates(value)
+		}
+	}
+	root.Content = decoded
+}
+
+// decodeConfig decodes what is left of the config, to find the problems of
+// the config as a whole like a missing interval.
+func (v *validator) decodeConfig(root *yamlv3.Node) {
+	config := Config{}
+	v.decode(root, reflect.TypeOf(config), "")
+}
+
+// decode decodes the node the way the config is loaded and reports whether
+// that succeeded.
+func (v *validator) decode(node *yamlv3.Node, valueType reflect.Type, path string) bool {
+	content, err := yamlv3.Marshal(node)
+	if err != nil {
+		v.add(node, path, "%v", err)
+		return false
+	}
+	if err := yaml.Unmarshal(content, reflect.New(valueType).Interface()); err != nil {
+		for _, message := range decodeErrorMessages(err) {
+			v.add(node, path, "%s", message)
+		}
+		return false
+	}
+	return true
+}
+
+// checkTemplates checks that the templates of the email config exist and
+// parse, the same way they are loaded when sending a mail.
+func (v *validator) checkTemplates(node *yamlv3.Node) {
+	emailConfig := EmailConfig{}
+	content, _ := yamlv3.Marshal(node)
+	if yaml.Unmarshal(content, &emailConfig) != nil {
+		return
+	}
+	if _, err := htmltemplate.ParseFiles(emailConfig.Template); err != nil {
+		v.add(valueNode(node, "template"), "email_config.template", "invalid template: %v", err)
+	}
+	if emailConfig.TextTemplate == "" {
+		return
+	}
+	if _, err := texttemplate.ParseFiles(emailConfig.TextTemplate); err != nil {
+		v.add(valueNode(node, "text_template"), "email_config.text_template", "invalid template: %v", err)
+	}
+}
+
+// valueNode returns the value of the key in the mapping, or the mapping when
+// the key is not set.
+func valueNode(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			return mapping.Content[idx+1]
+		}
+	}
+	return mapping
+}
+
+// resolveAliases replaces the aliases in the node by a copy of the node they
+// refer to, so every part of the config can be decoded on its own.
+func resolveAliases(node *yamlv3.Node) *yamlv3.Node {
+	if node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		resolved := *resolveAliases(node.Alias)
+		resolved.Anchor = ""
+		resolved.Line, resolved.Column = node.Line, node.Column
+		return &resolved
+	}
+	for idx, child := range node.Content {
+		node.Content[idx] = resolveAliases(child)
+	}
+	return node
+}
+
+// decodeErrorMessages splits the type errors of a decode, their lines are
+// left out as they are relative to the decoded part.
+func decodeErrorMessages(err error) []string {
+	typeError, ok := err.(*yaml.TypeError)
+	if !ok {
+		return []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	var messages []string
+	for _, message := range typeError.Errors {
+		if idx := strings.Index(message, ": "); strings.HasPrefix(message, "line ") && idx >= 0 {
+			message = message[idx+2:]
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// syntaxProblems returns the problems of a config that is not valid yaml, at
+// the line in the error.
+func syntaxProblems(err error) []Problem {
+	messages := []string{err.Error()}
+	if typeError, ok := err.(*yamlv3.TypeError); ok {
+		messages = typeError.Errors
+	}
+	var problems []Problem
+	for _, message := range messages {
+		problem := Problem{Line: 1, Column: 1, Message: strings.TrimPrefix(message, "yaml: ")}
+		if match := errorLine.FindStringSubmatch(message); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = strings.TrimPrefix(message, match[0])
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+func nodeProblem(node *yamlv3.Node, path string, message string) Problem {
+	return Problem{Line: node.Line, Column: node.Column, Path: path, Message: message}
+}
+
+// yamlFields returns the types of the fields of the struct by their yaml key,
+// including the fields of inlined structs.
+func yamlFields(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for idx := 0; idx < structType.NumField(); idx++ {
+		field := structType.Field(idx)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			for key, fieldType := range yamlFields(field.Type) {
+				fields[key] = fieldType
+			}
+			continue
+		}
+		if field.PkgPath != "" || tag[0] == "-" {
+			continue
+		}
+		if tag[0] == "" {
+			tag[0] = strings.ToLower(field.Name)
+		}
+		fields[tag[0]] = field.Type
+	}
+	return fields
+}
+
+func sortedKeys(fields map[string]reflect.Type) []string {
+	var keys []string
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// itemPath returns the path of an item of a list, with the name of the item
+// when it has one.
+func itemPath(path string, idx int, item *yamlv3.Node) string {
+	path = fmt.Sprintf("%s[%d]", path, idx)
+	if item.Kind != yamlv3.MappingNode {
+		return path
+	}
+	if name := valueNode(item, "name"); name != item && name.Kind == yamlv3.ScalarNode && name.Value != "" {
+		path += "(" + name.Value + ")"
+	}
+	return path
+}
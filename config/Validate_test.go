@@ -0,0 +1,110 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func problemStrings(problems []Problem) []string {
+	var lines []string
+	for _, problem := range problems {
+		lines = append(lines, problem.String())
+	}
+	return lines
+}
+
+func TestValidate(t *testing.T) {
+	content := `interval: 1h
+intervall: 2h
+pod_rules_labels_filled_in:
+- name: app label
+  labels: [app]
+  filter:
+    include_namespace: [default]
+- name: team label
+deployment_rules_replicas_minimum:
+- name: min replicas
+  minimum_replicas: two
+stateful_set_rules_replicas_minimum:
+- name: min replicas
+  minimum_replicas: 2
+`
+
+	problems := Validate([]byte(content))
+
+	assert.Equal(t, []string{
+		"2:1: intervall: unknown key intervall, valid keys are [cluster_name default_filter deployment_defaults deployment_rules_replicas_minimum email_config events field_rules interval notifications notifiers outputs pod_defaults pod_rules_labels_filled_in pod_rules_limits_filled_in pod_rules_requests_filled_in policy_reports rego_rules resource_rules routing stateful_set_defaults stateful_set_rules_replicas_minimum]",
+		"7:5: pod_rules_labels_filled_in[0](app label).filter.include_namespace: unknown key include_namespace, valid keys are [defaults exclude_annotation_selector exclude_annotations exclude_jobs exclude_labels exclude_names exclude_namespaces exclude_selector include_annotation_selector include_names include_namespaces include_selector namespace_selector]",
+		"8:3: pod_rules_labels_filled_in[1](team label): missing labels for PodRuleLabelsFilledIn",
+		"10:3: deployment_rules_replicas_minimum[0](min replicas): cannot unmarshal !!str `two` into int32",
+	}, problemStrings(problems))
+}
+
+func TestValidate_Valid(t *testing.T) {
+	content := `interval: 1h
+pod_defaults: &defaults
+  exclude_namespaces: [kube-system]
+pod_rules_labels_filled_in:
+- name: app label
+  labels: [app]
+  filter: *defaults
+`
+
+	assert.Empty(t, Validate([]byte(content)))
+}
+
+func TestValidate_Config(t *testing.T) {
+	content := `cluster_name: production
+routing:
+  routes:
+  - notifiers: [slack]
+`
+
+	assert.Equal(t, []string{
+		"1:1: missing interval in config",
+	}, problemStrings(Validate([]byte(content))))
+}
+
+func TestValidate_Syntax(t *testing.T) {
+	content := `interval: 1h
+pod_rules_labels_filled_in:
+  - name: app label
+ labels: [app]
+`
+
+	problems := Validate([]byte(content))
+
+	assert.Equal(t, []string{"3:1: did not find expected key"}, problemStrings(problems))
+	assert.Equal(t, []string{"1:1: empty config"}, problemStrings(Validate([]byte(""))))
+	assert.Equal(t, []string{"1:1: config must be a mapping"}, problemStrings(Validate([]byte("- interval: 1h"))))
+}
+
+func TestValidate_Templates(t *testing.T) {
+	template, err := ioutil.TempFile("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(template.Name())
+	template.WriteString("{{ .Summary.Violations }")
+	template.Close()
+	content := `interval: 1h
+email_config:
+  to: team@example.com
+  host: smtp.example.com
+  template: ` + template.Name() + `
+  text_template: missing.txt
+`
+
+	problems := Validate([]byte(content))
+
+	if assert.Len(t, problems, 2) {
+		assert.Equal(t, Problem{Line: 5, Column: 13, Path: "email_config.template"}, Problem{Line: problems[0].Line, Column: problems[0].Column, Path: problems[0].Path})
+		assert.Contains(t, problems[0].Message, "invalid template")
+		assert.Equal(t, 6, problems[1].Line)
+		assert.Equal(t, "email_config.text_template", problems[1].Path)
+		assert.Contains(t, problems[1].Message, "missing.txt")
+	}
+}
@@ -0,0 +1,298 @@
+package dashboard
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+)
+
+const (
+	historySize    = 100
+	rulesPath      = "/rules/"
+	namespacesPath = "/namespaces/"
+)
+
+var (
+	funcs = template.FuncMap{"pathEscape": url.PathEscape}
+
+	overviewPage   = newPage(overviewTemplate)
+	rulePage       = newPage(ruleTemplate)
+	namespacesPage = newPage(namespacesTemplate)
+	namespacePage  = newPage(namespaceTemplate)
+)
+
+func newPage(content string) *template.Template {
+	page := template.Must(template.New("layout").Funcs(funcs).Parse(layoutTemplate))
+	template.Must(page.Parse(entriesTemplate))
+	return template.Must(page.Parse(content))
+}
+
+// Dashboard renders the report of the last completed evaluation as HTML pages,
+// together with the number of violations of the evaluations before it.
+type Dashboard struct {
+	mutex       sync.RWMutex
+	report      *reports.Report
+	evaluatedAt time.Time
+	history     []Point
+}
+
+// Scorecard sums up the violations in a namespace. The score is the
+// percentage of rules that have no violations in the namespace.
+type Scorecard struct {
+	Namespace     string
+	Violations    int
+	Exempted      int
+	RulesViolated int
+	Score         int
+	Grade         string
+}
+
+type ruleRow struct {
+	Rule       reports.Rule
+	Violations int
+	Exempted   int
+}
+
+// pageData holds everything the pages render, every page uses the fields it
+// needs.
+type pageData struct {
+	Report          *reports.Report
+	EvaluatedAt     time.Time
+	History         []Point
+	Sparkline       string
+	SparklineWidth  int
+	SparklineHeight int
+	Rules           []ruleRow
+	Namespaces      []Scorecard
+	Rule            reports.Rule
+	Scorecard       Scorecard
+	Violations      []reports.Entry
+	Exempted        []reports.Entry
+}
+
+// values returns a value of every point of the history, the oldest first.
+func (p pageData) values(value func(Point) int) []int {
+	values := make([]int, 0, len(p.History))
+	for _, point := range p.History {
+		values = append(values, value(point))
+	}
+	return values
+}
+
+func New() *Dashboard {
+	return &Dashboard{}
+}
+
+// Update replaces the report shown by the dashboard with the report of a
+// completed evaluation and adds it to the history.
+func (d *Dashboard) Update(report reports.Report, evaluatedAt time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.report = &report
+	d.evaluatedAt = evaluatedAt
+	d.history = append(d.history, newPoint(report, evaluatedAt))
+	if len(d.history) > historySize {
+		d.history = d.history[len(d.history)-historySize:]
+	}
+}
+
+// Register adds the pages of the dashboard to the mux, the overview is served
+// on the root.
+func (d *Dashboard) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/", d.overviewHandler)
+	mux.HandleFunc(strings.TrimSuffix(namespacesPath, "/"), d.namespacesHandler)
+	mux.HandleFunc(namespacesPath, d.namespaceHandler)
+	mux.HandleFunc(rulesPath, d.ruleHandler)
+	mux.HandleFunc("/static/dashboard.css", stylesheetHandler)
+}
+
+func (d *Dashboard) data() pageData {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return pageData{
+		Report:          d.report,
+		EvaluatedAt:     d.evaluatedAt,
+		History:         append([]Point{}, d.history...),
+		SparklineWidth:  sparklineWidth,
+		SparklineHeight: sparklineHeight,
+	}
+}
+
+func (d *Dashboard) overviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	data := d.data()
+	if data.Report != nil {
+		data.Sparkline = sparkline(data.values(func(point Point) int { return point.Violations }), sparklineWidth, sparklineHeight)
+		data.Rules = ruleRows(*data.Report)
+		data.Namespaces = Scorecards(*data.Report)
+	}
+	render(w, overviewPage, data)
+}
+
+func (d *Dashboard) ruleHandler(w http.ResponseWriter, r *http.Request) {
+	data := d.data()
+	if data.Report != nil {
+		name := strings.TrimPrefix(r.URL.Path, rulesPath)
+		found := false
+		for _, rule := range data.Report.Rules {
+			if rule.Name == name {
+				data.Rule = rule
+				found = true
+			}
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		data.Violations = data.Report.ViolationsOf(name)
+		data.Sparkline = sparkline(data.values(func(point Point) int { return point.ViolationsByRule[name] }), sparklineWidth, sparklineHeight)
+		for _, exempted := range data.Report.Exempted {
+			if exempted.RuleName == name {
+				data.Exempted = append(data.Exempted, exempted)
+			}
+		}
+	}
+	render(w, rulePage, data)
+}
+
+func (d *Dashboard) namespacesHandler(w http.ResponseWriter, r *http.Request) {
+	data := d.data()
+	if data.Report != nil {
+		data.Namespaces = Scorecards(*data.Report)
+	}
+	render(w, namespacesPage, data)
+}
+
+func (d *Dashboard) namespaceHandler(w http.ResponseWriter, r *http.Request) {
+	data := d.data()
+	if data.Report != nil {
+		namespace := strings.TrimPrefix(r.URL.Path, namespacesPath)
+		if namespace == "" || strings.Contains(namespace, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		data.Scorecard = newScorecard(*data.Report, namespace)
+		data.Sparkline = sparkline(data.values(func(point Point) int { return point.ViolationsByNamespace[namespace] }), sparklineWidth, sparklineHeight)
+		for _, violation := range data.Report.Violations {
+			if violation.Namespace == namespace {
+				data.Violations = append(data.Violations, violation)
+			}
+		}
+		for _, exempted := range data.Report.Exempted {
+			if exempted.Namespace == namespace {
+				data.Exempted = append(data.Exempted, exempted)
+			}
+		}
+	}
+	render(w, namespacePage, data)
+}
+
+func stylesheetHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css")
+	w.Write([]byte(stylesheet))
+}
+
+func render(w http.ResponseWriter, page *template.Template, data pageData) {
+	buf := new(bytes.Buffer)
+	if err := page.Execute(buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if data.Report == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(buf.Bytes())
+}
+
+// ruleRows returns the rules of the report with their number of violations,
+// the rules with the most violations first.
+func ruleRows(report reports.Report) []ruleRow {
+	var rows []ruleRow
+	for _, rule := range report.Rules {
+		rows = append(rows, ruleRow{Rule: rule, Violations: len(report.ViolationsOf(rule.Name))})
+	}
+	for _, exempted := range report.Exempted {
+		for idx := range rows {
+			if rows[idx].Rule.Name == exempted.RuleName {
+				rows[idx].Exempted++
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Violations > rows[j].Violations
+	})
+	return rows
+}
+
+// Scorecards returns a scorecard for every namespace with violations or
+// exempted objects, the namespaces with the most violations first.
+func Scorecards(report reports.Report) []Scorecard {
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, entries := range [][]reports.Entry{report.Violations, report.Exempted} {
+		for _, entry := range entries {
+			if !seen[entry.Namespace] {
+				seen[entry.Namespace] = true
+				namespaces = append(namespaces, entry.Namespace)
+			}
+		}
+	}
+	sort.Strings(namespaces)
+	scorecards := []Scorecard{}
+	for _, namespace := range namespaces {
+		scorecards = append(scorecards, newScorecard(report, namespace))
+	}
+	sort.SliceStable(scorecards, func(i, j int) bool {
+		return scorecards[i].Violations > scorecards[j].Violations
+	})
+	return scorecards
+}
+
+func newScorecard(report reports.Report, namespace string) Scorecard {
+	scorecard := Scorecard{Namespace: namespace}
+	violatedRules := make(map[string]bool)
+	for _, violation := range report.Violations {
+		if violation.Namespace == namespace {
+			scorecard.Violations++
+			violatedRules[violation.RuleName] = true
+		}
+	}
+	for _, exempted := range report.Exempted {
+		if exempted.Namespace == namespace {
+			scorecard.Exempted++
+		}
+	}
+	scorecard.RulesViolated = len(violatedRules)
+	scorecard.Score = 100
+	if len(report.Rules) > 0 {
+		scorecard.Score = 100 * (len(report.Rules) - scorecard.RulesViolated) / len(report.Rules)
+	}
+	scorecard.Grade = grade(scorecard.Score)
+	return scorecard
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
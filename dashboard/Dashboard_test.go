@@ -0,0 +1,141 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReport() reports.Report {
+	return reports.Report{
+		Summary: reports.Summary{Rules: 2, Violations: 3, Exempted: 1, ViolationsBySeverity: map[string]int{"high": 1, "medium": 2}},
+		Rules: []reports.Rule{
+			{Name: "app label", Type: "pod_rules_labels_filled_in", Kind: "Pod", Severity: "high"},
+			{Name: "replicas minimum", Type: "deployment_rules_replicas_minimum", Kind: "Deployment", Severity: "medium"},
+		},
+		Violations: []reports.Entry{
+			{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"},
+			{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "default", Name: "api", Reason: "Deployment replicas below the minimum: 2"},
+			{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "testing", Name: "web", Reason: "Deployment replicas below the minimum: 2"},
+		},
+		Exempted: []reports.Entry{
+			{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "legacy", Name: "old", Reason: "Labels: [app] are not filled in", ExemptionReason: "Legacy workload"},
+		},
+	}
+}
+
+func newDashboard() *Dashboard {
+	dashboard := New()
+	dashboard.Update(newReport(), time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC))
+	return dashboard
+}
+
+func serve(dashboard *Dashboard, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	dashboard.Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr
+}
+
+func TestDashboard_Overview(t *testing.T) {
+	rr := serve(newDashboard(), "/")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "2019-06-15 12:00:00 UTC")
+	assert.Contains(t, rr.Body.String(), `<a href="/rules/replicas%20minimum">replicas minimum</a>`)
+	assert.Contains(t, rr.Body.String(), `<a href="/namespaces/default">default</a>`)
+	assert.Contains(t, rr.Body.String(), `<polyline points="0.0,0.0 240.0,0.0"/>`)
+	assert.NotContains(t, rr.Body.String(), "https://")
+}
+
+func TestDashboard_Overview_NoEvaluation(t *testing.T) {
+	rr := serve(New(), "/")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No evaluation has completed yet.")
+}
+
+func TestDashboard_Overview_NotFound(t *testing.T) {
+	rr := serve(newDashboard(), "/unknown")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDashboard_Rule(t *testing.T) {
+	rr := serve(newDashboard(), "/rules/replicas%20minimum")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<h1>Rule replicas minimum</h1>")
+	assert.Contains(t, rr.Body.String(), "<td>api</td>")
+	assert.Contains(t, rr.Body.String(), "<td>web</td>")
+	assert.NotContains(t, rr.Body.String(), "<td>foo</td>")
+}
+
+func TestDashboard_Rule_NotFound(t *testing.T) {
+	rr := serve(newDashboard(), "/rules/unknown")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDashboard_Namespaces(t *testing.T) {
+	rr := serve(newDashboard(), "/namespaces")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<a href="/namespaces/testing">testing</a>`)
+	assert.Contains(t, rr.Body.String(), `<a href="/namespaces/legacy">legacy</a>`)
+}
+
+func TestDashboard_Namespace(t *testing.T) {
+	rr := serve(newDashboard(), "/namespaces/default")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<h1>Namespace default</h1>")
+	assert.Contains(t, rr.Body.String(), "<td>foo</td>")
+	assert.NotContains(t, rr.Body.String(), "<td>web</td>")
+}
+
+func TestDashboard_Stylesheet(t *testing.T) {
+	rr := serve(New(), "/static/dashboard.css")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/css", rr.Header().Get("Content-Type"))
+	assert.Equal(t, stylesheet, rr.Body.String())
+}
+
+func TestDashboard_Update_History(t *testing.T) {
+	dashboard := New()
+	start := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	for idx := 0; idx < historySize+5; idx++ {
+		report := newReport()
+		report.Summary.Violations = idx
+		dashboard.Update(report, start.Add(time.Duration(idx)*time.Minute))
+	}
+
+	assert.Len(t, dashboard.history, historySize)
+	assert.Equal(t, 5, dashboard.history[0].Violations)
+	assert.Equal(t, historySize+4, dashboard.history[historySize-1].Violations)
+}
+
+func TestScorecards(t *testing.T) {
+	scorecards := Scorecards(newReport())
+
+	assert.Equal(t, []Scorecard{
+		{Namespace: "default", Violations: 2, RulesViolated: 2, Score: 0, Grade: "F"},
+		{Namespace: "testing", Violations: 1, RulesViolated: 1, Score: 50, Grade: "F"},
+		{Namespace: "legacy", Exempted: 1, Score: 100, Grade: "A"},
+	}, scorecards)
+}
+
+func TestGrade(t *testing.T) {
+	assert.Equal(t, "A", grade(100))
+	assert.Equal(t, "B", grade(85))
+	assert.Equal(t, "C", grade(70))
+	assert.Equal(t, "D", grade(65))
+	assert.Equal(t, "F", grade(10))
+}
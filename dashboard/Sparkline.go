@@ -0,0 +1,61 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+)
+
+const (
+	sparklineWidth  = 240
+	sparklineHeight = 40
+)
+
+// Point is the number of violations found by an evaluation, in total and per
+// rule and namespace.
+type Point struct {
+	Time                  time.Time
+	Violations            int
+	ViolationsByRule      map[string]int
+	ViolationsByNamespace map[string]int
+}
+
+func newPoint(report reports.Report, evaluatedAt time.Time) Point {
+	point := Point{
+		Time:                  evaluatedAt,
+		Violations:            report.Summary.Violations,
+		ViolationsByRule:      make(map[string]int),
+		ViolationsByNamespace: make(map[string]int),
+	}
+	for _, violation := range report.Violations {
+		point.ViolationsByRule[violation.RuleName]++
+		point.ViolationsByNamespace[violation.Namespace]++
+	}
+	return point
+}
+
+// sparkline returns the points of an SVG polyline drawing the values, scaled
+// to the width and height. A single value is drawn as a flat line.
+func sparkline(values []int, width, height int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if len(values) == 1 {
+		values = []int{values[0], values[0]}
+	}
+	max := 1
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+	points := make([]string, 0, len(values))
+	for idx, value := range values {
+		x := float64(idx*width) / float64(len(values)-1)
+		y := float64(height) - float64(value*height)/float64(max)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(points, " ")
+}
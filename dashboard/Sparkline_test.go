@@ -0,0 +1,32 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "0.0,0.0 100.0,20.0 200.0,40.0", sparkline([]int{4, 2, 0}, 200, 40))
+}
+
+func TestSparkline_SinglePoint(t *testing.T) {
+	assert.Equal(t, "0.0,0.0 200.0,0.0", sparkline([]int{3}, 200, 40))
+}
+
+func TestSparkline_NoViolations(t *testing.T) {
+	assert.Equal(t, "0.0,40.0 200.0,40.0", sparkline([]int{0, 0}, 200, 40))
+}
+
+func TestSparkline_Empty(t *testing.T) {
+	assert.Equal(t, "", sparkline(nil, 200, 40))
+}
+
+func TestNewPoint(t *testing.T) {
+	point := newPoint(newReport(), time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, 3, point.Violations)
+	assert.Equal(t, map[string]int{"app label": 1, "replicas minimum": 2}, point.ViolationsByRule)
+	assert.Equal(t, map[string]int{"default": 2, "testing": 1}, point.ViolationsByNamespace)
+}
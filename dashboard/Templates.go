@@ -0,0 +1,123 @@
+package dashboard
+
+// The templates and the stylesheet are compiled into the binary so the
+// dashboard works without access to the internet or to files next to it.
+
+const stylesheet = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #24292e; background: #f6f8fa; }
+header { background: #24292e; padding: 12px 24px; }
+header a { color: #fff; margin-right: 16px; text-decoration: none; }
+header a.title { font-weight: bold; }
+main { padding: 16px 24px; }
+h1 { font-size: 24px; }
+h2 { font-size: 18px; margin-top: 32px; }
+a { color: #0366d6; }
+.cards { display: flex; flex-wrap: wrap; }
+.card { background: #fff; border: 1px solid #e1e4e8; border-radius: 4px; padding: 12px 16px; margin: 0 12px 12px 0; min-width: 140px; }
+.card .value { font-size: 28px; font-weight: bold; }
+.card .label { color: #586069; font-size: 12px; text-transform: uppercase; }
+table { border-collapse: collapse; background: #fff; width: 100%; }
+th, td { border: 1px solid #e1e4e8; padding: 6px 10px; text-align: left; }
+th { background: #f1f3f5; }
+td.number { text-align: right; }
+.severity { border-radius: 3px; color: #fff; font-size: 12px; padding: 1px 6px; }
+.severity-critical { background: #86181d; }
+.severity-high { background: #d73a49; }
+.severity-medium { background: #e36209; }
+.severity-low { background: #dbab09; }
+.severity-info { background: #6a737d; }
+.grade { font-weight: bold; }
+.grade-A { color: #22863a; }
+.grade-B { color: #28a745; }
+.grade-C { color: #dbab09; }
+.grade-D { color: #e36209; }
+.grade-F { color: #d73a49; }
+.sparkline polyline { fill: none; stroke: #d73a49; stroke-width: 2; }
+.empty { color: #586069; }
+`
+
+const layoutTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Kube conformity</title>
+<link rel="stylesheet" href="/static/dashboard.css">
+</head>
+<body>
+<header>
+<a class="title" href="/">Kube conformity</a>
+<a href="/namespaces">Namespaces</a>
+<a href="/config">Configuration</a>
+<a href="/filters">Effective filters</a>
+<a href="/metrics">Metrics</a>
+<a href="/healthz">Health Check</a>
+</header>
+<main>
+{{if .Report}}{{template "content" .}}{{else}}<h1>Kube conformity</h1>
+<p class="empty">No evaluation has completed yet.</p>{{end}}
+</main>
+</body>
+</html>
+`
+
+const entriesTemplate = `{{define "entries"}}{{if .}}<table>
+<tr><th>Rule</th><th>Severity</th><th>Kind</th><th>Namespace</th><th>Name</th><th>Reason</th></tr>
+{{range .}}<tr><td><a href="/rules/{{pathEscape .RuleName}}">{{.RuleName}}</a></td><td><span class="severity severity-{{.Severity}}">{{.Severity}}</span></td><td>{{.Kind}}</td><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">None.</p>{{end}}{{end}}
+{{define "exempted"}}{{if .}}<table>
+<tr><th>Rule</th><th>Kind</th><th>Namespace</th><th>Name</th><th>Until</th><th>Reason</th><th>Ticket</th></tr>
+{{range .}}<tr><td><a href="/rules/{{pathEscape .RuleName}}">{{.RuleName}}</a></td><td>{{.Kind}}</td><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{if .ExemptedUntil}}{{.ExemptedUntil}}{{else}}forever{{end}}</td><td>{{.ExemptionReason}}</td><td>{{.ExemptionTicket}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">None.</p>{{end}}{{end}}
+{{define "sparkline"}}<div class="card"><svg class="sparkline" width="{{.SparklineWidth}}" height="{{.SparklineHeight}}"><polyline points="{{.Sparkline}}"/></svg><div class="label">Violations over the last {{len .History}} evaluations</div></div>{{end}}
+{{define "scorecards"}}<table>
+<tr><th>Namespace</th><th>Grade</th><th>Score</th><th>Rules violated</th><th>Violations</th><th>Exempted</th></tr>
+{{range .}}<tr><td>{{if .Namespace}}<a href="/namespaces/{{pathEscape .Namespace}}">{{.Namespace}}</a>{{else}}(cluster){{end}}</td><td class="grade grade-{{.Grade}}">{{.Grade}}</td><td class="number">{{.Score}}%</td><td class="number">{{.RulesViolated}}</td><td class="number">{{.Violations}}</td><td class="number">{{.Exempted}}</td></tr>
+{{end}}</table>{{end}}
+`
+
+const overviewTemplate = `{{define "content"}}<h1>Overview</h1>
+<p>Last evaluation: {{.EvaluatedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+<div class="cards">
+<div class="card"><div class="value">{{.Report.Summary.Violations}}</div><div class="label">Violations</div></div>
+<div class="card"><div class="value">{{.Report.Summary.Exempted}}</div><div class="label">Exempted</div></div>
+<div class="card"><div class="value">{{.Report.Summary.Rules}}</div><div class="label">Rules</div></div>
+<div class="card"><div class="value">{{len .Namespaces}}</div><div class="label">Namespaces with findings</div></div>
+{{template "sparkline" .}}
+</div>
+<h2>Violations per rule</h2>
+<table>
+<tr><th>Rule</th><th>Type</th><th>Kind</th><th>Severity</th><th>Violations</th><th>Exempted</th></tr>
+{{range .Rules}}<tr><td><a href="/rules/{{pathEscape .Rule.Name}}">{{.Rule.Name}}</a></td><td>{{.Rule.Type}}</td><td>{{.Rule.Kind}}</td><td><span class="severity severity-{{.Rule.Severity}}">{{.Rule.Severity}}</span></td><td class="number">{{.Violations}}</td><td class="number">{{.Exempted}}</td></tr>
+{{end}}</table>
+<h2>Violations per namespace</h2>
+{{if .Namespaces}}{{template "scorecards" .Namespaces}}{{else}}<p class="empty">None.</p>{{end}}
+{{end}}`
+
+const ruleTemplate = `{{define "content"}}<h1>Rule {{.Rule.Name}}</h1>
+<p>{{.Rule.Type}} on {{.Rule.Kind}}, severity <span class="severity severity-{{.Rule.Severity}}">{{.Rule.Severity}}</span></p>
+<div class="cards">
+{{template "sparkline" .}}
+</div>
+<h2>Violations ({{len .Violations}})</h2>
+{{template "entries" .Violations}}
+<h2>Exempted ({{len .Exempted}})</h2>
+{{template "exempted" .Exempted}}
+{{end}}`
+
+const namespacesTemplate = `{{define "content"}}<h1>Namespace scorecard</h1>
+<p>The score is the percentage of rules without violations in the namespace.</p>
+{{if .Namespaces}}{{template "scorecards" .Namespaces}}{{else}}<p class="empty">No namespace violates a rule.</p>{{end}}
+{{end}}`
+
+const namespaceTemplate = `{{define "content"}}<h1>Namespace {{.Scorecard.Namespace}}</h1>
+<div class="cards">
+<div class="card"><div class="value grade grade-{{.Scorecard.Grade}}">{{.Scorecard.Grade}}</div><div class="label">Grade</div></div>
+<div class="card"><div class="value">{{.Scorecard.Score}}%</div><div class="label">Score</div></div>
+<div class="card"><div class="value">{{.Scorecard.Violations}}</div><div class="label">Violations</div></div>
+<div class="card"><div class="value">{{.Scorecard.Exempted}}</div><div class="label">Exempted</div></div>
+{{template "sparkline" .}}
+</div>
+<h2>Violations</h2>
+{{template "entries" .Violations}}
+<h2>Exempted</h2>
+{{template "exempted" .Exempted}}
+{{end}}`
@@ -1,16 +1,32 @@
 package filters
 
 import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 type Filter struct {
-	IncludeNamespaces  []string          `yaml:"include_namespaces"`
-	ExcludeNamespaces  []string          `yaml:"exclude_namespaces"`
-	ExcludeAnnotations map[string]string `yaml:"exclude_annotations"`
-	ExcludeLabels      map[string]string `yaml:"exclude_labels"`
+	IncludeNamespaces         []string          `yaml:"include_namespaces"`
+	ExcludeNamespaces         []string          `yaml:"exclude_namespaces"`
+	IncludeNames              []string          `yaml:"include_names"`
+	ExcludeNames              []string          `yaml:"exclude_names"`
+	ExcludeAnnotations        map[string]string `yaml:"exclude_annotations"`
+	ExcludeLabels             map[string]string `yaml:"exclude_labels"`
+	IncludeSelector           string            `yaml:"include_selector"`
+	ExcludeSelector           string            `yaml:"exclude_selector"`
+	IncludeAnnotationSelector string            `yaml:"include_annotation_selector"`
+	ExcludeAnnotationSelector string            `yaml:"exclude_annotation_selector"`
+	NamespaceSelector         string            `yaml:"namespace_selector"`
+	Defaults                  string            `yaml:"defaults,omitempty"`
 }
 
 type DeploymentFilter struct {
@@ -21,6 +37,10 @@ type StatefulsetFilter struct {
 	Filter `yaml:",inline"`
 }
 
+type ObjectFilter struct {
+	Filter `yaml:",inline"`
+}
+
 type PodFilter struct {
 	Filter           `yaml:",inline"`
 	ExcludeJobs bool `yaml:"exclude_jobs"`
@@ -29,8 +49,126 @@ type PodFilter struct {
 func (f Filter) FilterObjects(objects []metav1.Object) []metav1.Object {
 	filteredObjects := f.FilterIncludeNamespace(objects)
 	filteredObjects = f.FilterExcludeNamespace(filteredObjects)
+	filteredObjects = f.FilterIncludeName(filteredObjects)
+	filteredObjects = f.FilterExcludeName(filteredObjects)
 	filteredObjects = f.FilterExcludeAnnotations(filteredObjects)
 	filteredObjects = f.FilterExcludeLabels(filteredObjects)
+	filteredObjects = f.FilterIncludeSelector(filteredObjects)
+	filteredObjects = f.FilterExcludeSelector(filteredObjects)
+	filteredObjects = f.FilterIncludeAnnotationSelector(filteredObjects)
+	filteredObjects = f.FilterExcludeAnnotationSelector(filteredObjects)
+	return filteredObjects
+}
+
+// Validate checks that all patterns and selectors of the filter can be parsed.
+func (f Filter) Validate() error {
+	patternLists := map[string][]string{
+		"include_namespaces": f.IncludeNamespaces,
+		"exclude_namespaces": f.ExcludeNamespaces,
+		"include_names":      f.IncludeNames,
+		"exclude_names":      f.ExcludeNames,
+	}
+	for field, patterns := range patternLists {
+		for _, pattern := range patterns {
+			if err := validatePattern(pattern); err != nil {
+				return fmt.Errorf("invalid pattern %q in %s: %v", pattern, field, err)
+			}
+		}
+	}
+	selectors := map[string]string{
+		"include_selector":            f.IncludeSelector,
+		"exclude_selector":            f.ExcludeSelector,
+		"include_annotation_selector": f.IncludeAnnotationSelector,
+		"exclude_annotation_selector": f.ExcludeAnnotationSelector,
+		"namespace_selector":          f.NamespaceSelector,
+	}
+	for field, selector := range selectors {
+		if _, err := labels.Parse(selector); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", field, selector, err)
+		}
+	}
+	if f.Defaults != "" && f.Defaults != DefaultsMerge && f.Defaults != DefaultsOverride {
+		return fmt.Errorf("invalid defaults %q, must be %s or %s", f.Defaults, DefaultsMerge, DefaultsOverride)
+	}
+	return nil
+}
+
+// ListOptions returns the options to list objects with, the include selector
+// is handed to the API server so it only returns the objects that can match.
+func (f Filter) ListOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: f.IncludeSelector}
+}
+
+// ListNamespace returns the namespace to list objects in, when the filter only
+// includes a single namespace there is no need to list all namespaces.
+func (f Filter) ListNamespace() string {
+	if len(f.IncludeNamespaces) == 1 && isLiteral(f.IncludeNamespaces[0]) {
+		return f.IncludeNamespaces[0]
+	}
+	return apiv1.NamespaceAll
+}
+
+// Patterns are globs like team-*, or regular expressions when they start with
+// ^ or end with $. A pattern starting with ! is negated.
+func isRegex(pattern string) bool {
+	return strings.HasPrefix(pattern, "^") || strings.HasSuffix(pattern, "$")
+}
+
+func isLiteral(pattern string) bool {
+	return !isRegex(pattern) && !strings.HasPrefix(pattern, "!") && !strings.ContainsAny(pattern, "*?[\\")
+}
+
+func validatePattern(pattern string) error {
+	pattern = strings.TrimPrefix(pattern, "!")
+	if isRegex(pattern) {
+		_, err := regexp.Compile(pattern)
+		return err
+	}
+	_, err := path.Match(pattern, "")
+	return err
+}
+
+func matchesPattern(value, pattern string) bool {
+	if isRegex(pattern) {
+		matched, err := regexp.MatchString(pattern, value)
+		return err == nil && matched
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// matchesPatterns reports whether the value matches at least one of the
+// patterns that are not negated, or all patterns are negated, and none of the
+// negated patterns. So [team-*, !team-test] matches team-a but not team-test,
+// and [!kube-system] matches everything except kube-system.
+func matchesPatterns(value string, patterns []string) bool {
+	hasPositive := false
+	matchesPositive := false
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if matchesPattern(value, strings.TrimPrefix(pattern, "!")) {
+				return false
+			}
+			continue
+		}
+		hasPositive = true
+		matchesPositive = matchesPositive || matchesPattern(value, pattern)
+	}
+	return !hasPositive || matchesPositive
+}
+
+func filterPatterns(objects []metav1.Object, patterns []string, include bool, value func(metav1.Object) string) []metav1.Object {
+	if len(patterns) == 0 {
+		return objects
+	}
+
+	var filteredObjects []metav1.Object
+
+	for _, object := range objects {
+		if matchesPatterns(value(object), patterns) == include {
+			filteredObjects = append(filteredObjects, object)
+		}
+	}
 	return filteredObjects
 }
 
@@ -103,41 +241,19 @@ func (f StatefulsetFilter) FilterStatefulSets(statefulSets []appsv1.StatefulSet)
 }
 
 func (f Filter) FilterIncludeNamespace(objects []metav1.Object) []metav1.Object {
-	if len(f.IncludeNamespaces) == 0 {
-		return objects
-	}
-
-	var filteredObjects []metav1.Object
-
-	for _, object := range objects {
-		include := false
-		for _, namespace := range f.IncludeNamespaces {
-			include = include || object.GetNamespace() == namespace
-		}
-		if include {
-			filteredObjects = append(filteredObjects, object)
-		}
-	}
-	return filteredObjects
+	return filterPatterns(objects, f.IncludeNamespaces, true, metav1.Object.GetNamespace)
 }
 
 func (f Filter) FilterExcludeNamespace(objects []metav1.Object) []metav1.Object {
-	if len(f.ExcludeNamespaces) == 0 {
-		return objects
-	}
+	return filterPatterns(objects, f.ExcludeNamespaces, false, metav1.Object.GetNamespace)
+}
 
-	var filteredObjects []metav1.Object
+func (f Filter) FilterIncludeName(objects []metav1.Object) []metav1.Object {
+	return filterPatterns(objects, f.IncludeNames, true, metav1.Object.GetName)
+}
 
-	for _, object := range objects {
-		exclude := false
-		for _, namespace := range f.ExcludeNamespaces {
-			exclude = exclude || object.GetNamespace() == namespace
-		}
-		if !exclude {
-			filteredObjects = append(filteredObjects, object)
-		}
-	}
-	return filteredObjects
+func (f Filter) FilterExcludeName(objects []metav1.Object) []metav1.Object {
+	return filterPatterns(objects, f.ExcludeNames, false, metav1.Object.GetName)
 }
 
 func (f Filter) FilterExcludeAnnotations(objects []metav1.Object) []metav1.Object {
@@ -182,6 +298,133 @@ func (f Filter) FilterExcludeLabels(objects []metav1.Object) []metav1.Object {
 	return filteredObjects
 }
 
+func (f Filter) FilterIncludeSelector(objects []metav1.Object) []metav1.Object {
+	return filterSelector(objects, f.IncludeSelector, true, metav1.Object.GetLabels)
+}
+
+func (f Filter) FilterExcludeSelector(objects []metav1.Object) []metav1.Object {
+	return filterSelector(objects, f.ExcludeSelector, false, metav1.Object.GetLabels)
+}
+
+func (f Filter) FilterIncludeAnnotationSelector(objects []metav1.Object) []metav1.Object {
+	return filterSelector(objects, f.IncludeAnnotationSelector, true, metav1.Object.GetAnnotations)
+}
+
+func (f Filter) FilterExcludeAnnotationSelector(objects []metav1.Object) []metav1.Object {
+	return filterSelector(objects, f.ExcludeAnnotationSelector, false, metav1.Object.GetAnnotations)
+}
+
+// filterSelector keeps the objects that match the selector when include is
+// true, and the ones that do not match it when include is false. An invalid
+// selector is rejected by Validate when the config is loaded, at this point it
+// leaves the objects untouched.
+func filterSelector(objects []metav1.Object, selector string, include bool, set func(metav1.Object) map[string]string) []metav1.Object {
+	if selector == "" {
+		return objects
+	}
+	parsedSelector, err := labels.Parse(selector)
+	if err != nil {
+		return objects
+	}
+
+	var filteredObjects []metav1.Object
+
+	for _, object := range objects {
+		if parsedSelector.Matches(labels.Set(set(object))) == include {
+			filteredObjects = append(filteredObjects, object)
+		}
+	}
+	return filteredObjects
+}
+
+// checkUnknownKeys rejects keys that are not a field of the filter, so a typo
+// does not silently disable part of the filter.
+func checkUnknownKeys(unmarshal func(interface{}) error, filter interface{}) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	knownKeys := yamlKeys(reflect.TypeOf(filter))
+	var unknownKeys []string
+	for key := range raw {
+		if !knownKeys[key] {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	if len(unknownKeys) == 0 {
+		return nil
+	}
+	sort.Strings(unknownKeys)
+	var validKeys []string
+	for key := range knownKeys {
+		validKeys = append(validKeys, key)
+	}
+	sort.Strings(validKeys)
+	return fmt.Errorf("unknown filter keys %v, valid keys are %v", unknownKeys, validKeys)
+}
+
+func yamlKeys(structType reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for idx := 0; idx < structType.NumField(); idx++ {
+		field := structType.Field(idx)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			for key := range yamlKeys(field.Type) {
+				keys[key] = true
+			}
+			continue
+		}
+		if tag[0] != "" && tag[0] != "-" {
+			keys[tag[0]] = true
+		}
+	}
+	return keys
+}
+
+func (f *ObjectFilter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := checkUnknownKeys(unmarshal, *f); err != nil {
+		return err
+	}
+	type plain ObjectFilter
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	return f.Validate()
+}
+
+func (f *PodFilter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := checkUnknownKeys(unmarshal, *f); err != nil {
+		return err
+	}
+	type plain PodFilter
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	return f.Validate()
+}
+
+func (f *DeploymentFilter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := checkUnknownKeys(unmarshal, *f); err != nil {
+		return err
+	}
+	type plain DeploymentFilter
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	return f.Validate()
+}
+
+func (f *StatefulsetFilter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := checkUnknownKeys(unmarshal, *f); err != nil {
+		return err
+	}
+	type plain StatefulsetFilter
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	return f.Validate()
+}
+
 func (f PodFilter) FilterExcludeJobs(objects []metav1.Object) []metav1.Object {
 	if !f.ExcludeJobs {
 		return objects
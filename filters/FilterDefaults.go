@@ -0,0 +1,90 @@
+package filters
+
+const (
+	DefaultsMerge    = "merge"
+	DefaultsOverride = "override"
+)
+
+// WithDefaults returns the effective filter of a rule given the defaults that
+// apply to it. With defaults set to override the filter is used as is. With
+// merge, the default, every field that is empty takes the value of the
+// defaults, include lists and selectors set on the filter replace the ones of
+// the defaults, exclude lists are appended to the ones of the defaults and
+// exclude maps are merged, the filter winning on duplicate keys.
+func (f Filter) WithDefaults(defaults Filter) Filter {
+	if f.Defaults == DefaultsOverride {
+		return f
+	}
+	effective := f
+	effective.Defaults = ""
+	effective.IncludeNamespaces = replaceList(f.IncludeNamespaces, defaults.IncludeNamespaces)
+	effective.ExcludeNamespaces = appendList(defaults.ExcludeNamespaces, f.ExcludeNamespaces)
+	effective.IncludeNames = replaceList(f.IncludeNames, defaults.IncludeNames)
+	effective.ExcludeNames = appendList(defaults.ExcludeNames, f.ExcludeNames)
+	effective.ExcludeAnnotations = mergeMap(defaults.ExcludeAnnotations, f.ExcludeAnnotations)
+	effective.ExcludeLabels = mergeMap(defaults.ExcludeLabels, f.ExcludeLabels)
+	effective.IncludeSelector = replaceString(f.IncludeSelector, defaults.IncludeSelector)
+	effective.ExcludeSelector = replaceString(f.ExcludeSelector, defaults.ExcludeSelector)
+	effective.IncludeAnnotationSelector = replaceString(f.IncludeAnnotationSelector, defaults.IncludeAnnotationSelector)
+	effective.ExcludeAnnotationSelector = replaceString(f.ExcludeAnnotationSelector, defaults.ExcludeAnnotationSelector)
+	effective.NamespaceSelector = replaceString(f.NamespaceSelector, defaults.NamespaceSelector)
+	return effective
+}
+
+// WithDefaults applies the defaults to the pod filter, when merging jobs are
+// excluded when either the filter or the defaults exclude them.
+func (f PodFilter) WithDefaults(defaults PodFilter) PodFilter {
+	effective := PodFilter{
+		Filter:      f.Filter.WithDefaults(defaults.Filter),
+		ExcludeJobs: f.ExcludeJobs,
+	}
+	if f.Defaults != DefaultsOverride {
+		effective.ExcludeJobs = f.ExcludeJobs || defaults.ExcludeJobs
+	}
+	return effective
+}
+
+func replaceList(values, defaults []string) []string {
+	if len(values) > 0 {
+		return values
+	}
+	return defaults
+}
+
+func appendList(defaults, values []string) []string {
+	if len(defaults) == 0 {
+		return values
+	}
+	merged := append([]string{}, defaults...)
+	for _, value := range values {
+		duplicate := false
+		for _, existing := range defaults {
+			duplicate = duplicate || existing == value
+		}
+		if !duplicate {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+func mergeMap(defaults, values map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return values
+	}
+	merged := make(map[string]string)
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
+
+func replaceString(value, defaults string) string {
+	if value != "" {
+		return value
+	}
+	return defaults
+}
@@ -0,0 +1,85 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFilter_WithDefaults_Empty(t *testing.T) {
+	defaults := Filter{
+		ExcludeNamespaces: []string{"kube-system"},
+		IncludeSelector:   "app",
+	}
+
+	effective := Filter{}.WithDefaults(defaults)
+
+	assert.Equal(t, defaults, effective)
+}
+
+func TestFilter_WithDefaults_Merge(t *testing.T) {
+	defaults := Filter{
+		IncludeNamespaces:  []string{"team-*"},
+		ExcludeNamespaces:  []string{"kube-system"},
+		ExcludeLabels:      map[string]string{"tier": "debug", "app": "default"},
+		IncludeSelector:    "app",
+		ExcludeNames:       []string{"debug-*"},
+		ExcludeAnnotations: map[string]string{"skip": "true"},
+	}
+	filter := Filter{
+		IncludeNamespaces: []string{"default"},
+		ExcludeNamespaces: []string{"kube-public", "kube-system"},
+		ExcludeLabels:     map[string]string{"app": "rule"},
+		IncludeSelector:   "team=a",
+	}
+
+	effective := filter.WithDefaults(defaults)
+
+	assert.Equal(t, []string{"default"}, effective.IncludeNamespaces)
+	assert.Equal(t, []string{"kube-system", "kube-public"}, effective.ExcludeNamespaces)
+	assert.Equal(t, map[string]string{"tier": "debug", "app": "rule"}, effective.ExcludeLabels)
+	assert.Equal(t, "team=a", effective.IncludeSelector)
+	assert.Equal(t, []string{"debug-*"}, effective.ExcludeNames)
+	assert.Equal(t, map[string]string{"skip": "true"}, effective.ExcludeAnnotations)
+	assert.Equal(t, []string{"kube-system"}, defaults.ExcludeNamespaces)
+}
+
+func TestFilter_WithDefaults_Override(t *testing.T) {
+	defaults := Filter{
+		ExcludeNamespaces: []string{"kube-system"},
+	}
+	filter := Filter{
+		IncludeNamespaces: []string{"kube-system"},
+		Defaults:          DefaultsOverride,
+	}
+
+	effective := filter.WithDefaults(defaults)
+
+	assert.Equal(t, filter, effective)
+}
+
+func TestPodFilter_WithDefaults(t *testing.T) {
+	defaults := PodFilter{
+		Filter:      Filter{ExcludeNamespaces: []string{"kube-system"}},
+		ExcludeJobs: true,
+	}
+
+	effective := PodFilter{}.WithDefaults(defaults)
+	assert.True(t, effective.ExcludeJobs)
+	assert.Equal(t, []string{"kube-system"}, effective.ExcludeNamespaces)
+
+	effective = PodFilter{Filter: Filter{Defaults: DefaultsOverride}}.WithDefaults(defaults)
+	assert.False(t, effective.ExcludeJobs)
+	assert.Empty(t, effective.ExcludeNamespaces)
+}
+
+func TestFilter_Validate_InvalidDefaults(t *testing.T) {
+	test := `
+defaults: replace`
+
+	objectFilter := ObjectFilter{}
+	err := yaml.Unmarshal([]byte(test), &objectFilter)
+
+	assert.NotNil(t, err)
+}
@@ -309,4 +309,271 @@ func newPodWithAnnotations(namespace, name string, uid types.UID, annotations ma
 			Annotations: annotations,
 		},
 	}
-}
\ No newline at end of file
+}
+func TestFilter_FilterIncludeSelector(t *testing.T) {
+	filter := Filter{
+		IncludeSelector: "app in (foo, bar), !canary",
+	}
+
+	pods := []apiv1.Pod{
+		newPodWithLabels("default", "name1", "uid1", map[string]string{"app": "foo"}),
+		newPodWithLabels("default", "name2", "uid2", map[string]string{"app": "foo", "canary": "true"}),
+		newPodWithLabels("default", "name3", "uid3", map[string]string{"app": "baz"}),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterIncludeSelector(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name1", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterExcludeSelector(t *testing.T) {
+	filter := Filter{
+		ExcludeSelector: "tier!=frontend",
+	}
+
+	pods := []apiv1.Pod{
+		newPodWithLabels("default", "name1", "uid1", map[string]string{"tier": "frontend"}),
+		newPodWithLabels("default", "name2", "uid2", map[string]string{"tier": "backend"}),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterExcludeSelector(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name1", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterIncludeAnnotationSelector(t *testing.T) {
+	filter := Filter{
+		IncludeAnnotationSelector: "owner",
+	}
+
+	pods := []apiv1.Pod{
+		newPodWithAnnotations("default", "name1", "uid1", map[string]string{"owner": "team-a"}),
+		newPodWithAnnotations("default", "name2", "uid2", map[string]string{}),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterIncludeAnnotationSelector(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name1", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterExcludeAnnotationSelector(t *testing.T) {
+	filter := Filter{
+		ExcludeAnnotationSelector: "skip-conformity=true",
+	}
+
+	pods := []apiv1.Pod{
+		newPodWithAnnotations("default", "name1", "uid1", map[string]string{"skip-conformity": "true"}),
+		newPodWithAnnotations("default", "name2", "uid2", map[string]string{}),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterExcludeAnnotationSelector(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name2", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterIncludeSelector_Empty(t *testing.T) {
+	filter := Filter{}
+
+	objects := convertPodsToObjects([]apiv1.Pod{newPod("default", "name", "uid1")})
+
+	filteredObjects := filter.FilterIncludeSelector(objects)
+	assert.Len(t, filteredObjects, 1)
+}
+
+func TestFilter_ListOptions(t *testing.T) {
+	filter := Filter{
+		IncludeSelector: "app=foo",
+	}
+
+	assert.Equal(t, "app=foo", filter.ListOptions().LabelSelector)
+}
+
+func TestFilter_ListNamespace(t *testing.T) {
+	assert.Equal(t, "", Filter{}.ListNamespace())
+	assert.Equal(t, "default", Filter{IncludeNamespaces: []string{"default"}}.ListNamespace())
+	assert.Equal(t, "", Filter{IncludeNamespaces: []string{"default", "testing"}}.ListNamespace())
+}
+
+func TestPodFilter_UnmarshalYAML_Selectors(t *testing.T) {
+	test := `
+include_selector: app in (a,b), !canary
+exclude_selector: tier!=frontend
+include_annotation_selector: owner
+exclude_annotation_selector: skip=true
+namespace_selector: team=payments
+exclude_jobs: true`
+
+	podFilter := PodFilter{}
+	err := yaml.Unmarshal([]byte(test), &podFilter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "app in (a,b), !canary", podFilter.IncludeSelector)
+	assert.Equal(t, "tier!=frontend", podFilter.ExcludeSelector)
+	assert.Equal(t, "owner", podFilter.IncludeAnnotationSelector)
+	assert.Equal(t, "skip=true", podFilter.ExcludeAnnotationSelector)
+	assert.Equal(t, "team=payments", podFilter.NamespaceSelector)
+	assert.True(t, podFilter.ExcludeJobs)
+}
+
+func TestDeploymentFilter_UnmarshalYAML_InvalidSelector(t *testing.T) {
+	test := `include_selector: app in (a,`
+
+	deploymentFilter := DeploymentFilter{}
+	err := yaml.Unmarshal([]byte(test), &deploymentFilter)
+
+	assert.NotNil(t, err)
+}
+
+func TestFilter_Validate_InvalidSelector(t *testing.T) {
+	filter := Filter{NamespaceSelector: "team in"}
+
+	assert.NotNil(t, filter.Validate())
+}
+
+func TestFilter_FilterIncludeNamespace_Patterns(t *testing.T) {
+	filter := Filter{
+		IncludeNamespaces: []string{"team-*", "^ci-[0-9]+$", "!team-test"},
+	}
+
+	pods := []apiv1.Pod{
+		newPod("team-a", "name1", "uid1"),
+		newPod("team-test", "name2", "uid2"),
+		newPod("ci-42", "name3", "uid3"),
+		newPod("ci-test", "name4", "uid4"),
+		newPod("default", "name5", "uid5"),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterIncludeNamespace(objects)
+	assert.Len(t, filteredObjects, 2)
+	assert.Equal(t, "name1", filteredObjects[0].GetName())
+	assert.Equal(t, "name3", filteredObjects[1].GetName())
+}
+
+func TestFilter_FilterIncludeNamespace_Negation(t *testing.T) {
+	filter := Filter{
+		IncludeNamespaces: []string{"!kube-system"},
+	}
+
+	pods := []apiv1.Pod{
+		newPod("default", "name1", "uid1"),
+		newPod("kube-system", "name2", "uid2"),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterIncludeNamespace(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name1", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterExcludeNamespace_Glob(t *testing.T) {
+	filter := Filter{
+		ExcludeNamespaces: []string{"kube-*"},
+	}
+
+	pods := []apiv1.Pod{
+		newPod("kube-system", "name1", "uid1"),
+		newPod("kube-public", "name2", "uid2"),
+		newPod("default", "name3", "uid3"),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterExcludeNamespace(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "name3", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterIncludeName(t *testing.T) {
+	filter := Filter{
+		IncludeNames: []string{"api-*"},
+	}
+
+	pods := []apiv1.Pod{
+		newPod("default", "api-1", "uid1"),
+		newPod("default", "worker-1", "uid2"),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterIncludeName(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "api-1", filteredObjects[0].GetName())
+}
+
+func TestFilter_FilterExcludeName(t *testing.T) {
+	filter := Filter{
+		ExcludeNames: []string{"^debug-.*$"},
+	}
+
+	pods := []apiv1.Pod{
+		newPod("default", "debug-shell", "uid1"),
+		newPod("default", "api", "uid2"),
+	}
+	objects := convertPodsToObjects(pods)
+
+	filteredObjects := filter.FilterExcludeName(objects)
+	assert.Len(t, filteredObjects, 1)
+	assert.Equal(t, "api", filteredObjects[0].GetName())
+}
+
+func TestFilter_ListNamespace_Pattern(t *testing.T) {
+	assert.Equal(t, "", Filter{IncludeNamespaces: []string{"team-*"}}.ListNamespace())
+	assert.Equal(t, "", Filter{IncludeNamespaces: []string{"!kube-system"}}.ListNamespace())
+	assert.Equal(t, "", Filter{IncludeNamespaces: []string{"^default$"}}.ListNamespace())
+}
+
+func TestFilter_Validate_InvalidPattern(t *testing.T) {
+	assert.NotNil(t, Filter{IncludeNamespaces: []string{"^team-($"}}.Validate())
+	assert.NotNil(t, Filter{ExcludeNames: []string{"!team-["}}.Validate())
+	assert.Nil(t, Filter{IncludeNames: []string{"!team-*", "^ci-.*$"}}.Validate())
+}
+
+func TestPodFilter_UnmarshalYAML_UnknownKey(t *testing.T) {
+	test := `
+namespaces: "!kube-system"`
+
+	podFilter := PodFilter{}
+	err := yaml.Unmarshal([]byte(test), &podFilter)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "namespaces")
+}
+
+func TestDeploymentFilter_UnmarshalYAML_UnknownKey(t *testing.T) {
+	test := `
+exclude_jobs: true`
+
+	deploymentFilter := DeploymentFilter{}
+	err := yaml.Unmarshal([]byte(test), &deploymentFilter)
+
+	assert.NotNil(t, err)
+}
+
+func TestObjectFilter_UnmarshalYAML(t *testing.T) {
+	test := `
+include_names:
+- api-*
+exclude_namespaces:
+- kube-*`
+
+	objectFilter := ObjectFilter{}
+	err := yaml.Unmarshal([]byte(test), &objectFilter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api-*"}, objectFilter.IncludeNames)
+	assert.Equal(t, []string{"kube-*"}, objectFilter.ExcludeNamespaces)
+}
+
+func TestObjectFilter_UnmarshalYAML_UnknownKey(t *testing.T) {
+	test := `
+names:
+- api-*`
+
+	objectFilter := ObjectFilter{}
+	err := yaml.Unmarshal([]byte(test), &objectFilter)
+
+	assert.NotNil(t, err)
+}
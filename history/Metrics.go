@@ -0,0 +1,60 @@
+package history
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	openViolations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kube_conformity_open_violations",
+		Help: "Number of violations that are open after the last run.",
+	}, []string{"rule_name", "namespace"})
+	oldestOpenViolation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kube_conformity_oldest_open_violation_age_seconds",
+		Help: "Age of the oldest violation that is open after the last run.",
+	}, []string{"rule_name", "namespace"})
+	newViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kube_conformity_new_violations_total",
+		Help: "Number of violations found that were not found by the previous run.",
+	}, []string{"rule_name", "namespace"})
+	resolvedViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kube_conformity_resolved_violations_total",
+		Help: "Number of violations of the previous run that were no longer found.",
+	}, []string{"rule_name", "namespace"})
+	timeToFix = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kube_conformity_violation_time_to_fix_seconds",
+		Help:    "Time between the first run finding a violation and the run that no longer found it.",
+		Buckets: prometheus.ExponentialBuckets(3600, 2, 10),
+	}, []string{"rule_name"})
+)
+
+func init() {
+	prometheus.MustRegister(openViolations, oldestOpenViolation, newViolations, resolvedViolations, timeToFix)
+}
+
+func updateMetrics(diff Diff, now time.Time) {
+	openViolations.Reset()
+	oldestOpenViolation.Reset()
+	oldest := make(map[[2]string]time.Duration)
+	for _, open := range [][]Violation{diff.New, diff.Open} {
+		for _, violation := range open {
+			labels := [2]string{violation.Entry.RuleName, violation.Entry.Namespace}
+			openViolations.WithLabelValues(labels[0], labels[1]).Inc()
+			if age := violation.TimeToFix(now); age >= oldest[labels] {
+				oldest[labels] = age
+			}
+		}
+	}
+	for labels, age := range oldest {
+		oldestOpenViolation.WithLabelValues(labels[0], labels[1]).Set(age.Seconds())
+	}
+	for _, violation := range diff.New {
+		newViolations.WithLabelValues(violation.Entry.RuleName, violation.Entry.Namespace).Inc()
+	}
+	for _, violation := range diff.Resolved {
+		resolvedViolations.WithLabelValues(violation.Entry.RuleName, violation.Entry.Namespace).Inc()
+		timeToFix.WithLabelValues(violation.Entry.RuleName).Observe(violation.TimeToFix(now).Seconds())
+	}
+}
@@ -0,0 +1,194 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	bolt "go.etcd.io/bbolt"
+)
+
+const DefaultRetention = 30 * 24 * time.Hour
+
+var (
+	runsBucket       = []byte("runs")
+	violationsBucket = []byte("violations")
+)
+
+// Store keeps the report of every run and follows the violations across runs
+// in a bolt file. Runs and resolved violations older than the retention are
+// removed when a run is recorded.
+type Store struct {
+	db        *bolt.DB
+	Retention time.Duration
+}
+
+// Run is the report of a single run.
+type Run struct {
+	Time   time.Time      `json:"time"`
+	Report reports.Report `json:"report"`
+}
+
+func Open(path string, retention time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{runsBucket, violationsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, Retention: retention}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores the report of a run and returns the diff with the violations
+// that were open before it.
+func (s *Store) Record(report reports.Report, now time.Time) (Diff, error) {
+	diff := Diff{}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		violations := tx.Bucket(violationsBucket)
+		open, err := readViolations(violations)
+		if err != nil {
+			return err
+		}
+		diff = Compare(open, report.Violations, now)
+		for _, changed := range [][]Violation{diff.New, diff.Resolved, diff.Open} {
+			for _, violation := range changed {
+				if err := putJSON(violations, []byte(Key(violation.Entry)), violation); err != nil {
+					return err
+				}
+			}
+		}
+		if err := putJSON(tx.Bucket(runsBucket), timeKey(now), Run{Time: now, Report: report}); err != nil {
+			return err
+		}
+		return s.expire(tx, now)
+	})
+	if err != nil {
+		return Diff{}, err
+	}
+	updateMetrics(diff, now)
+	return diff, nil
+}
+
+// expire removes the runs and the resolved violations that are older than the
+// retention.
+func (s *Store) expire(tx *bolt.Tx, now time.Time) error {
+	if s.Retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-s.Retention)
+	runs := tx.Bucket(runsBucket)
+	var expired [][]byte
+	cursor := runs.Cursor()
+	for key, _ := cursor.First(); key != nil && bytes.Compare(key, timeKey(cutoff)) < 0; key, _ = cursor.Next() {
+		expired = append(expired, append([]byte{}, key...))
+	}
+	for _, key := range expired {
+		if err := runs.Delete(key); err != nil {
+			return err
+		}
+	}
+	violations := tx.Bucket(violationsBucket)
+	stored, err := readViolations(violations)
+	if err != nil {
+		return err
+	}
+	for key, violation := range stored {
+		if violation.Resolved() && violation.ResolvedAt.Before(cutoff) {
+			if err := violations.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Runs returns the runs recorded at or after since, the oldest first.
+func (s *Store) Runs(since time.Time) ([]Run, error) {
+	var runs []Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(runsBucket).Cursor()
+		for key, value := cursor.Seek(timeKey(since)); key != nil; key, value = cursor.Next() {
+			run := Run{}
+			if err := json.Unmarshal(value, &run); err != nil {
+				return err
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return runs, err
+}
+
+// Violations returns the open violations and the resolved violations that are
+// still within the retention.
+func (s *Store) Violations() ([]Violation, error) {
+	var violations []Violation
+	err := s.db.View(func(tx *bolt.Tx) error {
+		stored, err := readViolations(tx.Bucket(violationsBucket))
+		if err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(stored) {
+			violations = append(violations, stored[key])
+		}
+		return nil
+	})
+	return violations, err
+}
+
+func readViolations(bucket *bolt.Bucket) (map[string]Violation, error) {
+	violations := make(map[string]Violation)
+	err := bucket.ForEach(func(key, value []byte) error {
+		violation := Violation{}
+		if err := json.Unmarshal(value, &violation); err != nil {
+			return err
+		}
+		violations[string(key)] = violation
+		return nil
+	})
+	return violations, err
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// timeKey returns a key that sorts in the order of time, times before 1970
+// sort before every run.
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	if t.Unix() > 0 {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	return key
+}
+
+func sortedKeys(violations map[string]Violation) []string {
+	keys := make([]string, 0, len(violations))
+	for key := range violations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func openStore(t *testing.T, retention time.Duration) (*Store, string) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "history.db")
+	store, err := Open(path, retention)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return store, path
+}
+
+func newReport(entries ...reports.Entry) reports.Report {
+	return reports.Report{
+		Summary:    reports.Summary{Violations: len(entries)},
+		Violations: entries,
+	}
+}
+
+func TestStore_Record(t *testing.T) {
+	store, path := openStore(t, DefaultRetention)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer store.Close()
+	first := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	diff, err := store.Record(newReport(fooEntry, barEntry), first)
+	assert.Nil(t, err)
+	assert.Len(t, diff.New, 2)
+
+	diff, err = store.Record(newReport(fooEntry, apiEntry), second)
+	assert.Nil(t, err)
+	assert.Len(t, diff.New, 1)
+	assert.Equal(t, "api", diff.New[0].Entry.Name)
+	assert.Len(t, diff.Resolved, 1)
+	assert.Equal(t, "bar", diff.Resolved[0].Entry.Name)
+	assert.Equal(t, time.Hour, diff.Resolved[0].TimeToFix(second))
+	assert.Len(t, diff.Open, 1)
+	assert.Equal(t, first, diff.Open[0].FirstSeen)
+
+	runs, err := store.Runs(time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, first, runs[0].Time)
+	assert.Equal(t, 2, runs[0].Report.Summary.Violations)
+	runs, err = store.Runs(second)
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+}
+
+func TestStore_Reopen(t *testing.T) {
+	store, path := openStore(t, DefaultRetention)
+	defer os.RemoveAll(filepath.Dir(path))
+	first := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	_, err := store.Record(newReport(fooEntry), first)
+	assert.Nil(t, err)
+	store.Close()
+
+	store, err = Open(path, DefaultRetention)
+	assert.Nil(t, err)
+	defer store.Close()
+	diff, err := store.Record(newReport(fooEntry), first.Add(time.Hour))
+
+	assert.Nil(t, err)
+	assert.Len(t, diff.New, 0)
+	assert.Len(t, diff.Open, 1)
+	assert.Equal(t, first, diff.Open[0].FirstSeen)
+}
+
+func TestStore_Retention(t *testing.T) {
+	store, path := openStore(t, 24*time.Hour)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer store.Close()
+	first := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := store.Record(newReport(fooEntry, barEntry), first)
+	assert.Nil(t, err)
+	_, err = store.Record(newReport(fooEntry), first.Add(time.Hour))
+	assert.Nil(t, err)
+	_, err = store.Record(newReport(fooEntry), first.Add(48*time.Hour))
+	assert.Nil(t, err)
+
+	runs, err := store.Runs(time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+	violations, err := store.Violations()
+	assert.Nil(t, err)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "foo", violations[0].Entry.Name)
+	assert.Equal(t, first, violations[0].FirstSeen)
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+)
+
+// Violation follows an object violating a rule across runs, from the first run
+// that found it until the run that no longer did.
+type Violation struct {
+	Entry      reports.Entry `json:"entry"`
+	FirstSeen  time.Time     `json:"firstSeen"`
+	LastSeen   time.Time     `json:"lastSeen"`
+	ResolvedAt *time.Time    `json:"resolvedAt,omitempty"`
+}
+
+// Diff holds the violations found for the first time by a run, the violations
+// of the previous run the run no longer found and the violations found by both.
+type Diff struct {
+	New      []Violation
+	Resolved []Violation
+	Open     []Violation
+}
+
+// Key identifies the violation of a rule by an object across runs. The reason
+// is left out, a violation whose reason changes is still the same violation.
+func Key(entry reports.Entry) string {
+	return strings.Join([]string{entry.RuleName, entry.Kind, entry.Namespace, entry.Name}, "\x00")
+}
+
+// Resolved reports whether a later run no longer found the violation.
+func (v Violation) Resolved() bool {
+	return v.ResolvedAt != nil
+}
+
+// TimeToFix returns how long the violation was open, up to now when it is not
+// resolved yet.
+func (v Violation) TimeToFix(now time.Time) time.Duration {
+	if v.ResolvedAt != nil {
+		return v.ResolvedAt.Sub(v.FirstSeen)
+	}
+	return now.Sub(v.FirstSeen)
+}
+
+// Compare returns the diff between the violations that were open before a run
+// and the violations the run found. The first seen time of violations that
+// are still open is kept.
+func Compare(open map[string]Violation, current []reports.Entry, now time.Time) Diff {
+	diff := Diff{}
+	seen := make(map[string]bool)
+	for _, entry := range current {
+		key := Key(entry)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if violation, exists := open[key]; exists && !violation.Resolved() {
+			violation.Entry = entry
+			violation.LastSeen = now
+			diff.Open = append(diff.Open, violation)
+			continue
+		}
+		diff.New = append(diff.New, Violation{Entry: entry, FirstSeen: now, LastSeen: now})
+	}
+	for _, key := range sortedKeys(open) {
+		violation := open[key]
+		if seen[key] || violation.Resolved() {
+			continue
+		}
+		resolvedAt := now
+		violation.ResolvedAt = &resolvedAt
+		diff.Resolved = append(diff.Resolved, violation)
+	}
+	return diff
+}
@@ -0,0 +1,70 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	fooEntry = reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"}
+	barEntry = reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "bar", Reason: "Labels: [app] are not filled in"}
+	apiEntry = reports.Entry{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "testing", Name: "api", Reason: "Deployment replicas below the minimum: 2"}
+)
+
+func TestCompare(t *testing.T) {
+	firstSeen := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	resolvedAt := time.Date(2019, 6, 2, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	open := map[string]Violation{
+		Key(fooEntry): {Entry: fooEntry, FirstSeen: firstSeen, LastSeen: firstSeen},
+		Key(barEntry): {Entry: barEntry, FirstSeen: firstSeen, LastSeen: firstSeen},
+		Key(apiEntry): {Entry: apiEntry, FirstSeen: firstSeen, LastSeen: firstSeen, ResolvedAt: &resolvedAt},
+	}
+
+	diff := Compare(open, []reports.Entry{fooEntry, apiEntry}, now)
+
+	assert.Equal(t, []Violation{{Entry: fooEntry, FirstSeen: firstSeen, LastSeen: now}}, diff.Open)
+	assert.Equal(t, []Violation{{Entry: apiEntry, FirstSeen: now, LastSeen: now}}, diff.New)
+	assert.Len(t, diff.Resolved, 1)
+	assert.Equal(t, "bar", diff.Resolved[0].Entry.Name)
+	assert.Equal(t, now, *diff.Resolved[0].ResolvedAt)
+	assert.Equal(t, 14*24*time.Hour, diff.Resolved[0].TimeToFix(now))
+}
+
+func TestCompare_Duplicates(t *testing.T) {
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	diff := Compare(nil, []reports.Entry{fooEntry, fooEntry}, now)
+
+	assert.Len(t, diff.New, 1)
+}
+
+func TestCompare_ReasonChanged(t *testing.T) {
+	firstSeen := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	changed := fooEntry
+	changed.Reason = "Labels: [app team] are not filled in"
+	open := map[string]Violation{Key(fooEntry): {Entry: fooEntry, FirstSeen: firstSeen, LastSeen: firstSeen}}
+
+	diff := Compare(open, []reports.Entry{changed}, now)
+
+	assert.Equal(t, []Violation{{Entry: changed, FirstSeen: firstSeen, LastSeen: now}}, diff.Open)
+	assert.Empty(t, diff.New)
+	assert.Empty(t, diff.Resolved)
+}
+
+func TestViolation_TimeToFix_Open(t *testing.T) {
+	violation := Violation{Entry: fooEntry, FirstSeen: time.Date(2019, 6, 15, 10, 0, 0, 0, time.UTC)}
+
+	assert.False(t, violation.Resolved())
+	assert.Equal(t, 2*time.Hour, violation.TimeToFix(time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestKey(t *testing.T) {
+	assert.NotEqual(t, Key(fooEntry), Key(barEntry))
+	assert.Equal(t, Key(fooEntry), Key(reports.Entry{RuleName: "app label", Severity: "low", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"}))
+	assert.Equal(t, Key(fooEntry), Key(reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [team] are not filled in"}))
+}
@@ -0,0 +1,260 @@
+package kubeconformity
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stijndehaes/kube-conformity/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	ConditionReady         = "Ready"
+	ReasonValid            = "Valid"
+	ReasonInvalidSpec      = "InvalidSpec"
+	ReasonEvaluationFailed = "EvaluationFailed"
+	conformityRuleResync   = 10 * time.Minute
+)
+
+var (
+	conformityRulesResource        = schema.GroupVersionResource{Group: "kube-conformity.io", Version: "v1alpha1", Resource: "conformityrules"}
+	clusterConformityRulesResource = schema.GroupVersionResource{Group: "kube-conformity.io", Version: "v1alpha1", Resource: "clusterconformityrules"}
+)
+
+// ConformityRules watches the ConformityRule and ClusterConformityRule objects
+// and keeps the rules of the valid ones. Every object gets a Ready condition
+// in its status, false with the error when its spec does not parse or its
+// rules fail to evaluate.
+type ConformityRules struct {
+	client           dynamic.Interface
+	discovery        discovery.DiscoveryInterface
+	logger           log.FieldLogger
+	now              func() time.Time
+	mutex            sync.Mutex
+	ruleSets         map[string]config.RuleSet
+	objects          map[string]*unstructured.Unstructured
+	evaluationErrors map[string]evaluationError
+}
+
+// evaluationError is the error of evaluating the rules of an object, at the
+// generation of the object the rules came from.
+type evaluationError struct {
+	generation int64
+	err        error
+}
+
+func NewConformityRules(client dynamic.Interface, discovery discovery.DiscoveryInterface, logger log.FieldLogger) *ConformityRules {
+	return &ConformityRules{
+		client:           client,
+		discovery:        discovery,
+		logger:           logger,
+		now:              time.Now,
+		ruleSets:         make(map[string]config.RuleSet),
+		objects:          make(map[string]*unstructured.Unstructured),
+		evaluationErrors: make(map[string]evaluationError),
+	}
+}
+
+// Start watches the objects until the stop channel is closed, it returns once
+// the objects that exist are loaded. It fails right away when the CRDs are not
+// installed.
+func (c *ConformityRules) Start(stop <-chan struct{}) error {
+	if err := c.checkResources(); err != nil {
+		return err
+	}
+	var synced []cache.InformerSynced
+	for _, gvr := range []schema.GroupVersionResource{conformityRulesResource, clusterConformityRulesResource} {
+		resource := c.client.Resource(gvr)
+		listWatch := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resource.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resource.Watch(options)
+			},
+		}
+		_, controller := cache.NewInformer(listWatch, &unstructured.Unstructured{}, conformityRuleResync, cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.update,
+			UpdateFunc: func(_, object interface{}) { c.update(object) },
+			DeleteFunc: c.delete,
+		})
+		go controller.Run(stop)
+		synced = append(synced, controller.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stop, synced...) {
+		return fmt.Errorf("stopped before the conformity rules were loaded")
+	}
+	return nil
+}
+
+// checkResources checks that the API server serves the conformity rule
+// resources, without them the informers would wait forever.
+func (c *ConformityRules) checkResources() error {
+	groupVersion := conformityRulesResource.GroupVersion().String()
+	resourceList, err := c.discovery.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return fmt.Errorf("looking up the conformity rule resources in %s, are the CRDs installed: %v", groupVersion, err)
+	}
+	var resources []metav1.APIResource
+	if resourceList != nil {
+		resources = resourceList.APIResources
+	}
+	for _, gvr := range []schema.GroupVersionResource{conformityRulesResource, clusterConformityRulesResource} {
+		found := false
+		for _, resource := range resources {
+			found = found || resource.Name == gvr.Resource
+		}
+		if !found {
+			return fmt.Errorf("resource %s not found in %s, install the CRDs from examples/ConformityRuleCRD.yaml", gvr.Resource, groupVersion)
+		}
+	}
+	return nil
+}
+
+// SetEvaluationError records the error of evaluating the rules of the object
+// with the key in its Ready condition, nil once they evaluate again.
+func (c *ConformityRules) SetEvaluationError(key string, err error) {
+	c.mutex.Lock()
+	object, ok := c.objects[key]
+	ruleSet := c.ruleSets[key]
+	if err != nil && ok {
+		c.evaluationErrors[key] = evaluationError{generation: object.GetGeneration(), err: err}
+	} else {
+		delete(c.evaluationErrors, key)
+	}
+	c.mutex.Unlock()
+	if !ok {
+		return
+	}
+	logger := c.logger.WithField("conformity_rule", key)
+	if err != nil {
+		logger.Warnf("Evaluating the conformity rule: %v", err)
+	}
+	if err := c.writeStatus(object, ruleSet, ReasonEvaluationFailed, err); err != nil {
+		logger.Errorf("Writing the status of the conformity rule: %v", err)
+	}
+}
+
+// RuleSets returns the rules of the valid objects, sorted on namespace and
+// name.
+func (c *ConformityRules) RuleSets() []config.RuleSet {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var keys []string
+	for key := range c.ruleSets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var ruleSets []config.RuleSet
+	for _, key := range keys {
+		ruleSets = append(ruleSets, c.ruleSets[key])
+	}
+	return ruleSets
+}
+
+func (c *ConformityRules) update(obj interface{}) {
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	key := conformityRuleKey(object)
+	spec, _, _ := unstructured.NestedMap(object.Object, "spec")
+	ruleSet, err := config.ParseRuleSet(object.GetNamespace(), spec)
+	ruleSet.Name = object.GetName()
+	reason, statusErr := ReasonInvalidSpec, err
+	c.mutex.Lock()
+	c.objects[key] = object
+	if err != nil {
+		delete(c.ruleSets, key)
+	} else {
+		c.ruleSets[key] = ruleSet
+	}
+	if evaluation, ok := c.evaluationErrors[key]; err == nil && ok && evaluation.generation == object.GetGeneration() {
+		reason, statusErr = ReasonEvaluationFailed, evaluation.err
+	}
+	c.mutex.Unlock()
+	logger := c.logger.WithField("conformity_rule", key)
+	if err != nil {
+		logger.Warnf("Invalid conformity rule: %v", err)
+	}
+	if err := c.writeStatus(object, ruleSet, reason, statusErr); err != nil {
+		logger.Errorf("Writing the status of the conformity rule: %v", err)
+	}
+}
+
+func (c *ConformityRules) delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	key := conformityRuleKey(object)
+	c.mutex.Lock()
+	delete(c.ruleSets, key)
+	delete(c.objects, key)
+	delete(c.evaluationErrors, key)
+	c.mutex.Unlock()
+}
+
+// writeStatus sets the Ready condition of the object, false with the reason
+// when there is an error. The status is left alone when the condition did not
+// change so the update it causes does not lead to another one.
+func (c *ConformityRules) writeStatus(object *unstructured.Unstructured, ruleSet config.RuleSet, reason string, err error) error {
+	condition := map[string]interface{}{
+		"type":               ConditionReady,
+		"status":             "True",
+		"reason":             ReasonValid,
+		"message":            fmt.Sprintf("%d rules loaded", ruleSet.Rules()),
+		"observedGeneration": object.GetGeneration(),
+	}
+	if err != nil {
+		condition["status"] = "False"
+		condition["reason"] = reason
+		condition["message"] = err.Error()
+	}
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, existing := range conditions {
+		existing, ok := existing.(map[string]interface{})
+		if !ok || existing["type"] != ConditionReady {
+			continue
+		}
+		if existing["status"] == condition["status"] {
+			if existing["reason"] == condition["reason"] && existing["message"] == condition["message"] && existing["observedGeneration"] == condition["observedGeneration"] {
+				return nil
+			}
+			condition["lastTransitionTime"] = existing["lastTransitionTime"]
+		}
+	}
+	if condition["lastTransitionTime"] == nil {
+		condition["lastTransitionTime"] = c.now().UTC().Format(time.RFC3339)
+	}
+	updated := object.DeepCopy()
+	if err := unstructured.SetNestedSlice(updated.Object, []interface{}{condition}, "status", "conditions"); err != nil {
+		return err
+	}
+	gvr := conformityRulesResource
+	if object.GetNamespace() == "" {
+		gvr = clusterConformityRulesResource
+	}
+	_, err = namespacedResource(c.client.Resource(gvr), object.GetNamespace()).UpdateStatus(updated, metav1.UpdateOptions{})
+	return err
+}
+
+func conformityRuleKey(object *unstructured.Unstructured) string {
+	if object.GetNamespace() == "" {
+		return object.GetName()
+	}
+	return object.GetNamespace() + "/" + object.GetName()
+}
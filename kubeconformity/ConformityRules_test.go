@@ -0,0 +1,282 @@
+package kubeconformity
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newConformityRule(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	kind := "ConformityRule"
+	if namespace == "" {
+		kind = "ClusterConformityRule"
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kube-conformity.io/v1alpha1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"namespace":  namespace,
+			"name":       name,
+			"generation": int64(1),
+		},
+		"spec": spec,
+	}}
+}
+
+func labelRuleSpec(name, label string) map[string]interface{} {
+	return map[string]interface{}{
+		"pod_rules_labels_filled_in": []interface{}{
+			map[string]interface{}{"name": name, "labels": []interface{}{label}, "severity": "high"},
+		},
+	}
+}
+
+func newConformityRulesClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	scheme := runtime.NewScheme()
+	for _, kind := range []string{"ConformityRuleList", "ClusterConformityRuleList"} {
+		scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "kube-conformity.io", Version: "v1alpha1", Kind: kind}, &unstructured.UnstructuredList{})
+	}
+	return dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+}
+
+func newConformityRulesDiscovery() *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "kube-conformity.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "conformityrules", Kind: "ConformityRule", Namespaced: true},
+			{Name: "clusterconformityrules", Kind: "ClusterConformityRule"},
+		},
+	}}}}
+}
+
+// startConformityRules starts watching and waits until the rule sets are
+// loaded, closing the returned channel stops watching.
+func startConformityRules(t *testing.T, conformityRules *ConformityRules, ruleSets int) chan struct{} {
+	stop := make(chan struct{})
+	if err := conformityRules.Start(stop); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); len(conformityRules.RuleSets()) < ruleSets && time.Since(start) < 5*time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return stop
+}
+
+func readyCondition(t *testing.T, conformityRules *ConformityRules, gvr schema.GroupVersionResource, namespace, name string) map[string]interface{} {
+	var conditions []interface{}
+	for start := time.Now(); len(conditions) == 0 && time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		object, err := namespacedResource(conformityRules.client.Resource(gvr), namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		conditions, _, _ = unstructured.NestedSlice(object.Object, "status", "conditions")
+	}
+	if len(conditions) != 1 {
+		t.Fatalf("expected a single condition, got %v", conditions)
+	}
+	return conditions[0].(map[string]interface{})
+}
+
+func TestConformityRules_Start(t *testing.T) {
+	client := newConformityRulesClient(
+		newConformityRule("payments", "team-label", labelRuleSpec("team label", "team")),
+		newConformityRule("payments", "typo", map[string]interface{}{"pod_rules_labels_fill_in": []interface{}{}}),
+		newConformityRule("", "app-label", labelRuleSpec("app label", "app")),
+	)
+	conformityRules := NewConformityRules(client, newConformityRulesDiscovery(), logger)
+	conformityRules.now = func() time.Time { return time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	stop := startConformityRules(t, conformityRules, 2)
+	defer close(stop)
+
+	ruleSets := conformityRules.RuleSets()
+	if assert.Len(t, ruleSets, 2) {
+		assert.Equal(t, "", ruleSets[0].Namespace)
+		assert.Equal(t, "app label", ruleSets[0].PodRulesLabelsFilledIn[0].Name)
+		assert.Equal(t, "payments", ruleSets[1].Namespace)
+		assert.Equal(t, "team label", ruleSets[1].PodRulesLabelsFilledIn[0].Name)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"type":               "Ready",
+		"status":             "True",
+		"reason":             "Valid",
+		"message":            "1 rules loaded",
+		"observedGeneration": int64(1),
+		"lastTransitionTime": "2019-06-15T12:00:00Z",
+	}, readyCondition(t, conformityRules, conformityRulesResource, "payments", "team-label"))
+	invalid := readyCondition(t, conformityRules, conformityRulesResource, "payments", "typo")
+	assert.Equal(t, "False", invalid["status"])
+	assert.Equal(t, "InvalidSpec", invalid["reason"])
+	assert.Contains(t, invalid["message"], "field pod_rules_labels_fill_in not found")
+	assert.Equal(t, "True", readyCondition(t, conformityRules, clusterConformityRulesResource, "", "app-label")["status"])
+}
+
+func TestConformityRules_Start_MissingCRDs(t *testing.T) {
+	conformityRules := NewConformityRules(newConformityRulesClient(), &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}, logger)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	err := conformityRules.Start(stop)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "kube-conformity.io/v1alpha1")
+	}
+}
+
+func TestConformityRules_UpdateAndDelete(t *testing.T) {
+	client := newConformityRulesClient()
+	conformityRules := NewConformityRules(client, newConformityRulesDiscovery(), logger)
+	object := newConformityRule("payments", "team-label", labelRuleSpec("team label", "team"))
+	if _, err := client.Resource(conformityRulesResource).Namespace("payments").Create(object, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	conformityRules.update(object)
+	updated, err := client.Resource(conformityRulesResource).Namespace("payments").Get("team-label", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.ClearActions()
+	conformityRules.update(updated)
+
+	assert.Len(t, conformityRules.RuleSets(), 1)
+	assert.Empty(t, client.Actions(), "the status should not be written again when it did not change")
+
+	invalid := updated.DeepCopy()
+	unstructured.SetNestedMap(invalid.Object, map[string]interface{}{}, "spec")
+	conformityRules.update(invalid)
+
+	assert.Empty(t, conformityRules.RuleSets())
+	assert.Equal(t, "missing rules in spec", readyCondition(t, conformityRules, conformityRulesResource, "payments", "team-label")["message"])
+
+	conformityRules.update(updated)
+	conformityRules.delete(updated)
+
+	assert.Empty(t, conformityRules.RuleSets())
+}
+
+func TestKubeConformity_Run_ConformityRules(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "app label", Labels: []string{"app"}}},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{"app"}),
+		newPodWithLabels("payments", "api", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	kubeConformity.ConformityRules = NewConformityRules(newConformityRulesClient(
+		newConformityRule("payments", "team-label", labelRuleSpec("team label", "team")),
+	), newConformityRulesDiscovery(), logger)
+	stop := startConformityRules(t, kubeConformity.ConformityRules, 1)
+	defer close(stop)
+
+	results, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	report := kubeConformity.Report(results)
+	assert.Equal(t, 2, report.Summary.Rules)
+	if assert.Len(t, report.Violations, 1) {
+		assert.Equal(t, "payments/team label", report.Violations[0].RuleName)
+		assert.Equal(t, "api", report.Violations[0].Name)
+	}
+	assert.Len(t, kubeConformity.KubeConformityConfig.PodRulesLabelsFilledIn, 1, "the config file rules should be restored after the run")
+}
+
+func TestKubeConformity_Run_ConformityRuleEvaluationError(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "app label", Labels: []string{"app"}}},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{"app"}),
+		newPodWithLabels("payments", "api", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	policies := v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "policies"}}
+	if _, err := kubeConformity.Client.CoreV1().ConfigMaps("kube-system").Create(&policies); err != nil {
+		t.Fatal(err)
+	}
+	kubeConformity.ConformityRules = NewConformityRules(newConformityRulesClient(
+		newConformityRule("payments", "team-label", labelRuleSpec("team label", "team")),
+		newConformityRule("payments", "policies", map[string]interface{}{
+			"rego_rules": []interface{}{map[string]interface{}{
+				"name":       "policies",
+				"kind":       "Pod",
+				"config_map": map[string]interface{}{"namespace": "kube-system", "name": "policies"},
+			}},
+		}),
+	), newConformityRulesDiscovery(), logger)
+	stop := startConformityRules(t, kubeConformity.ConformityRules, 2)
+	defer close(stop)
+
+	results, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	report := kubeConformity.Report(results)
+	assert.Equal(t, 2, report.Summary.Rules)
+	if assert.Len(t, report.Violations, 1) {
+		assert.Equal(t, "payments/team label", report.Violations[0].RuleName)
+	}
+	condition := readyCondition(t, kubeConformity.ConformityRules, conformityRulesResource, "payments", "policies")
+	assert.Equal(t, "False", condition["status"])
+	assert.Equal(t, "EvaluationFailed", condition["reason"])
+	assert.Contains(t, condition["message"], "not found", "the ConfigMap should be looked up in the namespace of the conformity rule")
+	assert.Equal(t, "True", readyCondition(t, kubeConformity.ConformityRules, conformityRulesResource, "payments", "team-label")["status"])
+	kubeConformity.ConformityRules.SetEvaluationError("payments/policies", nil)
+
+	assert.Equal(t, "True", readyCondition(t, kubeConformity.ConformityRules, conformityRulesResource, "payments", "policies")["status"])
+}
+
+func TestKubeConformity_Run_ConformityRulesEvaluatedOnce(t *testing.T) {
+	module, err := ioutil.ReadFile("../rules/testdata/rego/required_labels.rego")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pods := []v1.Pod{newPodWithLabels("payments", "api", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, config.Config{})
+	policies := v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "policies"},
+		Data:       map[string]string{"required_labels.rego": string(module)},
+	}
+	if _, err = kubeConformity.Client.CoreV1().ConfigMaps("payments").Create(&policies); err != nil {
+		t.Fatal(err)
+	}
+	kubeConformity.ConformityRules = NewConformityRules(newConformityRulesClient(
+		newConformityRule("payments", "policies", map[string]interface{}{
+			"rego_rules": []interface{}{map[string]interface{}{
+				"name":       "required labels",
+				"kind":       "Pod",
+				"config_map": map[string]interface{}{"namespace": "payments", "name": "policies"},
+				"parameters": map[string]interface{}{"labels": []interface{}{"app"}},
+			}},
+		}),
+	), newConformityRulesDiscovery(), logger)
+	stop := startConformityRules(t, kubeConformity.ConformityRules, 1)
+	defer close(stop)
+	client := kubeConformity.Client.(*fake.Clientset)
+	client.ClearActions()
+
+	results, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Len(t, kubeConformity.Report(results).Violations, 1)
+	gets := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" && action.GetResource().Resource == "configmaps" {
+			gets++
+		}
+	}
+	assert.Equal(t, 1, gets, "the rules should be evaluated once per run")
+}
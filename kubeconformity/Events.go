@@ -0,0 +1,204 @@
+package kubeconformity
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stijndehaes/kube-conformity/config"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	EventReasonNonConforming = "NonConforming"
+	eventComponent           = "kube-conformity"
+	eventFlushTimeout        = 5 * time.Second
+)
+
+// apiVersions are the api versions of the kinds of the typed rules, the
+// objects the client lists have no type meta.
+var apiVersions = map[string]string{
+	"Pod":         "v1",
+	"Deployment":  "apps/v1",
+	"StatefulSet": "apps/v1",
+}
+
+// EventRecorder records a Warning event on every violating object. The event
+// of an object and rule is recorded again once the interval has passed, and
+// events over the rate limit are dropped until a later run.
+type EventRecorder struct {
+	Recorder record.EventRecorder
+	Interval time.Duration
+	limiter  flowcontrol.RateLimiter
+	recorded map[string]time.Time
+	writer   *eventWriter
+	watcher  watch.Interface
+}
+
+// NewEventRecorder returns a recorder that creates the events through the
+// client.
+func NewEventRecorder(client kubernetes.Interface, eventConfig config.EventConfig, logger log.FieldLogger) *EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	writer := &eventWriter{
+		sink:       &typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")},
+		correlator: record.NewEventCorrelator(clock.RealClock{}),
+		logger:     logger,
+	}
+	recorder := newEventRecorder(broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventComponent}), eventConfig)
+	recorder.writer = writer
+	recorder.watcher = broadcaster.StartEventWatcher(writer.write)
+	return recorder
+}
+
+func newEventRecorder(recorder record.EventRecorder, eventConfig config.EventConfig) *EventRecorder {
+	return &EventRecorder{
+		Recorder: recorder,
+		Interval: eventConfig.Interval,
+		limiter:  flowcontrol.NewTokenBucketRateLimiter(eventConfig.QPS, eventConfig.Burst),
+		recorded: make(map[string]time.Time),
+	}
+}
+
+// Record records the events of the violations and returns how many were
+// recorded and how many were dropped by the rate limit. Objects that no
+// longer violate a rule are forgotten, so they get an event as soon as they
+// violate it again.
+func (e *EventRecorder) Record(violations []violation, now time.Time) (int, int) {
+	recorded, dropped := 0, 0
+	current := make(map[string]bool)
+	for _, violation := range violations {
+		key := eventKey(violation)
+		current[key] = true
+		if last, ok := e.recorded[key]; ok && now.Sub(last) < e.Interval {
+			continue
+		}
+		if !e.limiter.TryAccept() {
+			dropped++
+			continue
+		}
+		if e.writer != nil {
+			e.writer.pending.Add(1)
+		}
+		e.Recorder.Event(objectReference(violation), v1.EventTypeWarning, EventReasonNonConforming, eventMessage(violation))
+		e.recorded[key] = now
+		recorded++
+	}
+	for key := range e.recorded {
+		if !current[key] {
+			delete(e.recorded, key)
+		}
+	}
+	return recorded, dropped
+}
+
+// Shutdown waits up to the timeout for the recorded events to be written and
+// stops writing events, events are written in the background so they are lost
+// when the process exits before.
+func (e *EventRecorder) Shutdown(timeout time.Duration) {
+	if e.watcher == nil {
+		return
+	}
+	written := make(chan struct{})
+	go func() {
+		e.writer.pending.Wait()
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(timeout):
+		e.writer.logger.Warnf("Not all events were written within %s", timeout)
+	}
+	e.watcher.Stop()
+}
+
+// eventWriter writes the events of the broadcaster to the sink the way the
+// broadcaster does when recording to a sink, except that failed writes are
+// not retried. Every event is marked as done once it is handled, also when
+// the correlator drops it as spam, so a shutdown does not wait for events that
+// are never written.
+type eventWriter struct {
+	sink       record.EventSink
+	correlator *record.EventCorrelator
+	logger     log.FieldLogger
+	pending    sync.WaitGroup
+}
+
+func (w *eventWriter) write(event *v1.Event) {
+	defer w.pending.Done()
+	result, err := w.correlator.EventCorrelate(event)
+	if err != nil {
+		w.logger.Warnf("Correlating event %s: %v", event.Name, err)
+	}
+	if result == nil || result.Skip {
+		return
+	}
+	var written *v1.Event
+	if result.Event.Count > 1 {
+		written, err = w.sink.Patch(result.Event, result.Patch)
+	}
+	if result.Event.Count <= 1 || apierrors.IsNotFound(err) {
+		result.Event.ResourceVersion = ""
+		written, err = w.sink.Create(result.Event)
+	}
+	if err != nil {
+		w.logger.Warnf("Writing event %s: %v", event.Name, err)
+		return
+	}
+	w.correlator.UpdateState(written)
+}
+
+func eventKey(violation violation) string {
+	return fmt.Sprintf("%s/%s/%s/%s", violation.Kind, violation.Object.GetNamespace(), violation.Object.GetName(), violation.RuleName)
+}
+
+func eventMessage(violation violation) string {
+	return fmt.Sprintf("Violates rule %s (%s): %s", violation.RuleName, violation.Severity.OrDefault(), violation.Reason)
+}
+
+// objectReference returns the reference of the violating object, objects of
+// resource and rego rules carry their own api version.
+func objectReference(violation violation) *v1.ObjectReference {
+	apiVersion := apiVersions[violation.Kind]
+	if object, ok := violation.Object.(runtime.Object); ok {
+		if version := object.GetObjectKind().GroupVersionKind().GroupVersion().String(); version != "" {
+			apiVersion = version
+		}
+	}
+	return &v1.ObjectReference{
+		APIVersion:      apiVersion,
+		Kind:            violation.Kind,
+		Namespace:       violation.Object.GetNamespace(),
+		Name:            violation.Object.GetName(),
+		UID:             violation.Object.GetUID(),
+		ResourceVersion: violation.Object.GetResourceVersion(),
+	}
+}
+
+// Shutdown stops recording events, once the recorded events are written.
+func (k *KubeConformity) Shutdown() {
+	if k.Events != nil {
+		k.Events.Shutdown(eventFlushTimeout)
+	}
+}
+
+// recordEvents records the events of the violations when events are enabled.
+func (k *KubeConformity) recordEvents(results Results) {
+	if k.Events == nil {
+		return
+	}
+	recorded, dropped := k.Events.Record(results.violations(), k.now())
+	if dropped > 0 {
+		k.Logger.Warnf("Dropped %d events over the rate limit, they are recorded on a later run", dropped)
+	}
+	k.Logger.Debugf("Recorded %d events on non conforming objects", recorded)
+}
@@ -0,0 +1,177 @@
+package kubeconformity
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+var eventConfig = config.EventConfig{Enabled: true, Interval: time.Hour, QPS: 1, Burst: 2}
+
+func newViolation(rule string, pod v1.Pod) violation {
+	return violation{RuleName: rule, Severity: rules.SeverityHigh, Kind: "Pod", Reason: "Labels: [app] are not filled in", Object: &pod}
+}
+
+// recordedEvents drains the events of the fake recorder.
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestKubeConformity_Run_Events(t *testing.T) {
+	kubeConfig := config.Config{
+		Events: eventConfig,
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "app label", Labels: []string{"app"}, Severity: rules.SeverityHigh},
+		},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("default", "bar", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	var events []v1.Event
+	for start := time.Now(); len(events) == 0 && time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		list, err := kubeConformity.Client.CoreV1().Events("default").List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = list.Items
+	}
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, v1.EventTypeWarning, events[0].Type)
+		assert.Equal(t, EventReasonNonConforming, events[0].Reason)
+		assert.Equal(t, "Violates rule app label (high): Labels: [app] are not filled in", events[0].Message)
+		assert.Equal(t, v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "foo", UID: "uid1"}, events[0].InvolvedObject)
+		assert.Equal(t, "kube-conformity", events[0].Source.Component)
+	}
+}
+
+func TestKubeConformity_New_EventsDisabled(t *testing.T) {
+	kubeConformity := setup(t, nil, nil, nil, config.Config{})
+
+	assert.Nil(t, kubeConformity.Events)
+}
+
+func TestEventRecorder_Record_Deduplicated(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	eventRecorder := newEventRecorder(recorder, eventConfig)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	violations := []violation{newViolation("app label", newPodWithLabels("default", "foo", "uid1", []string{}))}
+
+	recorded, dropped := eventRecorder.Record(violations, now)
+	assert.Equal(t, 1, recorded)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, []string{"Warning NonConforming Violates rule app label (high): Labels: [app] are not filled in"}, recordedEvents(recorder))
+
+	recorded, _ = eventRecorder.Record(violations, now.Add(30*time.Minute))
+	assert.Equal(t, 0, recorded)
+	assert.Empty(t, recordedEvents(recorder))
+
+	recorded, _ = eventRecorder.Record(violations, now.Add(time.Hour))
+	assert.Equal(t, 1, recorded)
+	assert.Len(t, recordedEvents(recorder), 1)
+}
+
+func TestEventRecorder_Record_PerRule(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	eventRecorder := newEventRecorder(recorder, eventConfig)
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+
+	recorded, _ := eventRecorder.Record([]violation{newViolation("app label", pod), newViolation("team label", pod)}, time.Now())
+
+	assert.Equal(t, 2, recorded)
+	assert.Len(t, recordedEvents(recorder), 2)
+}
+
+func TestEventRecorder_Record_ResolvedForgotten(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	eventRecorder := newEventRecorder(recorder, eventConfig)
+	now := time.Now()
+	violations := []violation{newViolation("app label", newPodWithLabels("default", "foo", "uid1", []string{}))}
+
+	eventRecorder.Record(violations, now)
+	eventRecorder.Record(nil, now.Add(time.Minute))
+	recorded, _ := eventRecorder.Record(violations, now.Add(2*time.Minute))
+
+	assert.Equal(t, 1, recorded)
+	assert.Len(t, recordedEvents(recorder), 2)
+}
+
+func TestEventRecorder_Record_RateLimited(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	eventRecorder := newEventRecorder(recorder, eventConfig)
+	now := time.Now()
+	violations := []violation{
+		newViolation("app label", newPodWithLabels("default", "foo", "uid1", []string{})),
+		newViolation("app label", newPodWithLabels("default", "bar", "uid2", []string{})),
+		newViolation("app label", newPodWithLabels("default", "baz", "uid3", []string{})),
+	}
+
+	recorded, dropped := eventRecorder.Record(violations, now)
+
+	assert.Equal(t, 2, recorded)
+	assert.Equal(t, 1, dropped)
+	assert.Len(t, recordedEvents(recorder), 2)
+}
+
+func TestObjectReference(t *testing.T) {
+	statefulSet := newStatefulSet("default", "database", "uid1", 1)
+	certificate := newCertificate("default", "tls", nil)
+
+	assert.Equal(t, "apps/v1", objectReference(violation{Kind: "StatefulSet", Object: &statefulSet}).APIVersion)
+	assert.Equal(t, &v1.ObjectReference{APIVersion: "cert-manager.io/v1", Kind: "Certificate", Namespace: "default", Name: "tls"}, objectReference(violation{Kind: "Certificate", Object: certificate}))
+	assert.Equal(t, "apps/v1", objectReference(violation{Kind: "Deployment", Object: &appsv1.Deployment{}}).APIVersion)
+	assert.Equal(t, "", objectReference(violation{Kind: "Unknown", Object: &unstructured.Unstructured{}}).APIVersion)
+}
+
+func TestEventRecorder_Shutdown(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := NewEventRecorder(client, eventConfig, logger)
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	recorder.Record([]violation{newViolation("app label", pod)}, time.Now())
+
+	recorder.Shutdown(eventFlushTimeout)
+
+	events, err := client.CoreV1().Events("default").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, events.Items, 1, "the recorded event should be written before shutting down")
+}
+
+func TestEventRecorder_Shutdown_SpamFiltered(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := NewEventRecorder(client, config.EventConfig{Enabled: true, Interval: time.Hour, QPS: 100, Burst: 100}, logger)
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	var violations []violation
+	for idx := 0; idx < 30; idx++ {
+		violations = append(violations, newViolation(fmt.Sprintf("rule %d", idx), pod))
+	}
+	recorded, _ := recorder.Record(violations, time.Now())
+	assert.Equal(t, 30, recorded)
+
+	start := time.Now()
+	recorder.Shutdown(eventFlushTimeout)
+
+	assert.True(t, time.Since(start) < eventFlushTimeout/2, "events dropped by the spam filter should not be waited for")
+}
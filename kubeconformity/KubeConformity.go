@@ -4,114 +4,765 @@ import (
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/filters"
+	"github.com/stijndehaes/kube-conformity/notify"
+	"github.com/stijndehaes/kube-conformity/reports"
 	"github.com/stijndehaes/kube-conformity/rules"
 )
 
 type KubeConformity struct {
 	Client               kubernetes.Interface
-	Logger               log.StdLogger
+	DynamicClient        dynamic.Interface
+	Logger               log.FieldLogger
 	KubeConformityConfig config.Config
+	Exceptions           rules.Exceptions
+	Events               *EventRecorder
+	ConformityRules      *ConformityRules
+	now                  func() time.Time
+	lists                listCache
+	ruleSets             *ruleSetRun
 }
 
-func New(client kubernetes.Interface, logger log.StdLogger, config config.Config) *KubeConformity {
-	return &KubeConformity{
+// ruleSetRun keeps the conformity rule object every rule added for a run came
+// from, the first error of evaluating the rules of every object and the rules
+// that were left out because of their error.
+type ruleSetRun struct {
+	keys    map[string]string
+	errors  map[string]error
+	skipped map[string]bool
+}
+
+func New(client kubernetes.Interface, dynamicClient dynamic.Interface, logger log.FieldLogger, config config.Config) *KubeConformity {
+	kubeConformity := &KubeConformity{
 		Client:               client,
+		DynamicClient:        dynamicClient,
 		Logger:               logger,
 		KubeConformityConfig: config,
+		now:                  time.Now,
+	}
+	if config.Events.Enabled {
+		kubeConformity.Events = NewEventRecorder(client, config.Events, logger)
+	}
+	return kubeConformity
+}
+
+// SetConfig replaces the config used by the next run, the event recorder is
+// only recreated when the events config changed.
+func (k *KubeConformity) SetConfig(kubeConfig config.Config) {
+	if kubeConfig.Events != k.KubeConformityConfig.Events {
+		k.Shutdown()
+		k.Events = nil
+		if kubeConfig.Events.Enabled {
+			k.Events = NewEventRecorder(k.Client, kubeConfig.Events, k.Logger)
+		}
 	}
+	k.KubeConformityConfig = kubeConfig
 }
 
 
 
-func (k *KubeConformity) LogNonConforming() error {
-	podRuleResults := k.EvaluatePodRules()
-	deploymentRuleResults := k.EvaluateDeploymentRules()
-	statefulSetRuleResults := k.EvaluateStatefulSetRules()
-	if len(podRuleResults) > 0 {
-		k.Logger.Println(fmt.Sprint("Presenting Pod rule results"))
+func (k *KubeConformity) Evaluate() (Results, error) {
+	if k.lists == nil {
+		k.lists = listCache{}
+		defer func() { k.lists = nil }()
+	}
+	results := Results{Rules: k.KubeConformityConfig.RuleInfos()}
+	var err error
+	results.RegoRuleResults, err = k.EvaluateRegoRules()
+	if err != nil {
+		return results, err
+	}
+	results.ResourceRuleResults, err = k.EvaluateResourceRules()
+	if err != nil {
+		return results, err
 	}
+	results.FieldRuleResults, err = k.EvaluateFieldRules()
+	if err != nil {
+		return results, err
+	}
+	podRuleResults, err := k.EvaluatePodRules()
+	if err != nil {
+		return results, err
+	}
+	deploymentRuleResults, err := k.EvaluateDeploymentRules()
+	if err != nil {
+		return results, err
+	}
+	statefulSetRuleResults, err := k.EvaluateStatefulSetRules()
+	if err != nil {
+		return results, err
+	}
+	now := k.now()
 	for _, ruleResult := range podRuleResults {
-		k.Logger.Println(fmt.Sprintf("rule name: %s", ruleResult.RuleName))
-		k.Logger.Println(fmt.Sprintf("rule reason: %s", ruleResult.Reason))
-		for _, pod := range ruleResult.Pods {
-			k.Logger.Println(fmt.Sprintf("%s_%s", pod.Name, pod.Namespace))
+		ruleResult, exempted := ruleResult.WithoutExempted(now, k.Exceptions.Exceptions)
+		results.PodRuleResults = append(results.PodRuleResults, ruleResult)
+		results.ExemptedResults = append(results.ExemptedResults, exempted...)
+	}
+	for _, ruleResult := range deploymentRuleResults {
+		ruleResult, exempted := ruleResult.WithoutExempted(now, k.Exceptions.Exceptions)
+		results.DeploymentRuleResults = append(results.DeploymentRuleResults, ruleResult)
+		results.ExemptedResults = append(results.ExemptedResults, exempted...)
+	}
+	for _, ruleResult := range statefulSetRuleResults {
+		ruleResult, exempted := ruleResult.WithoutExempted(now, k.Exceptions.Exceptions)
+		results.StatefulSetRuleResults = append(results.StatefulSetRuleResults, ruleResult)
+		results.ExemptedResults = append(results.ExemptedResults, exempted...)
+	}
+	for _, objectResults := range []*[]rules.ObjectRuleResult{&results.RegoRuleResults, &results.ResourceRuleResults, &results.FieldRuleResults} {
+		var kept []rules.ObjectRuleResult
+		for _, ruleResult := range *objectResults {
+			ruleResult, exempted := ruleResult.WithoutExempted(now, k.Exceptions.Exceptions)
+			if len(ruleResult.Objects) > 0 {
+				kept = append(kept, ruleResult)
+			}
+			results.ExemptedResults = append(results.ExemptedResults, exempted...)
 		}
+		*objectResults = kept
 	}
-	if len(deploymentRuleResults) > 0 {
-		k.Logger.Println(fmt.Sprint("Presenting Deployment rule results"))
+	if k.ruleSets != nil {
+		var evaluated []config.RuleInfo
+		for _, ruleInfo := range results.Rules {
+			if !k.ruleSets.skipped[ruleInfo.Name] {
+				evaluated = append(evaluated, ruleInfo)
+			}
+		}
+		results.Rules = evaluated
 	}
-	for _, ruleResult := range deploymentRuleResults {
-		k.Logger.Println(fmt.Sprintf("rule name: %s", ruleResult.RuleName))
-		k.Logger.Println(fmt.Sprintf("rule reason: %s", ruleResult.Reason))
-		for _, deployment := range ruleResult.Deployments {
-			k.Logger.Println(fmt.Sprintf("%s_%s", deployment.Name, deployment.Namespace))
+	results.ExpiringExceptions = k.Exceptions.Expiring(now)
+	results.ExpiredExceptions = k.Exceptions.Expired(now)
+	results.StaleExceptions = k.Exceptions.Stale(results.ExemptedResults, now)
+	results.sort()
+	return results, nil
+}
+
+func (k *KubeConformity) LogNonConforming() error {
+	_, err := k.Run()
+	return err
+}
+
+// Run evaluates the rules, then logs, exposes and notifies about the results.
+// The rules of the conformity rule objects are added to the ones of the config
+// for the run, the rules of an object that fail to evaluate are left out.
+func (k *KubeConformity) Run() (Results, error) {
+	if k.ConformityRules != nil {
+		fileConfig := k.KubeConformityConfig
+		k.KubeConformityConfig = k.mergeRuleSets(fileConfig)
+		defer func() {
+			k.KubeConformityConfig = fileConfig
+			k.ruleSets = nil
+		}()
+	}
+	results, err := k.Evaluate()
+	if err != nil {
+		return results, err
+	}
+	if k.ruleSets != nil {
+		for key, err := range k.ruleSets.errors {
+			k.ConformityRules.SetEvaluationError(key, err)
 		}
 	}
-	if len(statefulSetRuleResults) > 0 {
-		k.Logger.Println(fmt.Sprint("Presenting StatefulSet rule results"))
+	k.logViolations(results)
+	k.logExemptedResults(results.ExemptedResults)
+	k.logExceptions(results)
+	k.logSummary(results)
+	k.recordEvents(results)
+	updateMetrics(results)
+	if err := k.WriteOutputs(results); err != nil {
+		return results, err
 	}
-	for _, ruleResult := range statefulSetRuleResults {
-		k.Logger.Println(fmt.Sprintf("rule name: %s", ruleResult.RuleName))
-		k.Logger.Println(fmt.Sprintf("rule reason: %s", ruleResult.Reason))
-		for _, statefulSet := range ruleResult.StatefulSets {
-			k.Logger.Println(fmt.Sprintf("%s_%s", statefulSet.Name, statefulSet.Namespace))
+	if err := k.WritePolicyReports(results); err != nil {
+		return results, err
+	}
+	k.notify(results)
+	return results, nil
+}
+
+// mergeRuleSets returns the config with the rules of the conformity rule
+// objects added and keeps the object every added rule came from, so the
+// error of a rule that fails to evaluate, like a rego rule with a ConfigMap
+// that does not exist, goes to the Ready condition of its object instead of
+// failing the run for every rule.
+func (k *KubeConformity) mergeRuleSets(fileConfig config.Config) config.Config {
+	merged := fileConfig
+	k.ruleSets = &ruleSetRun{keys: make(map[string]string), errors: make(map[string]error), skipped: make(map[string]bool)}
+	for _, ruleSet := range k.ConformityRules.RuleSets() {
+		added, errs := merged.WithRuleSets([]config.RuleSet{ruleSet})
+		if len(errs) > 0 {
+			k.ConformityRules.SetEvaluationError(ruleSet.Key(), errs[0])
+			continue
+		}
+		merged = added
+		k.ruleSets.errors[ruleSet.Key()] = nil
+		for _, ruleInfo := range fileConfig.RuleSetConfig(ruleSet).RuleInfos() {
+			k.ruleSets.keys[ruleInfo.Name] = ruleSet.Key()
 		}
 	}
-	if k.KubeConformityConfig.EmailConfig.Enabled {
-		k.Logger.Println("Sending mail with conformity results")
-		return k.KubeConformityConfig.EmailConfig.SendMail(podRuleResults, deploymentRuleResults)
+	return merged
+}
+
+// ruleError returns the error of evaluating the rule. The error of a rule of a
+// conformity rule object is kept for the Ready condition of the object
+// instead, the first one of every object, and the rule is left out of the run.
+func (k *KubeConformity) ruleError(ruleName string, err error) error {
+	if k.ruleSets == nil {
+		return err
 	}
+	key, ok := k.ruleSets.keys[ruleName]
+	if !ok {
+		return err
+	}
+	if k.ruleSets.errors[key] == nil {
+		k.ruleSets.errors[key] = err
+	}
+	k.ruleSets.skipped[ruleName] = true
 	return nil
 }
 
-func (k *KubeConformity) EvaluatePodRules() []rules.PodRuleResult {
-	podList, err := k.Client.CoreV1().Pods(v1.NamespaceAll).List(metav1.ListOptions{})
+// notify mails the results and sends them to the notifiers. With routing every
+// address and notifier only gets the violations routed to it. Failures are
+// logged, they do not fail the run. In diff mode every address and notifier
+// keeps its own state, the state of one that failed is left as it was so it
+// gets the notification again on the next run.
+func (k *KubeConformity) notify(results Results) {
+	kubeConfig := k.KubeConformityConfig
+	if !kubeConfig.EmailConfig.Enabled && len(kubeConfig.Notifiers) == 0 {
+		return
+	}
+	violations := k.Report(results).Violations
+	var store notify.StateStore
+	states := notify.States{}
+	if kubeConfig.Notifications.Mode == config.NotifyDiff {
+		store = notify.NewStateStore(kubeConfig.Notifications.StateFile, kubeConfig.Notifications.StateConfigMap, k.Client)
+		var err error
+		if states, err = store.Load(); err != nil {
+			k.Logger.Errorf("Loading the notification state, not notifying: %v", err)
+			return
+		}
+	}
+	var router *notify.Router
+	if kubeConfig.Routing != nil {
+		namespaces, err := k.listNamespaces()
+		if err != nil {
+			k.Logger.Errorf("Listing the namespaces to route notifications, not notifying: %v", err)
+			return
+		}
+		router = &notify.Router{Config: *kubeConfig.Routing, Namespaces: namespaces}
+		for namespace, owners := range router.InvalidOwners() {
+			k.Logger.WithField("namespace", namespace).Warnf("Skipping invalid addresses %v in %s", owners, kubeConfig.Routing.OwnerAnnotation)
+		}
+	}
+	next := notify.States{Channels: make(map[string]notify.State)}
+	send := func(channel string, keep func(reports.Entry) bool, send func(notify.Notification, []reports.Entry) error) {
+		channelViolations := violations
+		if keep != nil {
+			channelViolations = notify.NewMessage(notify.Notification{Digest: true}, violations).Filter(keep).Violations
+		}
+		notification := notify.Notification{Digest: true}
+		state := states.Channel(channel)
+		if store != nil {
+			notification, state = notify.Plan(state, channelViolations, kubeConfig.Notifications.DigestInterval, k.now())
+		}
+		if err := send(notification, channelViolations); err != nil {
+			k.Logger.Errorf("Notifying %s failed: %v", channel, err)
+			state = states.Channel(channel)
+		}
+		next.Channels[channel] = state
+	}
+	if kubeConfig.EmailConfig.Enabled && router == nil {
+		send("email", nil, func(notification notify.Notification, _ []reports.Entry) error {
+			return k.sendMail(kubeConfig.EmailConfig, results, notification)
+		})
+	}
+	if kubeConfig.EmailConfig.Enabled && router != nil {
+		for _, address := range routedEmails(router, violations, states) {
+			emailConfig := routedEmailConfig(kubeConfig.EmailConfig, address)
+			keep := router.ForEmail(address)
+			send("email "+address, keep, func(notification notify.Notification, _ []reports.Entry) error {
+				return k.sendMail(emailConfig, results.Select(keep), notification)
+			})
+		}
+	}
+	for _, notifierConfig := range kubeConfig.Notifiers {
+		notifierConfig := notifierConfig
+		var keep func(reports.Entry) bool
+		if router != nil {
+			keep = router.ForNotifier(notifierConfig.Name)
+		}
+		send("notifier "+notifierConfig.Name, keep, func(notification notify.Notification, channelViolations []reports.Entry) error {
+			return k.sendNotification(notifierConfig, notify.NewMessage(notification, channelViolations))
+		})
+	}
+	if store != nil {
+		if err := store.Save(next); err != nil {
+			k.Logger.Errorf("Saving the notification state: %v", err)
+		}
+	}
+}
+
+// routedEmailConfig returns the email config of the mail to a routed address,
+// without the cc and bcc of the config as they would get a copy of the mail of
+// every address.
+func routedEmailConfig(emailConfig config.EmailConfig, address string) config.EmailConfig {
+	emailConfig.To = config.Addresses{address}
+	emailConfig.Cc = nil
+	emailConfig.Bcc = nil
+	return emailConfig
+}
+
+// routedEmails returns the addresses the violations are routed to and, in
+// diff mode, the ones that got a notification before so they hear about their
+// resolved violations.
+func routedEmails(router *notify.Router, violations []reports.Entry, states notify.States) []string {
+	addresses := router.Emails(notify.NewMessage(notify.Notification{Digest: true}, violations))
+	routed := make(map[string]bool)
+	for _, address := range addresses {
+		routed[address] = true
+	}
+	for channel := range states.Channels {
+		if address := strings.TrimPrefix(channel, "email "); address != channel && !routed[address] {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// sendMail mails the results in full mode. In diff mode it mails the
+// violations that are new or resolved since the last mail, or all of them when
+// a digest is due.
+func (k *KubeConformity) sendMail(emailConfig config.EmailConfig, results Results, notification notify.Notification) error {
+	logger := k.Logger.WithField("to", emailConfig.To.String())
+	mailResults := results
+	if emailConfig.MinSeverity != "" {
+		mailResults = results.AtLeast(emailConfig.MinSeverity)
+	}
+	data := k.mailData(mailResults)
+	if k.KubeConformityConfig.Notifications.Mode == config.NotifyDiff {
+		if notification.Digest {
+			logger.Println("Sending mail with all conformity results as digest")
+			return emailConfig.SendMail(data)
+		}
+		if emailConfig.MinSeverity != "" {
+			notification = notification.AtLeast(emailConfig.MinSeverity)
+		}
+		if notification.Empty() {
+			logger.Println("No new or resolved violations, not sending mail")
+			return nil
+		}
+		logger.Println(fmt.Sprintf("Sending mail with %d new and %d resolved violations", len(notification.New), len(notification.Resolved)))
+		data.Diff = true
+		data.NewViolations = notification.New
+		data.ResolvedViolations = notification.Resolved
+		return emailConfig.SendMail(data)
+	}
+	if emailConfig.MinSeverity != "" && mailResults.Violations() == 0 {
+		logger.Println(fmt.Sprintf("No results with severity %s or higher, not sending mail", emailConfig.MinSeverity))
+		return nil
+	}
+	logger.Println("Sending mail with conformity results")
+	return emailConfig.SendMail(data)
+}
+
+// mailData returns the data the mail templates render for the results.
+func (k *KubeConformity) mailData(results Results) config.MailData {
+	data := config.NewMailData(k.Report(results), k.KubeConformityConfig.ClusterName, k.now())
+	data.PodRuleResults = results.PodRuleResults
+	data.DeploymentRuleResults = results.DeploymentRuleResults
+	data.StatefulSetRuleResults = results.StatefulSetRuleResults
+	data.ExemptedResults = results.ExemptedResults
+	return data
+}
+
+// sendNotification sends the violations of the message routed to the
+// notifier, nothing is sent when none are.
+func (k *KubeConformity) sendNotification(notifierConfig notify.NotifierConfig, message notify.Message) error {
+	message = notifierConfig.Route(message)
+	if message.Empty() {
+		k.Logger.Println(fmt.Sprintf("No violations for notifier %s, not sending notification", notifierConfig.Name))
+		return nil
+	}
+	notifier, err := notifierConfig.Notifier()
 	if err != nil {
-		k.Logger.Fatal(err)
+		return err
 	}
+	k.Logger.Println(fmt.Sprintf("Sending notification to notifier %s", notifierConfig.Name))
+	return notifier.Notify(message)
+}
 
+// WriteOutputs writes the report of the results to every configured output.
+func (k *KubeConformity) WriteOutputs(results Results) error {
+	if len(k.KubeConformityConfig.Outputs) == 0 {
+		return nil
+	}
+	report := k.Report(results)
+	for _, output := range k.KubeConformityConfig.Outputs {
+		if err := output.Write(report); err != nil {
+			return fmt.Errorf("writing %s report: %v", output.Format, err)
+		}
+	}
+	return nil
+}
+
+func (k *KubeConformity) logExceptions(results Results) {
+	for _, exception := range results.ExpiringExceptions {
+		k.Logger.Println(fmt.Sprintf("Exception %s expires on %s", exception, exception.Expires))
+	}
+	for _, exception := range results.ExpiredExceptions {
+		k.Logger.Println(fmt.Sprintf("Exception %s expired on %s", exception, exception.Expires))
+	}
+	for _, exception := range results.StaleExceptions {
+		k.Logger.Println(fmt.Sprintf("Exception %s no longer matches any violation", exception))
+	}
+}
+
+func (k *KubeConformity) EvaluatePodRules() ([]rules.PodRuleResult, error) {
 	var ruleResults []rules.PodRuleResult
 	for _, rule := range k.KubeConformityConfig.PodRulesRequestsFilledIn {
-		result := rule.FindNonConformingPods(podList.Items)
-		ruleResults = append(ruleResults, result)
+		pods, err := k.ListPods(rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, rule.FindNonConformingPods(pods))
 	}
 	for _, rule := range k.KubeConformityConfig.PodRulesLimitsFilledIn {
-		result := rule.FindNonConformingPods(podList.Items)
-		ruleResults = append(ruleResults, result)
+		pods, err := k.ListPods(rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, rule.FindNonConformingPods(pods))
 	}
 	for _, rule := range k.KubeConformityConfig.PodRulesLabelsFilledIn {
-		result := rule.FindNonConformingPods(podList.Items)
-		ruleResults = append(ruleResults, result)
+		pods, err := k.ListPods(rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, rule.FindNonConformingPods(pods))
 	}
-	return ruleResults
+	return ruleResults, nil
 }
 
-func (k *KubeConformity) EvaluateDeploymentRules() []rules.DeploymentRuleResult {
-	deploymentList, err := k.Client.AppsV1().Deployments(v1.NamespaceAll).List(metav1.ListOptions{})
-	if err != nil {
-		k.Logger.Fatal(err)
-	}
+func (k *KubeConformity) EvaluateDeploymentRules() ([]rules.DeploymentRuleResult, error) {
 	var ruleResults []rules.DeploymentRuleResult
 	for _, rule := range k.KubeConformityConfig.DeploymentRuleReplicasMinimum {
-		result := rule.FindNonConformingDeployment(deploymentList.Items)
+		deployments, err := k.ListDeployments(rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		result := rule.FindNonConformingDeployment(deployments)
 		ruleResults = append(ruleResults, result)
 	}
-	return ruleResults
+	return ruleResults, nil
 }
 
-func (k *KubeConformity) EvaluateStatefulSetRules() []rules.StatefulSetRuleResult {
-	statefulSetList, err := k.Client.AppsV1().StatefulSets(v1.NamespaceAll).List(metav1.ListOptions{})
-	if err != nil {
-		k.Logger.Fatal(err)
-	}
+func (k *KubeConformity) EvaluateStatefulSetRules() ([]rules.StatefulSetRuleResult, error) {
 	var ruleResults []rules.StatefulSetRuleResult
 	for _, rule := range k.KubeConformityConfig.StatefulSetRuleReplicasMinimum {
-		result := rule.FindNonConformingStatefulSet(statefulSetList.Items)
+		statefulSets, err := k.ListStatefulSets(rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		result := rule.FindNonConformingStatefulSet(statefulSets)
 		ruleResults = append(ruleResults, result)
 	}
-	return ruleResults
-}
\ No newline at end of file
+	return ruleResults, nil
+}
+
+func (k *KubeConformity) EvaluateRegoRules() ([]rules.ObjectRuleResult, error) {
+	var ruleResults []rules.ObjectRuleResult
+	for _, rule := range k.KubeConformityConfig.RegoRules {
+		modules, err := rule.LoadModules()
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if rule.ConfigMap != nil {
+			configMap, err := k.Client.CoreV1().ConfigMaps(rule.ConfigMap.Namespace).Get(rule.ConfigMap.Name, metav1.GetOptions{})
+			if err != nil {
+				if err := k.ruleError(rule.Name, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			for name, module := range rules.ConfigMapModules(*rule.ConfigMap, configMap.Data) {
+				modules[name] = module
+			}
+		}
+		objects, err := k.ListObjects(rule.Kind, rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		results, err := rule.FindNonConformingObjects(modules, objects)
+		if err != nil {
+			if err := k.ruleError(rule.Name, fmt.Errorf("evaluating rego rule %s: %v", rule.Name, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, results...)
+	}
+	return ruleResults, nil
+}
+
+func (k *KubeConformity) EvaluateFieldRules() ([]rules.ObjectRuleResult, error) {
+	var ruleResults []rules.ObjectRuleResult
+	for _, rule := range k.KubeConformityConfig.FieldRules {
+		objects, err := k.ListObjects(rule.Kind, rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		results, err := rule.FindNonConformingObjects(objects)
+		if err != nil {
+			if err := k.ruleError(rule.Name, fmt.Errorf("evaluating field rule %s: %v", rule.Name, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, results...)
+	}
+	return ruleResults, nil
+}
+
+// ListPods lists the pods the filter can match. The include selector and a
+// single included namespace are handed to the API server, the namespace
+// selector is resolved by listing the matching namespaces.
+func (k *KubeConformity) ListPods(filter filters.Filter) ([]v1.Pod, error) {
+	namespaces, err := k.selectNamespaces(filter)
+	if err != nil {
+		return nil, err
+	}
+	items, err := k.cachedList(newListKey("pods", filter.ListNamespace(), filter.ListOptions()), func() (interface{}, error) {
+		podList, err := k.Client.CoreV1().Pods(filter.ListNamespace()).List(filter.ListOptions())
+		if err != nil {
+			return nil, err
+		}
+		return podList.Items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []v1.Pod
+	for _, pod := range items.([]v1.Pod) {
+		if namespaces == nil || namespaces[pod.Namespace] {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func (k *KubeConformity) ListDeployments(filter filters.Filter) ([]appsv1.Deployment, error) {
+	namespaces, err := k.selectNamespaces(filter)
+	if err != nil {
+		return nil, err
+	}
+	items, err := k.cachedList(newListKey("deployments", filter.ListNamespace(), filter.ListOptions()), func() (interface{}, error) {
+		deploymentList, err := k.Client.AppsV1().Deployments(filter.ListNamespace()).List(filter.ListOptions())
+		if err != nil {
+			return nil, err
+		}
+		return deploymentList.Items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var deployments []appsv1.Deployment
+	for _, deployment := range items.([]appsv1.Deployment) {
+		if namespaces == nil || namespaces[deployment.Namespace] {
+			deployments = append(deployments, deployment)
+		}
+	}
+	return deployments, nil
+}
+
+func (k *KubeConformity) ListStatefulSets(filter filters.Filter) ([]appsv1.StatefulSet, error) {
+	namespaces, err := k.selectNamespaces(filter)
+	if err != nil {
+		return nil, err
+	}
+	items, err := k.cachedList(newListKey("statefulsets", filter.ListNamespace(), filter.ListOptions()), func() (interface{}, error) {
+		statefulSetList, err := k.Client.AppsV1().StatefulSets(filter.ListNamespace()).List(filter.ListOptions())
+		if err != nil {
+			return nil, err
+		}
+		return statefulSetList.Items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var statefulSets []appsv1.StatefulSet
+	for _, statefulSet := range items.([]appsv1.StatefulSet) {
+		if namespaces == nil || namespaces[statefulSet.Namespace] {
+			statefulSets = append(statefulSets, statefulSet)
+		}
+	}
+	return statefulSets, nil
+}
+
+func (k *KubeConformity) ListObjects(kind string, filter filters.Filter) ([]metav1.Object, error) {
+	var objects []metav1.Object
+	switch kind {
+	case "Pod":
+		pods, err := k.ListPods(filter)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range pods {
+			objects = append(objects, &pods[idx])
+		}
+	case "Deployment":
+		deployments, err := k.ListDeployments(filter)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range deployments {
+			objects = append(objects, &deployments[idx])
+		}
+	case "StatefulSet":
+		statefulSets, err := k.ListStatefulSets(filter)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range statefulSets {
+			objects = append(objects, &statefulSets[idx])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", kind)
+	}
+	return objects, nil
+}
+
+// listNamespaces returns the namespaces of the cluster by name.
+func (k *KubeConformity) listNamespaces() (map[string]v1.Namespace, error) {
+	namespaceList, err := k.Client.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string]v1.Namespace)
+	for _, namespace := range namespaceList.Items {
+		namespaces[namespace.Name] = namespace
+	}
+	return namespaces, nil
+}
+
+// selectNamespaces returns the names of the namespaces matching the namespace
+// selector of the filter, or nil when the filter has no namespace selector.
+func (k *KubeConformity) selectNamespaces(filter filters.Filter) (map[string]bool, error) {
+	if filter.NamespaceSelector == "" {
+		return nil, nil
+	}
+	options := metav1.ListOptions{LabelSelector: filter.NamespaceSelector}
+	namespaces, err := k.cachedList(newListKey("namespaces", "", options), func() (interface{}, error) {
+		namespaceList, err := k.Client.CoreV1().Namespaces().List(options)
+		if err != nil {
+			return nil, err
+		}
+		namespaces := make(map[string]bool)
+		for _, namespace := range namespaceList.Items {
+			namespaces[namespace.Name] = true
+		}
+		return namespaces, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return namespaces.(map[string]bool), nil
+}
+
+func (k *KubeConformity) EvaluateResourceRules() ([]rules.ObjectRuleResult, error) {
+	var ruleResults []rules.ObjectRuleResult
+	for _, rule := range k.KubeConformityConfig.ResourceRules {
+		objects, err := k.ListResources(rule.Resource.GroupVersionKind(), rule.Filter.Filter)
+		if err != nil {
+			if err := k.ruleError(rule.Name, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		results, err := rule.FindNonConformingObjects(objects)
+		if err != nil {
+			if err := k.ruleError(rule.Name, fmt.Errorf("evaluating resource rule %s: %v", rule.Name, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ruleResults = append(ruleResults, results...)
+	}
+	return ruleResults, nil
+}
+
+// ListResources lists the objects of a kind the filter can match through the
+// dynamic client, the resource to query is looked up through discovery.
+func (k *KubeConformity) ListResources(gvk schema.GroupVersionKind, filter filters.Filter) ([]unstructured.Unstructured, error) {
+	gvr, err := k.resourceFor(gvk)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := k.selectNamespaces(filter)
+	if err != nil {
+		return nil, err
+	}
+	items, err := k.cachedList(newListKey(gvr.String(), filter.ListNamespace(), filter.ListOptions()), func() (interface{}, error) {
+		list, err := k.DynamicClient.Resource(gvr).Namespace(filter.ListNamespace()).List(filter.ListOptions())
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var objects []unstructured.Unstructured
+	for _, object := range items.([]unstructured.Unstructured) {
+		if namespaces == nil || namespaces[object.GetNamespace()] {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (k *KubeConformity) resourceFor(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	groupVersion := gvk.GroupVersion().String()
+	resourceList, err := k.Client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	for _, resource := range resourceList.APIResources {
+		if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+			return gvk.GroupVersion().WithResource(resource.Name), nil
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("kind %s not found in %s", gvk.Kind, groupVersion)
+}
@@ -2,20 +2,57 @@ package kubeconformity
 
 import (
 	"testing"
-	"log"
+	"encoding/json"
+	"strings"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/api/core/v1"
 	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+	"gopkg.in/yaml.v2"
 	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/filters"
+	"github.com/stijndehaes/kube-conformity/notify"
+	"github.com/stijndehaes/kube-conformity/reports"
 	"github.com/stijndehaes/kube-conformity/rules"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"fmt"
 )
 
 var logOutput = bytes.NewBuffer([]byte{})
-var logger = log.New(logOutput, "", 0)
+var logger = &log.Logger{
+	Out:       logOutput,
+	Formatter: &log.JSONFormatter{DisableTimestamp: true},
+	Hooks:     make(log.LevelHooks),
+	Level:     log.InfoLevel,
+}
+
+// logEntries returns the fields of the entries logged with the given message.
+func logEntries(t *testing.T, message string) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logOutput.String()), "\n") {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["msg"] == message {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
 
 // TestCandidatesNamespaces tests that the list of pods available for
 // termination can be restricted by namespaces.
@@ -32,7 +69,8 @@ func TestKubeConformity_EvaluatePodRules(t *testing.T) {
 		newPodWithLabels("testing", "bar", "uid2", []string{"app"}),
 	}
 	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
-	conformityResult := kubeConformity.EvaluatePodRules()
+	conformityResult, err := kubeConformity.EvaluatePodRules()
+	assert.Nil(t, err)
 	assert.Equal(t, 3, len(conformityResult))
 }
 
@@ -47,7 +85,8 @@ func TestKubeConformity_EvaluateDeploymentRulesRules(t *testing.T) {
 		newDeployment("testing", "bar", "uid2", 2),
 	}
 	kubeConformity := setup(t, nil, deployments, nil, kubeConfig)
-	conformityResult := kubeConformity.EvaluateDeploymentRules()
+	conformityResult, err := kubeConformity.EvaluateDeploymentRules()
+	assert.Nil(t, err)
 	assert.Equal(t, 1, len(conformityResult))
 }
 
@@ -62,7 +101,8 @@ func TestKubeConformity_EvaluateStatefulSetRulesRules(t *testing.T) {
 		newStatefulSet("testing", "bar", "uid2", 2),
 	}
 	kubeConformity := setup(t, nil, nil, statefulSets, kubeConfig)
-	conformityResult := kubeConformity.EvaluateStatefulSetRules()
+	conformityResult, err := kubeConformity.EvaluateStatefulSetRules()
+	assert.Nil(t, err)
 	assert.Equal(t, 1, len(conformityResult))
 }
 
@@ -78,8 +118,20 @@ func TestKubeConformity_LogNonConforming_Pods(t *testing.T) {
 	}
 	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
 	kubeConformity.LogNonConforming()
-	logOutput.String()
-	assert.Equal(t, "Presenting Pod rule results\nrule name: \nrule reason: Labels: [app] are not filled in\nfoo_default\n", logOutput.String())
+	entries := logEntries(t, "Non conforming object")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{
+		"level":     "warning",
+		"msg":       "Non conforming object",
+		"rule":      "",
+		"severity":  "medium",
+		"kind":      "Pod",
+		"namespace": "default",
+		"name":      "foo",
+		"uid":       "uid1",
+		"owner":     "",
+		"reason":    "Labels: [app] are not filled in",
+	}, entries[0])
 }
 
 func TestKubeConformity_LogNonConforming_Deployments(t *testing.T) {
@@ -94,8 +146,13 @@ func TestKubeConformity_LogNonConforming_Deployments(t *testing.T) {
 	}
 	kubeConformity := setup(t, nil, deployments, nil, kubeConfig)
 	kubeConformity.LogNonConforming()
-	logOutput.String()
-	assert.Equal(t, "Presenting Deployment rule results\nrule name: \nrule reason: Deployment replicas below the minimum: 2\nfoo_default\n", logOutput.String())
+	entries := logEntries(t, "Non conforming object")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "Deployment", entries[0]["kind"])
+	assert.Equal(t, "default", entries[0]["namespace"])
+	assert.Equal(t, "foo", entries[0]["name"])
+	assert.Equal(t, "uid1", entries[0]["uid"])
+	assert.Equal(t, "Deployment replicas below the minimum: 2", entries[0]["reason"])
 }
 
 func TestKubeConformity_LogNonConforming_StatefulSets(t *testing.T) {
@@ -110,8 +167,413 @@ func TestKubeConformity_LogNonConforming_StatefulSets(t *testing.T) {
 	}
 	kubeConformity := setup(t, nil, nil, statefulSets, kubeConfig)
 	kubeConformity.LogNonConforming()
-	logOutput.String()
-	assert.Equal(t, "Presenting StatefulSet rule results\nrule name: \nrule reason: StatefulSet replicas below the minimum: 2\nfoo_default\n", logOutput.String())
+	entries := logEntries(t, "Non conforming object")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "StatefulSet", entries[0]["kind"])
+	assert.Equal(t, "default", entries[0]["namespace"])
+	assert.Equal(t, "foo", entries[0]["name"])
+	assert.Equal(t, "uid1", entries[0]["uid"])
+	assert.Equal(t, "StatefulSet replicas below the minimum: 2", entries[0]["reason"])
+}
+
+func TestKubeConformity_MailData_StatefulSets(t *testing.T) {
+	kubeConfig := config.Config{
+		ClusterName: "production",
+		StatefulSetRuleReplicasMinimum: []rules.StatefulSetRuleReplicasMinimum{{
+			Name:            "replicas minimum",
+			MinimumReplicas: 2,
+		}},
+	}
+	statefulSets := []appsv1.StatefulSet{
+		newStatefulSet("default", "foo", "uid1", 1),
+		newStatefulSet("testing", "bar", "uid2", 2),
+	}
+	kubeConformity := setup(t, nil, nil, statefulSets, kubeConfig)
+
+	results, err := kubeConformity.Evaluate()
+	assert.Nil(t, err)
+	data := kubeConformity.mailData(results)
+
+	assert.Equal(t, "production", data.Summary.ClusterName)
+	assert.Equal(t, 1, data.Summary.Violations)
+	if assert.Len(t, data.Violations, 1) {
+		assert.Equal(t, "StatefulSet", data.Violations[0].Kind)
+		assert.Equal(t, "foo", data.Violations[0].Name)
+	}
+	if assert.Len(t, data.Summary.ByRule, 1) {
+		assert.Equal(t, "replicas minimum", data.Summary.ByRule[0].Rule.Name)
+	}
+	assert.Len(t, data.StatefulSetRuleResults, 1)
+}
+
+func TestKubeConformity_EvaluateRegoRules(t *testing.T) {
+	kubeConfig := config.Config{
+		RegoRules: []rules.RegoRule{{
+			Name:       "required labels",
+			Kind:       "Pod",
+			Modules:    []string{"../rules/testdata/rego"},
+			Parameters: map[string]interface{}{"labels": []interface{}{"app"}},
+		}},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("testing", "bar", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	conformityResult, err := kubeConformity.EvaluateRegoRules()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(conformityResult))
+	assert.Equal(t, "foo", conformityResult[0].Objects[0].GetName())
+}
+
+func TestKubeConformity_EvaluateFieldRules(t *testing.T) {
+	kubeConfig := config.Config{
+		FieldRules: []rules.FieldRule{{
+			Name: "replicas at most 1",
+			Kind: "StatefulSet",
+			Fields: []rules.FieldCheck{
+				{Path: "spec.replicas", Operator: rules.OperatorLte, Value: 1},
+			},
+		}},
+	}
+	statefulSets := []appsv1.StatefulSet{
+		newStatefulSet("default", "foo", "uid1", 1),
+		newStatefulSet("testing", "bar", "uid2", 2),
+	}
+	kubeConformity := setup(t, nil, nil, statefulSets, kubeConfig)
+	conformityResult, err := kubeConformity.EvaluateFieldRules()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(conformityResult))
+	assert.Equal(t, "Field spec.replicas is greater than 1", conformityResult[0].Reason)
+	assert.Equal(t, "bar", conformityResult[0].Objects[0].GetName())
+}
+
+func TestKubeConformity_ListPods_IncludeSelector(t *testing.T) {
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("testing", "bar", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, config.Config{})
+	filteredPods, err := kubeConformity.ListPods(filters.Filter{IncludeSelector: "app"})
+	assert.Nil(t, err)
+	assert.Len(t, filteredPods, 1)
+	assert.Equal(t, "bar", filteredPods[0].Name)
+}
+
+func TestKubeConformity_ListPods_IncludeNamespace(t *testing.T) {
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("testing", "bar", "uid2", []string{}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, config.Config{})
+	filteredPods, err := kubeConformity.ListPods(filters.Filter{IncludeNamespaces: []string{"testing"}})
+	assert.Nil(t, err)
+	assert.Len(t, filteredPods, 1)
+	assert.Equal(t, "bar", filteredPods[0].Name)
+}
+
+func TestKubeConformity_ListDeployments_NamespaceSelector(t *testing.T) {
+	deployments := []appsv1.Deployment{
+		newDeployment("default", "foo", "uid1", 1),
+		newDeployment("payments", "bar", "uid2", 1),
+	}
+	kubeConformity := setup(t, nil, deployments, nil, config.Config{})
+	createNamespace(t, kubeConformity, "default", map[string]string{})
+	createNamespace(t, kubeConformity, "payments", map[string]string{"team": "payments"})
+	filteredDeployments, err := kubeConformity.ListDeployments(filters.Filter{NamespaceSelector: "team in (payments, checkout)"})
+	assert.Nil(t, err)
+	assert.Len(t, filteredDeployments, 1)
+	assert.Equal(t, "bar", filteredDeployments[0].Name)
+}
+
+func TestKubeConformity_EvaluateStatefulSetRules_NamespaceSelector(t *testing.T) {
+	rule := rules.StatefulSetRuleReplicasMinimum{MinimumReplicas: 2}
+	rule.Filter.NamespaceSelector = "team=payments"
+	kubeConfig := config.Config{
+		StatefulSetRuleReplicasMinimum: []rules.StatefulSetRuleReplicasMinimum{rule},
+	}
+	statefulSets := []appsv1.StatefulSet{
+		newStatefulSet("default", "foo", "uid1", 1),
+		newStatefulSet("payments", "bar", "uid2", 1),
+	}
+	kubeConformity := setup(t, nil, nil, statefulSets, kubeConfig)
+	createNamespace(t, kubeConformity, "payments", map[string]string{"team": "payments"})
+	conformityResult, err := kubeConformity.EvaluateStatefulSetRules()
+	assert.Nil(t, err)
+	assert.Len(t, conformityResult[0].StatefulSets, 1)
+	assert.Equal(t, "bar", conformityResult[0].StatefulSets[0].Name)
+}
+
+func TestKubeConformity_Evaluate_ListError(t *testing.T) {
+	for _, resource := range []string{"pods", "deployments", "statefulsets"} {
+		kubeConfig := config.Config{
+			PodRulesLabelsFilledIn:         []rules.PodRuleLabelsFilledIn{{Labels: []string{"app"}}},
+			DeploymentRuleReplicasMinimum:  []rules.DeploymentRuleReplicasMinimum{{MinimumReplicas: 2}},
+			StatefulSetRuleReplicasMinimum: []rules.StatefulSetRuleReplicasMinimum{{MinimumReplicas: 2}},
+		}
+		kubeConformity := setup(t, nil, nil, nil, kubeConfig)
+		kubeConformity.Client.(*fake.Clientset).PrependReactor("list", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("listing %s failed", resource)
+		})
+
+		_, err := kubeConformity.Evaluate()
+
+		assert.EqualError(t, err, fmt.Sprintf("listing %s failed", resource))
+	}
+}
+
+func TestKubeConformity_ListObjects_UnsupportedKind(t *testing.T) {
+	kubeConformity := setup(t, nil, nil, nil, config.Config{})
+	_, err := kubeConformity.ListObjects("Service", filters.Filter{})
+	assert.NotNil(t, err)
+}
+
+func TestKubeConformity_EvaluateResourceRules(t *testing.T) {
+	kubeConfig := config.Config{
+		ResourceRules: []rules.ResourceRule{{
+			Name:     "certificates have an owner",
+			Resource: rules.ResourceSelector{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			Labels:   []string{"owner"},
+		}},
+	}
+	kubeConformity := setupWithResources(t, []runtime.Object{
+		newCertificate("default", "foo", map[string]interface{}{}),
+		newCertificate("default", "bar", map[string]interface{}{"owner": "team-a"}),
+	}, kubeConfig)
+	conformityResult, err := kubeConformity.EvaluateResourceRules()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(conformityResult))
+	assert.Equal(t, "Certificate", conformityResult[0].Kind)
+	assert.Len(t, conformityResult[0].Objects, 1)
+	assert.Equal(t, "foo", conformityResult[0].Objects[0].GetName())
+}
+
+func TestKubeConformity_ListResources_UnknownKind(t *testing.T) {
+	kubeConformity := setupWithResources(t, nil, config.Config{})
+	_, err := kubeConformity.ListResources(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}, filters.Filter{})
+	assert.NotNil(t, err)
+}
+
+func TestKubeConformity_LogNonConforming_Resources(t *testing.T) {
+	kubeConfig := config.Config{
+		ResourceRules: []rules.ResourceRule{{
+			Name:     "certificates have an owner",
+			Resource: rules.ResourceSelector{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			Labels:   []string{"owner"},
+		}},
+	}
+	kubeConformity := setupWithResources(t, []runtime.Object{
+		newCertificate("default", "foo", map[string]interface{}{}),
+	}, kubeConfig)
+	kubeConformity.LogNonConforming()
+	entries := logEntries(t, "Non conforming object")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "certificates have an owner", entries[0]["rule"])
+	assert.Equal(t, "Certificate", entries[0]["kind"])
+	assert.Equal(t, "foo", entries[0]["name"])
+	assert.Equal(t, "Labels: [owner] are not filled in", entries[0]["reason"])
+}
+
+func TestKubeConformity_Evaluate_Exempted(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "app label", Labels: []string{"app"}},
+		},
+		FieldRules: []rules.FieldRule{{
+			Name:   "priority class",
+			Kind:   "Pod",
+			Fields: []rules.FieldCheck{{Path: "spec.priorityClassName"}},
+		}},
+	}
+	exemptedPod := newPodWithLabels("default", "exempted", "uid1", []string{})
+	exemptedPod.Annotations = map[string]string{
+		rules.ExemptAnnotation:       "app label,priority class",
+		rules.ExemptUntilAnnotation:  "2999-01-01",
+		rules.ExemptReasonAnnotation: "Migrating to a new chart",
+	}
+	expiredPod := newPodWithLabels("default", "expired", "uid2", []string{})
+	expiredPod.Annotations = map[string]string{
+		rules.ExemptAnnotation:      "app label",
+		rules.ExemptUntilAnnotation: "2000-01-01",
+	}
+	kubeConformity := setup(t, []v1.Pod{exemptedPod, expiredPod}, nil, nil, kubeConfig)
+
+	results, err := kubeConformity.Evaluate()
+
+	assert.Nil(t, err)
+	assert.Len(t, results.PodRuleResults, 1)
+	assert.Len(t, results.PodRuleResults[0].Pods, 1)
+	assert.Equal(t, "expired", results.PodRuleResults[0].Pods[0].Name)
+	assert.Len(t, results.FieldRuleResults, 1)
+	assert.Len(t, results.FieldRuleResults[0].Objects, 1)
+	assert.Len(t, results.ExemptedResults, 2)
+	for _, exempted := range results.ExemptedResults {
+		assert.Equal(t, "exempted", exempted.Object.GetName())
+		assert.Equal(t, "Migrating to a new chart", exempted.Exemption.Reason)
+	}
+}
+
+func TestKubeConformity_LogNonConforming_Exempted(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "app label", Labels: []string{"app"}},
+		},
+	}
+	pod := newPodWithLabels("default", "exempted", "uid1", []string{})
+	pod.Annotations = map[string]string{
+		rules.ExemptAnnotation:       "*",
+		rules.ExemptReasonAnnotation: "Legacy workload",
+	}
+	logOutput.Reset()
+	kubeConformity := setup(t, []v1.Pod{pod}, nil, nil, kubeConfig)
+
+	err := kubeConformity.LogNonConforming()
+
+	assert.Nil(t, err)
+	assert.Len(t, logEntries(t, "Non conforming object"), 0)
+	entries := logEntries(t, "Exempted object")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "app label", entries[0]["rule"])
+	assert.Equal(t, "exempted", entries[0]["name"])
+	assert.Equal(t, "forever", entries[0]["exempted_until"])
+	assert.Equal(t, "Legacy workload", entries[0]["exemption_reason"])
+}
+
+func TestKubeConformity_LogNonConforming_Exceptions(t *testing.T) {
+	kubeConfig := config.Config{
+		DeploymentRuleReplicasMinimum: []rules.DeploymentRuleReplicasMinimum{{
+			Name:            "replicas minimum",
+			MinimumReplicas: 2,
+		}},
+	}
+	exceptions := rules.Exceptions{}
+	err := yaml.Unmarshal([]byte(`
+warn_days: 30
+exceptions:
+- kind: Deployment
+  name: legacy-*
+  rules: [replicas minimum]
+  owner: platform-team
+  ticket: SEC-1
+  expires: "2019-06-30"
+- kind: Deployment
+  name: removed-*
+  rules: [replicas minimum]
+  owner: platform-team
+  ticket: SEC-2
+  expires: "2019-12-31"
+- rules: [replicas minimum]
+  owner: platform-team
+  ticket: SEC-3
+  expires: "2019-01-31"`), &exceptions)
+	assert.Nil(t, err)
+	deployments := []appsv1.Deployment{
+		newDeployment("default", "legacy-api", "uid1", 1),
+		newDeployment("default", "api", "uid2", 1),
+	}
+	logOutput.Reset()
+	kubeConformity := setup(t, nil, deployments, nil, kubeConfig)
+	kubeConformity.Exceptions = exceptions
+	kubeConformity.now = func() time.Time { return time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	results, err := kubeConformity.Evaluate()
+
+	assert.Nil(t, err)
+	assert.Len(t, results.DeploymentRuleResults[0].Deployments, 1)
+	assert.Equal(t, "api", results.DeploymentRuleResults[0].Deployments[0].Name)
+	assert.Len(t, results.ExemptedResults, 1)
+	assert.Equal(t, "SEC-1", results.ExemptedResults[0].Exception.Ticket)
+
+	err = kubeConformity.LogNonConforming()
+
+	assert.Nil(t, err)
+	assert.Contains(t, logOutput.String(), "Exception SEC-1 owned by platform-team expires on 2019-06-30")
+	assert.Contains(t, logOutput.String(), "Exception SEC-3 owned by platform-team expired on 2019-01-31")
+	assert.Contains(t, logOutput.String(), "Exception SEC-2 owned by platform-team no longer matches any violation")
+}
+
+func TestKubeConformity_Evaluate_SortsOnSeverity(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLimitsFilledIn: []rules.PodRuleLimitsFilledIn{
+			{Name: "limits", Severity: rules.SeverityLow},
+		},
+		PodRulesRequestsFilledIn: []rules.PodRuleRequestsFilledIn{
+			{Name: "requests", Severity: rules.SeverityCritical},
+		},
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}},
+		},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	results, err := kubeConformity.Evaluate()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "requests", results.PodRuleResults[0].RuleName)
+	assert.Equal(t, "labels", results.PodRuleResults[1].RuleName)
+	assert.Equal(t, rules.SeverityMedium, results.PodRuleResults[1].Severity)
+	assert.Equal(t, "limits", results.PodRuleResults[2].RuleName)
+}
+
+func TestKubeConformity_Run_EmailMinSeverity(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}, Severity: rules.SeverityLow},
+		},
+		EmailConfig: config.EmailConfig{Enabled: true, MinSeverity: rules.SeverityHigh},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	logOutput.Reset()
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	results, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, results.Violations())
+	assert.Contains(t, logOutput.String(), "No results with severity high or higher, not sending mail")
+}
+
+func TestKubeConformity_SetConfig(t *testing.T) {
+	kubeConformity := setup(t, nil, nil, nil, config.Config{Interval: time.Hour})
+	eventsConfig := config.DefaultEventConfig
+	eventsConfig.Enabled = true
+
+	kubeConformity.SetConfig(config.Config{Interval: time.Hour, Events: eventsConfig})
+	events := kubeConformity.Events
+	assert.NotNil(t, events)
+
+	kubeConformity.SetConfig(config.Config{Interval: 5 * time.Minute, Events: eventsConfig})
+	assert.Equal(t, 5*time.Minute, kubeConformity.KubeConformityConfig.Interval)
+	assert.True(t, events == kubeConformity.Events, "the event recorder should be kept when the events config did not change")
+
+	kubeConformity.SetConfig(config.Config{Interval: 5 * time.Minute})
+	assert.Nil(t, kubeConformity.Events)
+}
+
+func setupWithResources(t *testing.T, objects []runtime.Object, kubeConfig config.Config) *KubeConformity {
+	kubeConformity := setup(t, nil, nil, nil, kubeConfig)
+	kubeConformity.Client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: "cert-manager.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "certificates", Kind: "Certificate", Namespaced: true},
+			{Name: "certificates/status", Kind: "Certificate", Namespaced: true},
+		},
+	}}
+	kubeConformity.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	return kubeConformity
+}
+
+func newCertificate(namespace, name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+			"labels":    labels,
+		},
+	}}
 }
 
 func setup(t *testing.T, pods []v1.Pod, deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet, kubeConfig config.Config) *KubeConformity {
@@ -134,7 +596,14 @@ func setup(t *testing.T, pods []v1.Pod, deployments []appsv1.Deployment, statefu
 	}
 	logOutput.Reset()
 
-	return New(client, logger, kubeConfig)
+	return New(client, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), logger, kubeConfig)
+}
+
+func createNamespace(t *testing.T, kubeConformity *KubeConformity, name string, labels map[string]string) {
+	namespace := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	if _, err := kubeConformity.Client.CoreV1().Namespaces().Create(&namespace); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func newPodWithLabels(namespace, name string, uid types.UID, labels []string) v1.Pod {
@@ -177,3 +646,156 @@ func newStatefulSet(namespace, name string, uid types.UID, replicas int32) appsv
 		},
 	}
 }
+
+func TestKubeConformity_Run_DiffMailNothingChanged(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}},
+		},
+		EmailConfig: config.EmailConfig{Enabled: true},
+		Notifications: config.NotificationConfig{
+			Mode:           config.NotifyDiff,
+			StateConfigMap: &rules.ConfigMapReference{Namespace: "kube-conformity", Name: "kube-conformity-state"},
+		},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	kubeConformity.now = func() time.Time { return now }
+	store := notify.NewStateStore(kubeConfig.Notifications.StateFile, kubeConfig.Notifications.StateConfigMap, kubeConformity.Client)
+	results, err := kubeConformity.Evaluate()
+	assert.Nil(t, err)
+	_, state := notify.Plan(notify.State{}, kubeConformity.Report(results).Violations, 0, now.Add(-time.Hour))
+	assert.Nil(t, store.Save(notify.States{Channels: map[string]notify.State{"email": state}}))
+
+	_, err = kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Contains(t, logOutput.String(), "No new or resolved violations, not sending mail")
+	states, err := store.Load()
+	assert.Nil(t, err)
+	assert.Len(t, states.Channel("email").Violations, 1)
+}
+
+func TestKubeConformity_Run_Notifiers(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}, Severity: rules.SeverityHigh},
+		},
+		Notifiers: []notify.NotifierConfig{
+			{Name: "all", Type: notify.NotifierWebhook, URL: server.URL, Body: `{{ len .Violations }}`},
+			{Name: "critical", Type: notify.NotifierWebhook, URL: server.URL, MinSeverity: rules.SeverityCritical},
+		},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, bodies)
+	assert.Contains(t, logOutput.String(), "Sending notification to notifier all")
+	assert.Contains(t, logOutput.String(), "No violations for notifier critical, not sending notification")
+}
+
+func TestKubeConformity_Run_DiffNotifierFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slack" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}},
+		},
+		Notifications: config.NotificationConfig{
+			Mode:           config.NotifyDiff,
+			StateConfigMap: &rules.ConfigMapReference{Namespace: "kube-conformity", Name: "kube-conformity-state"},
+		},
+		Notifiers: []notify.NotifierConfig{
+			{Name: "slack", Type: notify.NotifierSlack, URL: server.URL + "/slack"},
+			{Name: "webhook", Type: notify.NotifierWebhook, URL: server.URL + "/webhook"},
+		},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err, "a failed notification should not fail the run")
+	assert.Contains(t, logOutput.String(), "Notifying notifier slack failed")
+	states, err := notify.NewStateStore("", kubeConfig.Notifications.StateConfigMap, kubeConformity.Client).Load()
+	assert.Nil(t, err)
+	assert.Empty(t, states.Channel("notifier slack").Violations)
+	assert.Len(t, states.Channel("notifier webhook").Violations, 1, "the state of the notifier that succeeded should be saved")
+}
+
+func TestKubeConformity_Run_Routing(t *testing.T) {
+	bodies := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies[r.URL.Path] = append(bodies[r.URL.Path], string(body))
+	}))
+	defer server.Close()
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}},
+		},
+		Notifiers: []notify.NotifierConfig{
+			{Name: "payments", Type: notify.NotifierWebhook, URL: server.URL + "/payments", Body: `{{ range .Violations }}{{ .Name }} {{ end }}`},
+			{Name: "platform", Type: notify.NotifierWebhook, URL: server.URL + "/platform", Body: `{{ range .Violations }}{{ .Name }} {{ end }}`},
+		},
+		Routing: &notify.RoutingConfig{
+			Routes:   []notify.Route{{NamespaceSelector: "team=payments", Receiver: notify.Receiver{Notifiers: []string{"payments"}}}},
+			Fallback: &notify.Receiver{Notifiers: []string{"platform"}},
+		},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("payments", "checkout", "uid1", []string{}),
+		newPodWithLabels("default", "foo", "uid2", []string{}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	createNamespace(t, kubeConformity, "payments", map[string]string{"team": "payments"})
+	createNamespace(t, kubeConformity, "default", nil)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"/payments": {"checkout "}, "/platform": {"foo "}}, bodies)
+}
+
+func TestRoutedEmails(t *testing.T) {
+	router := &notify.Router{Config: notify.RoutingConfig{Fallback: &notify.Receiver{Email: []string{"platform@example.com"}}}}
+	violations := []reports.Entry{{RuleName: "labels", Kind: "Pod", Namespace: "default", Name: "foo"}}
+	states := notify.States{Channels: map[string]notify.State{
+		"email payments@example.com": {},
+		"email platform@example.com": {},
+		"notifier slack":             {},
+	}}
+
+	assert.Equal(t, []string{"payments@example.com", "platform@example.com"}, routedEmails(router, violations, states))
+}
+
+func TestRoutedEmailConfig(t *testing.T) {
+	emailConfig := config.EmailConfig{
+		From: "kube-conformity@example.com",
+		To:   config.Addresses{"conformity@example.com"},
+		Cc:   config.Addresses{"security@example.com"},
+		Bcc:  config.Addresses{"audit@example.com"},
+	}
+
+	routed := routedEmailConfig(emailConfig, "payments@example.com")
+
+	assert.Equal(t, config.Addresses{"payments@example.com"}, routed.To)
+	assert.Empty(t, routed.Cc)
+	assert.Empty(t, routed.Bcc)
+	assert.Equal(t, "kube-conformity@example.com", routed.From)
+	assert.Equal(t, config.Addresses{"security@example.com"}, emailConfig.Cc)
+}
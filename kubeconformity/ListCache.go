@@ -0,0 +1,37 @@
+package kubeconformity
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listCache keeps the lists of a run by resource, namespace and list options,
+// so rules listing the same objects share a single list call.
+type listCache map[listKey]interface{}
+
+type listKey struct {
+	resource      string
+	namespace     string
+	labelSelector string
+	fieldSelector string
+}
+
+func newListKey(resource string, namespace string, options metav1.ListOptions) listKey {
+	return listKey{resource, namespace, options.LabelSelector, options.FieldSelector}
+}
+
+// cachedList returns the cached list of the key, or lists and caches it.
+// Outside of a run nothing is cached and every call lists.
+func (k *KubeConformity) cachedList(key listKey, list func() (interface{}, error)) (interface{}, error) {
+	if k.lists == nil {
+		return list()
+	}
+	if cached, ok := k.lists[key]; ok {
+		return cached, nil
+	}
+	items, err := list()
+	if err != nil {
+		return nil, err
+	}
+	k.lists[key] = items
+	return items, nil
+}
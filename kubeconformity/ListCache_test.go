@@ -0,0 +1,44 @@
+package kubeconformity
+
+import (
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func listActions(client *fake.Clientset, resource string) int {
+	lists := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == resource {
+			lists++
+		}
+	}
+	return lists
+}
+
+func TestKubeConformity_Evaluate_ListsOncePerRun(t *testing.T) {
+	labelsRule := rules.PodRuleLabelsFilledIn{Name: "app label", Labels: []string{"app"}}
+	otherNamespace := rules.PodRuleLabelsFilledIn{Name: "team label", Labels: []string{"team"}}
+	otherNamespace.Filter.IncludeNamespaces = []string{"payments"}
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn:   []rules.PodRuleLabelsFilledIn{labelsRule, otherNamespace},
+		PodRulesLimitsFilledIn:   []rules.PodRuleLimitsFilledIn{{Name: "limits"}},
+		PodRulesRequestsFilledIn: []rules.PodRuleRequestsFilledIn{{Name: "requests"}},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	client := kubeConformity.Client.(*fake.Clientset)
+	client.ClearActions()
+
+	_, err := kubeConformity.Evaluate()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, listActions(client, "pods"), "the rules listing all namespaces should share a list")
+
+	_, err = kubeConformity.Evaluate()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, listActions(client, "pods"), "every run should list again")
+}
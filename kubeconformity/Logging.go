@@ -0,0 +1,68 @@
+package kubeconformity
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stijndehaes/kube-conformity/rules"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logViolations logs a single entry for every object violating a rule, so the
+// rule and the object can be correlated in a log aggregator.
+func (k *KubeConformity) logViolations(results Results) {
+	for _, violation := range results.violations() {
+		k.Logger.WithFields(objectFields(violation.RuleName, violation.Severity, violation.Kind, violation.Reason, violation.Object)).Warn("Non conforming object")
+	}
+}
+
+func (k *KubeConformity) logExemptedResults(exemptedResults []rules.ExemptedResult) {
+	for _, exempted := range exemptedResults {
+		fields := objectFields(exempted.RuleName, exempted.Severity, exempted.Kind, exempted.Reason, exempted.Object)
+		fields["exempted_until"] = "forever"
+		if exempted.Exemption.Until != nil {
+			fields["exempted_until"] = exempted.Exemption.Until.Format(time.RFC3339)
+		}
+		fields["exemption_reason"] = exempted.Exemption.Reason
+		fields["exemption_owner"] = exempted.Exemption.Owner
+		fields["exemption_ticket"] = exempted.Exemption.Ticket
+		k.Logger.WithFields(fields).Info("Exempted object")
+	}
+}
+
+// logSummary logs a single entry with the totals of a run.
+func (k *KubeConformity) logSummary(results Results) {
+	summary := k.Report(results).Summary
+	fields := log.Fields{
+		"rules":      summary.Rules,
+		"violations": summary.Violations,
+		"exempted":   summary.Exempted,
+	}
+	for _, severity := range rules.Severities {
+		fields["violations_"+string(severity)] = summary.ViolationsBySeverity[string(severity)]
+	}
+	k.Logger.WithFields(fields).Info("Conformity run finished")
+}
+
+func objectFields(ruleName string, severity rules.Severity, kind, reason string, object metav1.Object) log.Fields {
+	return log.Fields{
+		"rule":      ruleName,
+		"severity":  string(severity.OrDefault()),
+		"kind":      kind,
+		"namespace": object.GetNamespace(),
+		"name":      object.GetName(),
+		"uid":       string(object.GetUID()),
+		"owner":     objectOwner(object),
+		"reason":    reason,
+	}
+}
+
+// objectOwner returns the controller of the object as kind/name, or an empty
+// string when the object is not controlled.
+func objectOwner(object metav1.Object) string {
+	owner := metav1.GetControllerOf(object)
+	if owner == nil {
+		return ""
+	}
+	return owner.Kind + "/" + owner.Name
+}
@@ -0,0 +1,55 @@
+package kubeconformity
+
+import (
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectOwner(t *testing.T) {
+	controller := true
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{Kind: "ConfigMap", Name: "settings"},
+		{Kind: "ReplicaSet", Name: "foo-6d4cf56db6", Controller: &controller},
+	}
+
+	assert.Equal(t, "ReplicaSet/foo-6d4cf56db6", objectOwner(&pod))
+}
+
+func TestObjectOwner_NoController(t *testing.T) {
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+
+	assert.Equal(t, "", objectOwner(&pod))
+}
+
+func TestKubeConformity_LogNonConforming_Summary(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "app label", Labels: []string{"app"}, Severity: rules.SeverityHigh},
+		},
+		PodRulesLimitsFilledIn: []rules.PodRuleLimitsFilledIn{{Name: "limits"}},
+	}
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("default", "bar", "uid2", []string{"app"}),
+	}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	err := kubeConformity.LogNonConforming()
+
+	assert.Nil(t, err)
+	assert.Len(t, logEntries(t, "Non conforming object"), 1)
+	entries := logEntries(t, "Conformity run finished")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, float64(2), entries[0]["rules"])
+	assert.Equal(t, float64(1), entries[0]["violations"])
+	assert.Equal(t, float64(0), entries[0]["exempted"])
+	assert.Equal(t, float64(1), entries[0]["violations_high"])
+	assert.Equal(t, float64(0), entries[0]["violations_medium"])
+	assert.Equal(t, float64(0), entries[0]["violations_critical"])
+}
@@ -0,0 +1,45 @@
+package kubeconformity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stijndehaes/kube-conformity/rules"
+)
+
+var (
+	nonConformingObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kube_conformity_non_conforming_objects",
+		Help: "Number of objects violating a rule during the last run.",
+	}, []string{"rule_name", "kind", "severity"})
+	exemptedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kube_conformity_exempted_objects",
+		Help: "Number of objects violating a rule that are exempted from it during the last run.",
+	}, []string{"rule_name", "kind", "severity"})
+)
+
+func init() {
+	prometheus.MustRegister(nonConformingObjects, exemptedObjects)
+}
+
+// updateMetrics replaces the gauges with the counts of the last run, so rules
+// that are no longer violated do not keep reporting old counts.
+func updateMetrics(results Results) {
+	nonConformingObjects.Reset()
+	exemptedObjects.Reset()
+	for _, result := range results.PodRuleResults {
+		nonConformingObjects.WithLabelValues(result.RuleName, "Pod", string(result.Severity)).Add(float64(len(result.Pods)))
+	}
+	for _, result := range results.DeploymentRuleResults {
+		nonConformingObjects.WithLabelValues(result.RuleName, "Deployment", string(result.Severity)).Add(float64(len(result.Deployments)))
+	}
+	for _, result := range results.StatefulSetRuleResults {
+		nonConformingObjects.WithLabelValues(result.RuleName, "StatefulSet", string(result.Severity)).Add(float64(len(result.StatefulSets)))
+	}
+	for _, objectResults := range [][]rules.ObjectRuleResult{results.RegoRuleResults, results.ResourceRuleResults, results.FieldRuleResults} {
+		for _, result := range objectResults {
+			nonConformingObjects.WithLabelValues(result.RuleName, result.Kind, string(result.Severity)).Add(float64(len(result.Objects)))
+		}
+	}
+	for _, result := range results.ExemptedResults {
+		exemptedObjects.WithLabelValues(result.RuleName, result.Kind, string(result.Severity)).Inc()
+	}
+}
@@ -0,0 +1,190 @@
+package kubeconformity
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/rules"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	policyReportAPIVersion = "wgpolicyk8s.io/v1alpha2"
+	managedByLabel         = "app.kubernetes.io/managed-by"
+
+	PolicyResultFail = "fail"
+	PolicyResultSkip = "skip"
+)
+
+var (
+	policyReportsResource        = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}
+	clusterPolicyReportsResource = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}
+)
+
+// WritePolicyReports reconciles the PolicyReports of every namespace with
+// violating or exempted objects and the ClusterPolicyReport of the objects
+// without a namespace. Reports are created and updated every run, reports of
+// namespaces without results anymore are deleted.
+func (k *KubeConformity) WritePolicyReports(results Results) error {
+	if !k.KubeConformityConfig.PolicyReports.Enabled {
+		return nil
+	}
+	namespaced, cluster := k.policyReports(results, k.now())
+	if err := k.reconcilePolicyReports(policyReportsResource, namespaced); err != nil {
+		return fmt.Errorf("reconciling policy reports: %v", err)
+	}
+	if err := k.reconcilePolicyReports(clusterPolicyReportsResource, cluster); err != nil {
+		return fmt.Errorf("reconciling cluster policy reports: %v", err)
+	}
+	return nil
+}
+
+// policyReports returns the PolicyReports of the namespaces and the
+// ClusterPolicyReport, sorted on namespace. Violations are failed results and
+// exempted objects skipped results.
+func (k *KubeConformity) policyReports(results Results, now time.Time) ([]*unstructured.Unstructured, []*unstructured.Unstructured) {
+	ruleTypes := make(map[string]string)
+	for _, ruleInfo := range k.ruleInfos(results) {
+		ruleTypes[ruleInfo.Name] = ruleInfo.Type
+	}
+	resultsByNamespace := make(map[string][]interface{})
+	summaries := make(map[string]map[string]interface{})
+	add := func(violation violation, result string, properties map[string]interface{}) {
+		namespace := violation.Object.GetNamespace()
+		if summaries[namespace] == nil {
+			summaries[namespace] = map[string]interface{}{"pass": int64(0), "fail": int64(0), "warn": int64(0), "error": int64(0), "skip": int64(0)}
+		}
+		summaries[namespace][result] = summaries[namespace][result].(int64) + 1
+		resultsByNamespace[namespace] = append(resultsByNamespace[namespace], policyReportResult(violation, ruleTypes[violation.RuleName], result, properties, now))
+	}
+	for _, violation := range results.violations() {
+		add(violation, PolicyResultFail, nil)
+	}
+	for _, exempted := range results.ExemptedResults {
+		add(violation{exempted.RuleName, exempted.Severity, exempted.Kind, exempted.Reason, exempted.Object}, PolicyResultSkip, exemptionProperties(exempted.Exemption))
+	}
+	var namespaces []string
+	for namespace := range resultsByNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	var namespaced, cluster []*unstructured.Unstructured
+	for _, namespace := range namespaces {
+		kind := "PolicyReport"
+		if namespace == "" {
+			kind = "ClusterPolicyReport"
+		}
+		report := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": policyReportAPIVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":   k.KubeConformityConfig.PolicyReports.Name,
+				"labels": map[string]interface{}{managedByLabel: eventComponent},
+			},
+			"summary": summaries[namespace],
+			"results": resultsByNamespace[namespace],
+		}}
+		if namespace == "" {
+			cluster = append(cluster, report)
+			continue
+		}
+		report.SetNamespace(namespace)
+		namespaced = append(namespaced, report)
+	}
+	return namespaced, cluster
+}
+
+func policyReportResult(violation violation, ruleType string, result string, properties map[string]interface{}, now time.Time) map[string]interface{} {
+	reference := objectReference(violation)
+	policyResult := map[string]interface{}{
+		"policy":    violation.RuleName,
+		"message":   violation.Reason,
+		"result":    result,
+		"severity":  string(violation.Severity.OrDefault()),
+		"source":    eventComponent,
+		"scored":    true,
+		"timestamp": map[string]interface{}{"seconds": now.Unix(), "nanos": int64(0)},
+		"resources": []interface{}{map[string]interface{}{
+			"apiVersion": reference.APIVersion,
+			"kind":       reference.Kind,
+			"namespace":  reference.Namespace,
+			"name":       reference.Name,
+			"uid":        string(reference.UID),
+		}},
+	}
+	if ruleType != "" {
+		policyResult["rule"] = ruleType
+	}
+	if len(properties) > 0 {
+		policyResult["properties"] = properties
+	}
+	return policyResult
+}
+
+func exemptionProperties(exemption rules.Exemption) map[string]interface{} {
+	properties := make(map[string]interface{})
+	if exemption.Until != nil {
+		properties["exemptedUntil"] = exemption.Until.Format(time.RFC3339)
+	}
+	for name, value := range map[string]string{"exemptionReason": exemption.Reason, "exemptionOwner": exemption.Owner, "exemptionTicket": exemption.Ticket} {
+		if value != "" {
+			properties[name] = value
+		}
+	}
+	return properties
+}
+
+// reconcilePolicyReports creates or updates the reports and deletes the
+// reports managed by kube-conformity with the configured name that are not
+// among them, so reports of other instances are left alone.
+func (k *KubeConformity) reconcilePolicyReports(gvr schema.GroupVersionResource, reports []*unstructured.Unstructured) error {
+	resource := k.DynamicClient.Resource(gvr)
+	name := k.KubeConformityConfig.PolicyReports.Name
+	list, err := resource.List(metav1.ListOptions{
+		LabelSelector: managedByLabel + "=" + eventComponent,
+		FieldSelector: "metadata.name=" + name,
+	})
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]unstructured.Unstructured)
+	for _, item := range list.Items {
+		if item.GetName() != name {
+			continue
+		}
+		existing[item.GetNamespace()+"/"+item.GetName()] = item
+	}
+	for _, report := range reports {
+		key := report.GetNamespace() + "/" + report.GetName()
+		if current, ok := existing[key]; ok {
+			report.SetResourceVersion(current.GetResourceVersion())
+			if _, err := namespacedResource(resource, report.GetNamespace()).Update(report, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			delete(existing, key)
+			continue
+		}
+		if _, err := namespacedResource(resource, report.GetNamespace()).Create(report, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		k.Logger.Debugf("Created %s %s", report.GetKind(), key)
+	}
+	for key, item := range existing {
+		if err := namespacedResource(resource, item.GetNamespace()).Delete(item.GetName(), &metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		k.Logger.Debugf("Deleted %s %s without results", item.GetKind(), key)
+	}
+	return nil
+}
+
+func namespacedResource(resource dynamic.NamespaceableResourceInterface, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return resource
+	}
+	return resource.Namespace(namespace)
+}
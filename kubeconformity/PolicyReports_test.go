@@ -0,0 +1,177 @@
+package kubeconformity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var policyReportConfig = config.Config{
+	PolicyReports: config.PolicyReportConfig{Enabled: true, Name: "kube-conformity"},
+	PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+		{Name: "app label", Labels: []string{"app"}, Severity: rules.SeverityHigh},
+	},
+}
+
+// setupPolicyReports returns a kube conformity with a dynamic client that
+// holds the existing policy reports.
+func setupPolicyReports(t *testing.T, pods []v1.Pod, kubeConfig config.Config, objects ...runtime.Object) *KubeConformity {
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+	scheme := runtime.NewScheme()
+	for _, kind := range []string{"PolicyReportList", "ClusterPolicyReportList"} {
+		scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: kind}, &unstructured.UnstructuredList{})
+	}
+	kubeConformity.DynamicClient = dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+	kubeConformity.now = func() time.Time { return time.Unix(1560600000, 0) }
+	return kubeConformity
+}
+
+func newPolicyReport(namespace, name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "wgpolicyk8s.io/v1alpha2",
+		"kind":       "PolicyReport",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+			"labels":    labels,
+		},
+	}}
+}
+
+func listPolicyReports(t *testing.T, kubeConformity *KubeConformity, gvr schema.GroupVersionResource) []unstructured.Unstructured {
+	list, err := kubeConformity.DynamicClient.Resource(gvr).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list.Items
+}
+
+func TestKubeConformity_WritePolicyReports(t *testing.T) {
+	pods := []v1.Pod{
+		newPodWithLabels("default", "foo", "uid1", []string{}),
+		newPodWithLabels("default", "bar", "uid2", []string{"app"}),
+		newPodWithLabels("payments", "api", "uid3", []string{}),
+	}
+	kubeConformity := setupPolicyReports(t, pods, policyReportConfig)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	reports := listPolicyReports(t, kubeConformity, policyReportsResource)
+	if assert.Len(t, reports, 2) {
+		report := reports[0]
+		if report.GetNamespace() != "default" {
+			report = reports[1]
+		}
+		assert.Equal(t, "kube-conformity", report.GetName())
+		assert.Equal(t, map[string]string{"app.kubernetes.io/managed-by": "kube-conformity"}, report.GetLabels())
+		assert.Equal(t, map[string]interface{}{"pass": int64(0), "fail": int64(1), "warn": int64(0), "error": int64(0), "skip": int64(0)}, report.Object["summary"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"policy":    "app label",
+			"rule":      "pod_rules_labels_filled_in",
+			"message":   "Labels: [app] are not filled in",
+			"result":    "fail",
+			"severity":  "high",
+			"source":    "kube-conformity",
+			"scored":    true,
+			"timestamp": map[string]interface{}{"seconds": int64(1560600000), "nanos": int64(0)},
+			"resources": []interface{}{map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"namespace":  "default",
+				"name":       "foo",
+				"uid":        "uid1",
+			}},
+		}}, report.Object["results"])
+	}
+	assert.Empty(t, listPolicyReports(t, kubeConformity, clusterPolicyReportsResource))
+}
+
+func TestKubeConformity_WritePolicyReports_UpdatedAndPruned(t *testing.T) {
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	managed := map[string]interface{}{"app.kubernetes.io/managed-by": "kube-conformity"}
+	kubeConformity := setupPolicyReports(t, pods, policyReportConfig,
+		newPolicyReport("default", "kube-conformity", managed),
+		newPolicyReport("payments", "kube-conformity", managed),
+		newPolicyReport("payments", "kyverno", nil),
+		newPolicyReport("payments", "kube-conformity-staging", managed),
+	)
+
+	podRuleResults, err := kubeConformity.EvaluatePodRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = kubeConformity.WritePolicyReports(Results{PodRuleResults: podRuleResults})
+
+	assert.Nil(t, err)
+	reports := listPolicyReports(t, kubeConformity, policyReportsResource)
+	var names []string
+	for _, report := range reports {
+		names = append(names, report.GetNamespace()+"/"+report.GetName())
+		if report.GetNamespace() == "default" {
+			assert.Len(t, report.Object["results"], 1)
+		}
+	}
+	assert.ElementsMatch(t, []string{"default/kube-conformity", "payments/kyverno", "payments/kube-conformity-staging"}, names)
+}
+
+func TestKubeConformity_WritePolicyReports_ExemptedAndClusterScoped(t *testing.T) {
+	until := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": "payments", "uid": "uid2"},
+	}}
+	results := Results{
+		ExemptedResults: []rules.ExemptedResult{{
+			Kind: "Pod", Object: &pod, Reason: "Labels: [app] are not filled in", RuleName: "app label", Severity: rules.SeverityHigh,
+			Exemption: rules.Exemption{Until: &until, Reason: "Migrating", Ticket: "SEC-1"},
+		}},
+		ResourceRuleResults: []rules.ObjectRuleResult{{
+			Kind: "Namespace", Objects: []metav1.Object{namespace}, Reason: "Labels: [team] are not filled in", RuleName: "team label",
+		}},
+	}
+	kubeConformity := setupPolicyReports(t, nil, policyReportConfig)
+
+	err := kubeConformity.WritePolicyReports(results)
+
+	assert.Nil(t, err)
+	reports := listPolicyReports(t, kubeConformity, policyReportsResource)
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, int64(1), reports[0].Object["summary"].(map[string]interface{})["skip"])
+		result := reports[0].Object["results"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "skip", result["result"])
+		assert.Equal(t, map[string]interface{}{"exemptedUntil": "2019-07-01T00:00:00Z", "exemptionReason": "Migrating", "exemptionTicket": "SEC-1"}, result["properties"])
+	}
+	clusterReports := listPolicyReports(t, kubeConformity, clusterPolicyReportsResource)
+	if assert.Len(t, clusterReports, 1) {
+		assert.Equal(t, "ClusterPolicyReport", clusterReports[0].GetKind())
+		assert.Equal(t, "", clusterReports[0].GetNamespace())
+		result := clusterReports[0].Object["results"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "medium", result["severity"])
+		assert.Equal(t, "Namespace", result["resources"].([]interface{})[0].(map[string]interface{})["kind"])
+	}
+}
+
+func TestKubeConformity_WritePolicyReports_Disabled(t *testing.T) {
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConfig := policyReportConfig
+	kubeConfig.PolicyReports.Enabled = false
+	kubeConformity := setupPolicyReports(t, pods, kubeConfig)
+
+	_, err := kubeConformity.Run()
+
+	assert.Nil(t, err)
+	assert.Empty(t, listPolicyReports(t, kubeConformity, policyReportsResource))
+}
@@ -0,0 +1,89 @@
+package kubeconformity
+
+import (
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Report returns the report of the results of a run.
+func (k *KubeConformity) Report(results Results) reports.Report {
+	return NewReport(results, k.ruleInfos(results))
+}
+
+// ruleInfos returns the rules the results were evaluated with, or the rules
+// of the config for results that do not hold them.
+func (k *KubeConformity) ruleInfos(results Results) []config.RuleInfo {
+	if results.Rules != nil {
+		return results.Rules
+	}
+	return k.KubeConformityConfig.RuleInfos()
+}
+
+func NewReport(results Results, ruleInfos []config.RuleInfo) reports.Report {
+	report := reports.Report{
+		Summary:            reports.Summary{Rules: len(ruleInfos), ViolationsBySeverity: make(map[string]int)},
+		Rules:              []reports.Rule{},
+		Violations:         []reports.Entry{},
+		Exempted:           []reports.Entry{},
+		ExpiringExceptions: newReportExceptions(results.ExpiringExceptions),
+		ExpiredExceptions:  newReportExceptions(results.ExpiredExceptions),
+		StaleExceptions:    newReportExceptions(results.StaleExceptions),
+	}
+	for _, ruleInfo := range ruleInfos {
+		report.Rules = append(report.Rules, reports.Rule{
+			Name:     ruleInfo.Name,
+			Type:     ruleInfo.Type,
+			Kind:     ruleInfo.Kind,
+			Severity: string(ruleInfo.Severity.OrDefault()),
+		})
+	}
+	for _, violation := range results.violations() {
+		addViolation(&report, violation.RuleName, violation.Severity, violation.Kind, violation.Object, violation.Reason)
+	}
+	for _, exempted := range results.ExemptedResults {
+		entry := newReportEntry(exempted.RuleName, exempted.Severity, exempted.Kind, exempted.Object, exempted.Reason)
+		if exempted.Exemption.Until != nil {
+			entry.ExemptedUntil = exempted.Exemption.Until.Format(time.RFC3339)
+		}
+		entry.ExemptionReason = exempted.Exemption.Reason
+		entry.ExemptionOwner = exempted.Exemption.Owner
+		entry.ExemptionTicket = exempted.Exemption.Ticket
+		report.Exempted = append(report.Exempted, entry)
+	}
+	report.Summary.Exempted = len(report.Exempted)
+	return report
+}
+
+func addViolation(report *reports.Report, ruleName string, severity rules.Severity, kind string, object metav1.Object, reason string) {
+	report.Violations = append(report.Violations, newReportEntry(ruleName, severity, kind, object, reason))
+	report.Summary.ViolationsBySeverity[string(severity.OrDefault())]++
+	report.Summary.Violations++
+}
+
+func newReportEntry(ruleName string, severity rules.Severity, kind string, object metav1.Object, reason string) reports.Entry {
+	return reports.Entry{
+		RuleName:  ruleName,
+		Severity:  string(severity.OrDefault()),
+		Kind:      kind,
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+		Reason:    reason,
+	}
+}
+
+func newReportExceptions(exceptions []rules.Exception) []reports.Exception {
+	reportExceptions := []reports.Exception{}
+	for _, exception := range exceptions {
+		reportExceptions = append(reportExceptions, reports.Exception{
+			Owner:   exception.Owner,
+			Ticket:  exception.Ticket,
+			Rules:   exception.Rules,
+			Expires: exception.Expires,
+		})
+	}
+	return reportExceptions
+}
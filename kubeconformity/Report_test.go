@@ -0,0 +1,107 @@
+package kubeconformity
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newReportResults() Results {
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	deployment := newDeployment("testing", "bar", "uid2", 1)
+	until := time.Date(2019, 6, 30, 0, 0, 0, 0, time.UTC)
+	return Results{
+		PodRuleResults: []rules.PodRuleResult{
+			{RuleName: "labels", Severity: rules.SeverityHigh, Reason: "Labels: [app] are not filled in", Pods: []v1.Pod{pod}},
+		},
+		DeploymentRuleResults: []rules.DeploymentRuleResult{
+			{RuleName: "replicas", Reason: "Deployment replicas below the minimum: 2", Deployments: []appsv1.Deployment{deployment}},
+		},
+		FieldRuleResults: []rules.ObjectRuleResult{
+			{RuleName: "priority", Kind: "Pod", Severity: rules.SeverityHigh, Reason: "Field spec.priorityClassName is not present", Objects: []metav1.Object{&pod}},
+		},
+		ExemptedResults: []rules.ExemptedResult{
+			{
+				RuleName: "labels",
+				Kind:     "Pod",
+				Severity: rules.SeverityHigh,
+				Object:   &pod,
+				Reason:   "Labels: [app] are not filled in",
+				Exemption: rules.Exemption{
+					Until:  &until,
+					Reason: "Migrating",
+					Owner:  "platform-team",
+					Ticket: "SEC-1",
+				},
+			},
+		},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	report := NewReport(newReportResults(), []config.RuleInfo{
+		{Type: "pod_rules_labels_filled_in", Name: "labels", Kind: "Pod", Severity: rules.SeverityHigh},
+		{Type: "pod_rules_limits_filled_in", Name: "limits", Kind: "Pod"},
+	})
+
+	assert.Equal(t, 2, report.Summary.Rules)
+	assert.Equal(t, reports.Rule{Name: "limits", Type: "pod_rules_limits_filled_in", Kind: "Pod", Severity: "medium"}, report.Rules[1])
+	assert.Equal(t, 3, report.Summary.Violations)
+	assert.Equal(t, 1, report.Summary.Exempted)
+	assert.Equal(t, map[string]int{"high": 2, "medium": 1}, report.Summary.ViolationsBySeverity)
+	assert.Equal(t, reports.Entry{
+		RuleName:  "replicas",
+		Severity:  "medium",
+		Kind:      "Deployment",
+		Namespace: "testing",
+		Name:      "bar",
+		Reason:    "Deployment replicas below the minimum: 2",
+	}, report.Violations[1])
+	assert.Equal(t, "2019-06-30T00:00:00Z", report.Exempted[0].ExemptedUntil)
+	assert.Equal(t, "SEC-1", report.Exempted[0].ExemptionTicket)
+}
+
+func TestNewReport_Empty(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := reports.JSONFormatter{}.Format(NewReport(Results{}, nil), buffer)
+
+	assert.Nil(t, err)
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, []interface{}{}, decoded["violations"])
+	assert.Equal(t, []interface{}{}, decoded["staleExceptions"])
+	assert.Equal(t, []interface{}{}, decoded["rules"])
+}
+
+func TestKubeConformity_WriteOutputs(t *testing.T) {
+	destination, err := ioutil.TempFile("", "report")
+	assert.Nil(t, err)
+	defer os.Remove(destination.Name())
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}},
+		},
+		Outputs: []reports.Output{{Format: "csv", Destination: destination.Name()}},
+	}
+	pods := []v1.Pod{newPodWithLabels("default", "foo", "uid1", []string{})}
+	kubeConformity := setup(t, pods, nil, nil, kubeConfig)
+
+	_, err = kubeConformity.Run()
+
+	assert.Nil(t, err)
+	content, err := ioutil.ReadFile(destination.Name())
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "violation,labels,medium,Pod,default,foo,Labels: [app] are not filled in")
+}
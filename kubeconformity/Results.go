@@ -0,0 +1,196 @@
+package kubeconformity
+
+import (
+	"github.com/stijndehaes/kube-conformity/config"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Results holds the outcome of evaluating every rule. Objects that are exempted
+// from a rule through annotations are moved from the rule results to the
+// exempted results. Rules are the rules the results were evaluated with.
+type Results struct {
+	Rules                  []config.RuleInfo
+	PodRuleResults         []rules.PodRuleResult
+	DeploymentRuleResults  []rules.DeploymentRuleResult
+	StatefulSetRuleResults []rules.StatefulSetRuleResult
+	RegoRuleResults        []rules.ObjectRuleResult
+	ResourceRuleResults    []rules.ObjectRuleResult
+	FieldRuleResults       []rules.ObjectRuleResult
+	ExemptedResults        []rules.ExemptedResult
+	ExpiringExceptions     []rules.Exception
+	ExpiredExceptions      []rules.Exception
+	StaleExceptions        []rules.Exception
+}
+
+func (r *Results) sort() {
+	rules.SortPodRuleResults(r.PodRuleResults)
+	rules.SortDeploymentRuleResults(r.DeploymentRuleResults)
+	rules.SortStatefulSetRuleResults(r.StatefulSetRuleResults)
+	rules.SortObjectRuleResults(r.RegoRuleResults)
+	rules.SortObjectRuleResults(r.ResourceRuleResults)
+	rules.SortObjectRuleResults(r.FieldRuleResults)
+}
+
+// AtLeast returns the results of the rules with the threshold severity or a
+// higher one.
+func (r Results) AtLeast(threshold rules.Severity) Results {
+	filtered := r
+	filtered.PodRuleResults = nil
+	for _, result := range r.PodRuleResults {
+		if result.Severity.AtLeast(threshold) {
+			filtered.PodRuleResults = append(filtered.PodRuleResults, result)
+		}
+	}
+	filtered.DeploymentRuleResults = nil
+	for _, result := range r.DeploymentRuleResults {
+		if result.Severity.AtLeast(threshold) {
+			filtered.DeploymentRuleResults = append(filtered.DeploymentRuleResults, result)
+		}
+	}
+	filtered.StatefulSetRuleResults = nil
+	for _, result := range r.StatefulSetRuleResults {
+		if result.Severity.AtLeast(threshold) {
+			filtered.StatefulSetRuleResults = append(filtered.StatefulSetRuleResults, result)
+		}
+	}
+	filtered.RegoRuleResults = objectRuleResultsAtLeast(r.RegoRuleResults, threshold)
+	filtered.ResourceRuleResults = objectRuleResultsAtLeast(r.ResourceRuleResults, threshold)
+	filtered.FieldRuleResults = objectRuleResultsAtLeast(r.FieldRuleResults, threshold)
+	filtered.ExemptedResults = nil
+	for _, result := range r.ExemptedResults {
+		if result.Severity.AtLeast(threshold) {
+			filtered.ExemptedResults = append(filtered.ExemptedResults, result)
+		}
+	}
+	return filtered
+}
+
+func objectRuleResultsAtLeast(results []rules.ObjectRuleResult, threshold rules.Severity) []rules.ObjectRuleResult {
+	var filtered []rules.ObjectRuleResult
+	for _, result := range results {
+		if result.Severity.AtLeast(threshold) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// violation is a single object violating a rule.
+type violation struct {
+	RuleName string
+	Severity rules.Severity
+	Kind     string
+	Reason   string
+	Object   metav1.Object
+}
+
+// violations returns every object violating a rule, in the order of the rule
+// results.
+func (r Results) violations() []violation {
+	var violations []violation
+	for _, result := range r.PodRuleResults {
+		for idx := range result.Pods {
+			violations = append(violations, violation{result.RuleName, result.Severity, "Pod", result.Reason, &result.Pods[idx]})
+		}
+	}
+	for _, result := range r.DeploymentRuleResults {
+		for idx := range result.Deployments {
+			violations = append(violations, violation{result.RuleName, result.Severity, "Deployment", result.Reason, &result.Deployments[idx]})
+		}
+	}
+	for _, result := range r.StatefulSetRuleResults {
+		for idx := range result.StatefulSets {
+			violations = append(violations, violation{result.RuleName, result.Severity, "StatefulSet", result.Reason, &result.StatefulSets[idx]})
+		}
+	}
+	for _, objectResults := range [][]rules.ObjectRuleResult{r.RegoRuleResults, r.ResourceRuleResults, r.FieldRuleResults} {
+		for _, result := range objectResults {
+			for _, object := range result.Objects {
+				violations = append(violations, violation{result.RuleName, result.Severity, result.Kind, result.Reason, object})
+			}
+		}
+	}
+	return violations
+}
+
+// Violations returns the number of objects violating a rule, exempted objects
+// are not counted.
+func (r Results) Violations() int {
+	return len(r.violations())
+}
+
+// Select returns the results with only the violating and exempted objects the
+// function keeps, rule results without objects left are dropped.
+func (r Results) Select(keep func(reports.Entry) bool) Results {
+	selected := r
+	selected.PodRuleResults = nil
+	for _, result := range r.PodRuleResults {
+		var pods []v1.Pod
+		for idx := range result.Pods {
+			if keep(newReportEntry(result.RuleName, result.Severity, "Pod", &result.Pods[idx], result.Reason)) {
+				pods = append(pods, result.Pods[idx])
+			}
+		}
+		if len(pods) > 0 {
+			result.Pods = pods
+			selected.PodRuleResults = append(selected.PodRuleResults, result)
+		}
+	}
+	selected.DeploymentRuleResults = nil
+	for _, result := range r.DeploymentRuleResults {
+		var deployments []appsv1.Deployment
+		for idx := range result.Deployments {
+			if keep(newReportEntry(result.RuleName, result.Severity, "Deployment", &result.Deployments[idx], result.Reason)) {
+				deployments = append(deployments, result.Deployments[idx])
+			}
+		}
+		if len(deployments) > 0 {
+			result.Deployments = deployments
+			selected.DeploymentRuleResults = append(selected.DeploymentRuleResults, result)
+		}
+	}
+	selected.StatefulSetRuleResults = nil
+	for _, result := range r.StatefulSetRuleResults {
+		var statefulSets []appsv1.StatefulSet
+		for idx := range result.StatefulSets {
+			if keep(newReportEntry(result.RuleName, result.Severity, "StatefulSet", &result.StatefulSets[idx], result.Reason)) {
+				statefulSets = append(statefulSets, result.StatefulSets[idx])
+			}
+		}
+		if len(statefulSets) > 0 {
+			result.StatefulSets = statefulSets
+			selected.StatefulSetRuleResults = append(selected.StatefulSetRuleResults, result)
+		}
+	}
+	selected.RegoRuleResults = selectObjectRuleResults(r.RegoRuleResults, keep)
+	selected.ResourceRuleResults = selectObjectRuleResults(r.ResourceRuleResults, keep)
+	selected.FieldRuleResults = selectObjectRuleResults(r.FieldRuleResults, keep)
+	selected.ExemptedResults = nil
+	for _, result := range r.ExemptedResults {
+		if keep(newReportEntry(result.RuleName, result.Severity, result.Kind, result.Object, result.Reason)) {
+			selected.ExemptedResults = append(selected.ExemptedResults, result)
+		}
+	}
+	return selected
+}
+
+func selectObjectRuleResults(results []rules.ObjectRuleResult, keep func(reports.Entry) bool) []rules.ObjectRuleResult {
+	var selected []rules.ObjectRuleResult
+	for _, result := range results {
+		var objects []metav1.Object
+		for _, object := range result.Objects {
+			if keep(newReportEntry(result.RuleName, result.Severity, result.Kind, object, result.Reason)) {
+				objects = append(objects, object)
+			}
+		}
+		if len(objects) > 0 {
+			result.Objects = objects
+			selected = append(selected, result)
+		}
+	}
+	return selected
+}
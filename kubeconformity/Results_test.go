@@ -0,0 +1,63 @@
+package kubeconformity
+
+import (
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResults_AtLeast(t *testing.T) {
+	pod := newPodWithLabels("default", "foo", "uid1", []string{})
+	results := Results{
+		PodRuleResults: []rules.PodRuleResult{
+			{RuleName: "critical", Severity: rules.SeverityCritical, Pods: []v1.Pod{pod}},
+			{RuleName: "low", Severity: rules.SeverityLow, Pods: []v1.Pod{pod}},
+		},
+		FieldRuleResults: []rules.ObjectRuleResult{
+			{RuleName: "medium", Severity: rules.SeverityMedium, Objects: []metav1.Object{&pod}},
+		},
+		ExemptedResults: []rules.ExemptedResult{
+			{RuleName: "low", Severity: rules.SeverityLow, Object: &pod},
+		},
+	}
+
+	assert.Equal(t, 3, results.Violations())
+	assert.Equal(t, 2, results.AtLeast(rules.SeverityMedium).Violations())
+	assert.Equal(t, 1, results.AtLeast(rules.SeverityHigh).Violations())
+	assert.Empty(t, results.AtLeast(rules.SeverityMedium).ExemptedResults)
+	assert.Len(t, results.AtLeast(rules.SeverityInfo).ExemptedResults, 1)
+}
+
+func TestResults_Select(t *testing.T) {
+	foo := newPodWithLabels("default", "foo", "uid1", []string{})
+	bar := newPodWithLabels("payments", "bar", "uid2", []string{})
+	deployment := newDeployment("default", "api", "uid3", 1)
+	results := Results{
+		PodRuleResults: []rules.PodRuleResult{
+			{RuleName: "labels", Severity: rules.SeverityHigh, Pods: []v1.Pod{foo, bar}},
+		},
+		DeploymentRuleResults: []rules.DeploymentRuleResult{
+			{RuleName: "replicas", Severity: rules.SeverityLow, Deployments: []appsv1.Deployment{deployment}},
+		},
+		FieldRuleResults: []rules.ObjectRuleResult{
+			{RuleName: "field", Kind: "Pod", Severity: rules.SeverityMedium, Objects: []metav1.Object{&foo, &bar}},
+		},
+		ExemptedResults: []rules.ExemptedResult{
+			{RuleName: "labels", Kind: "Pod", Severity: rules.SeverityHigh, Object: &foo},
+		},
+	}
+
+	selected := results.Select(func(entry reports.Entry) bool { return entry.Namespace == "payments" })
+
+	assert.Equal(t, 2, selected.Violations())
+	assert.Equal(t, []v1.Pod{bar}, selected.PodRuleResults[0].Pods)
+	assert.Empty(t, selected.DeploymentRuleResults)
+	assert.Equal(t, []metav1.Object{&bar}, selected.FieldRuleResults[0].Objects)
+	assert.Empty(t, selected.ExemptedResults)
+	assert.Equal(t, 5, results.Violations())
+}
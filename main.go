@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/stijndehaes/kube-conformity/config"
+	"io"
 	"os"
 	"time"
 
@@ -12,43 +13,67 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
 
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/stijndehaes/kube-conformity/api"
+	"github.com/stijndehaes/kube-conformity/dashboard"
+	"github.com/stijndehaes/kube-conformity/history"
 	"github.com/stijndehaes/kube-conformity/kubeconformity"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 )
 
 var (
-	master            = *kingpin.Flag("master", "The address of the Kubernetes cluster to target").String()
-	kubeConfig        = *kingpin.Flag("kube-config", "Path to a kubeConfig file").String()
-	debug             = *kingpin.Flag("debug", "Enable debug logging.").Bool()
-	configLocation    = *kingpin.Flag("config-location", "The location of the config.yaml").Default("config.yaml").String()
-	jsonLogging       = *kingpin.Flag("json-logging", "Enable json logging.").Bool()
-	prometheusEnabled = *kingpin.Flag("prometheus-enabled", "Enable prometheus metrics").Default("true").Bool()
-	PrometheusAddr    = *kingpin.Flag("prometheus-addr", "Prometheus metrics addr").Default(":8000").String()
+	master             = kingpin.Flag("master", "The address of the Kubernetes cluster to target").String()
+	kubeConfig         = kingpin.Flag("kube-config", "Path to a kubeConfig file").String()
+	debug              = kingpin.Flag("debug", "Enable debug logging.").Bool()
+	configLocation     = kingpin.Flag("config-location", "The location of the config.yaml").Default("config.yaml").String()
+	exceptionsLocation = kingpin.Flag("exceptions-location", "The location of the exceptions.yaml, no exceptions are applied when empty").String()
+	jsonLogging        = kingpin.Flag("json-logging", "Enable json logging.").Bool()
+	failOn             = kingpin.Flag("fail-on", "Report a failure when a rule with this severity or a higher one is violated, one of info, low, medium, high or critical").Enum("info", "low", "medium", "high", "critical")
+	once               = kingpin.Flag("once", "Evaluate the rules once, write a report and exit with status 1 when rules are violated").Bool()
+	reportLocation     = kingpin.Flag("report-location", "The location to write the report to in once mode, default stdout").String()
+	outputs            = kingpin.Flag("output", "Write a report every run as format or format:path, with format one of csv, json, junit, sarif or yaml, can be repeated and replaces the outputs of the config").Strings()
+	historyLocation    = kingpin.Flag("history-location", "The location of the file the results of every run are stored in, no history is kept when empty").String()
+	historyRetention   = kingpin.Flag("history-retention", "How long the results of a run are kept in the history").Default(history.DefaultRetention.String()).Duration()
+	reloadInterval     = kingpin.Flag("config-reload-interval", "How often the config file is checked for changes, 0 only reloads on SIGHUP and POST /-/reload").Default("10s").Duration()
+	conformityRules    = kingpin.Flag("conformity-rules", "Watch ConformityRule and ClusterConformityRule objects and evaluate their rules next to the ones of the config").Bool()
+	prometheusEnabled  = kingpin.Flag("prometheus-enabled", "Enable prometheus metrics").Default("true").Bool()
+	PrometheusAddr     = kingpin.Flag("prometheus-addr", "Prometheus metrics addr").Default(":8000").String()
+
+	runCommand        = kingpin.Command("run", "Evaluate the rules every interval, the default command").Default()
+	validateCommand   = kingpin.Command("validate", "Validate config files, every problem is printed with its line and column and the exit status is 1 when there are problems")
+	validateLocations = validateCommand.Arg("config", "The config files to validate, default the config location").Strings()
 )
 
-func defaultPageHandler(config config.Config) func(w http.ResponseWriter, r *http.Request) {
-	configByte, err := yaml.Marshal(&config)
-	if err != nil {
-		log.Fatal(err)
-	}
+func configHandler(currentConfig func() config.Config) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(`<html>
-					<head><title>Kube conformity</title></head>
-					<body>
-					<h1>Kube conformity</h1>
-					<p><a href="/metrics">Metrics</a></p>
-					<p><a href="/healthz">Health Check</a></p>
-					<h2>Configuration</h2>
-					<p style='white-space: pre-wrap;'>`))
+		kubeConfig := currentConfig()
+		configByte, err := yaml.Marshal(&kubeConfig)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
 		w.Write(configByte)
-		w.Write([]byte(`</p>
-						</body>
-					    </html>`))
+	}
+}
+
+func filtersHandler(currentConfig func() config.Config) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filtersByte, err := yaml.Marshal(currentConfig().RuleFilters())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(filtersByte)
 	}
 }
 
@@ -56,13 +81,17 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "OK")
 }
 
-func configurePrometheus(config config.Config) {
-	log.Info("Prometheus enabled will run it on addr: ", PrometheusAddr)
+func configurePrometheus(reloader *config.Reloader, resultsAPI *api.API, resultsDashboard *dashboard.Dashboard) {
+	log.Info("Prometheus enabled will run it on addr: ", *PrometheusAddr)
 	http.Handle("/metrics", promhttp.Handler())
+	resultsAPI.Register(http.DefaultServeMux)
+	resultsDashboard.Register(http.DefaultServeMux)
 	http.HandleFunc("/healthz", healthHandler)
-	http.HandleFunc("/", defaultPageHandler(config))
+	http.HandleFunc("/filters", filtersHandler(reloader.Config))
+	http.HandleFunc("/config", configHandler(reloader.Config))
+	http.Handle("/-/reload", reloader)
 	go func() {
-		if err := http.ListenAndServe(PrometheusAddr, nil); err != nil {
+		if err := http.ListenAndServe(*PrometheusAddr, nil); err != nil {
 			log.WithFields(log.Fields{
 				"err": err,
 			}).Fatal("failed to start HTTP server")
@@ -71,46 +100,177 @@ func configurePrometheus(config config.Config) {
 }
 
 func main() {
-	kingpin.Parse()
-	client, err := newClient()
+	if kingpin.Parse() == validateCommand.FullCommand() {
+		os.Exit(validateConfigs(*validateLocations, os.Stdout))
+	}
+	client, dynamicClient, err := newClient()
 	if err != nil {
 		log.Fatal(err)
 	}
 	ConfigureLogging()
-	config, err := ConstructConfig()
+	conformityConfig, err := ConstructConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if prometheusEnabled {
-		configurePrometheus(config)
+	exceptions, err := ConstructExceptions()
+	if err != nil {
+		log.Fatal(err)
 	}
+	logEffectiveFilters(conformityConfig)
 
 	kubeConformity := kubeconformity.New(
 		client,
+		dynamicClient,
 		log.StandardLogger(),
-		config,
+		conformityConfig,
 	)
+	kubeConformity.Exceptions = exceptions
+	if *conformityRules {
+		kubeConformity.ConformityRules = kubeconformity.NewConformityRules(dynamicClient, client.Discovery(), log.StandardLogger())
+		if err := kubeConformity.ConformityRules.Start(wait.NeverStop); err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Loaded %d conformity rules", len(kubeConformity.ConformityRules.RuleSets()))
+	}
 
-	for {
-		err := kubeConformity.LogNonConforming()
+	if *once {
+		status := runOnce(kubeConformity)
+		kubeConformity.Shutdown()
+		os.Exit(status)
+	}
+
+	resultsAPI := api.New()
+	resultsDashboard := dashboard.New()
+	var store *history.Store
+	if *historyLocation != "" {
+		store, err = openHistory(resultsDashboard)
 		if err != nil {
 			log.Fatal(err)
 		}
+		defer store.Close()
+	}
+	reloader := config.NewReloader(*configLocation, conformityConfig, ParseConfig, log.StandardLogger())
+	go reloader.Watch(*reloadInterval, wait.NeverStop)
+	if *prometheusEnabled {
+		configurePrometheus(reloader, resultsAPI, resultsDashboard)
+	}
+
+	for {
+		run(kubeConformity, resultsAPI, resultsDashboard, store)
 
 		log.Debugf("Sleeping for %s...", kubeConformity.KubeConformityConfig.Interval)
-		time.Sleep(kubeConformity.KubeConformityConfig.Interval)
+		select {
+		case <-time.After(kubeConformity.KubeConformityConfig.Interval):
+		case <-resultsAPI.Evaluate():
+			log.Info("Evaluation requested through the API")
+		case reloaded := <-reloader.Reloaded():
+			kubeConformity.SetConfig(reloaded)
+			logEffectiveFilters(reloaded)
+			log.Info("Evaluating with the reloaded config")
+		}
+	}
+}
+
+// run runs kube-conformity and publishes the results, a run that fails is
+// logged and the next one is tried after the interval.
+func run(kubeConformity *kubeconformity.KubeConformity, resultsAPI *api.API, resultsDashboard *dashboard.Dashboard, store *history.Store) {
+	results, err := kubeConformity.Run()
+	if err != nil {
+		log.Errorf("Run failed: %v", err)
+		return
+	}
+	if failed(results) {
+		log.Errorf("Found %d violations of rules with severity %s or higher", results.AtLeast(rules.Severity(*failOn)).Violations(), *failOn)
+	}
+
+	report, evaluatedAt := kubeConformity.Report(results), time.Now()
+	resultsAPI.Update(report, evaluatedAt)
+	resultsDashboard.Update(report, evaluatedAt)
+	if store != nil {
+		diff, err := store.Record(report, evaluatedAt)
+		if err != nil {
+			log.Errorf("Storing the results in the history: %v", err)
+		} else {
+			logDiff(diff, evaluatedAt)
+		}
+	}
+}
+
+// openHistory opens the history store and shows the runs it holds on the
+// dashboard.
+func openHistory(resultsDashboard *dashboard.Dashboard) (*history.Store, error) {
+	store, err := history.Open(*historyLocation, *historyRetention)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := store.Runs(time.Now().Add(-*historyRetention))
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	for _, run := range runs {
+		resultsDashboard.Update(run.Report, run.Time)
 	}
+	log.Infof("Loaded %d runs from the history at %s", len(runs), *historyLocation)
+	return store, nil
+}
+
+func logDiff(diff history.Diff, now time.Time) {
+	for _, violation := range diff.Resolved {
+		log.WithFields(log.Fields{
+			"rule":        violation.Entry.RuleName,
+			"kind":        violation.Entry.Kind,
+			"namespace":   violation.Entry.Namespace,
+			"name":        violation.Entry.Name,
+			"time_to_fix": violation.TimeToFix(now).String(),
+		}).Info("Violation resolved")
+	}
+	log.WithFields(log.Fields{
+		"new":      len(diff.New),
+		"resolved": len(diff.Resolved),
+		"open":     len(diff.Open),
+	}).Info("Compared the violations with the previous run")
+}
+
+// runOnce evaluates the rules a single time and returns the exit status: 0
+// when no rules are violated, 1 when they are and 2 when the run failed. With
+// fail-on only violations of rules at or above that severity count. Without
+// outputs a JSON report is written to the report location.
+func runOnce(kubeConformity *kubeconformity.KubeConformity) int {
+	if len(kubeConformity.KubeConformityConfig.Outputs) == 0 {
+		kubeConformity.KubeConformityConfig.Outputs = []reports.Output{{Format: "json", Destination: *reportLocation}}
+	}
+	results, err := kubeConformity.Run()
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	threshold := rules.SeverityInfo
+	if *failOn != "" {
+		threshold = rules.Severity(*failOn)
+	}
+	if violations := results.AtLeast(threshold).Violations(); violations > 0 {
+		log.Infof("Found %d violations of rules with severity %s or higher", violations, threshold)
+		return 1
+	}
+	return 0
+}
+
+// failed reports whether the results hold violations at or above the fail-on
+// severity.
+func failed(results kubeconformity.Results) bool {
+	return *failOn != "" && results.AtLeast(rules.Severity(*failOn)).Violations() > 0
 }
 
 func ConfigureLogging() {
-	if jsonLogging {
+	if *jsonLogging {
 		log.SetFormatter(&log.JSONFormatter{})
 		log.Info("Json logging enabled")
 	} else {
 		log.SetFormatter(&log.TextFormatter{})
 	}
-	if debug {
+	if *debug {
 		log.Info("Debug level enabled")
 		log.SetLevel(log.DebugLevel)
 	} else {
@@ -118,31 +278,102 @@ func ConfigureLogging() {
 	}
 }
 
+func logEffectiveFilters(config config.Config) {
+	for _, ruleFilter := range config.RuleFilters() {
+		filterByte, err := yaml.Marshal(ruleFilter.Filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Debugf("Effective filter of %s rule %s:\n%s", ruleFilter.Type, ruleFilter.Name, filterByte)
+	}
+}
+
+// ConstructConfig reads the config file once and loads it the way a reload
+// does, a config with problems fails the startup.
 func ConstructConfig() (config.Config, error) {
-	kubeConformityConfig := config.Config{}
-	yamlFile, err := ioutil.ReadFile(configLocation)
+	yamlFile, err := ioutil.ReadFile(*configLocation)
 	if err != nil {
-		return kubeConformityConfig, err
+		return config.Config{}, err
 	}
-	err = yaml.Unmarshal(yamlFile, &kubeConformityConfig)
+	return config.Load(yamlFile, ParseConfig)
+}
+
+// ParseConfig parses the content of the config file, the outputs flag
+// replaces the outputs of the file.
+func ParseConfig(content []byte) (config.Config, error) {
+	kubeConformityConfig := config.Config{}
+	err := yaml.Unmarshal(content, &kubeConformityConfig)
 	if err != nil {
 		return kubeConformityConfig, err
 	}
+	if len(*outputs) > 0 {
+		kubeConformityConfig.Outputs = nil
+		for _, value := range *outputs {
+			output, err := reports.ParseOutput(value)
+			if err != nil {
+				return kubeConformityConfig, err
+			}
+			kubeConformityConfig.Outputs = append(kubeConformityConfig.Outputs, output)
+		}
+	}
 	return kubeConformityConfig, nil
 }
 
-func newClient() (*kubernetes.Clientset, error) {
-	if _, err := os.Stat(clientcmd.RecommendedHomeFile); kubeConfig == "" && err == nil {
-		kubeConfig = clientcmd.RecommendedHomeFile
+// validateConfigs prints the problems of the config files as
+// file:line:column: path: message and returns the exit status: 0 when the
+// files are valid and 1 when they are not.
+func validateConfigs(locations []string, out io.Writer) int {
+	if len(locations) == 0 {
+		locations = []string{*configLocation}
 	}
-	kConfig, err := clientcmd.BuildConfigFromFlags(master, kubeConfig)
+	status := 0
+	for _, location := range locations {
+		content, err := ioutil.ReadFile(location)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", location, err)
+			status = 1
+			continue
+		}
+		for _, problem := range config.Validate(content) {
+			fmt.Fprintf(out, "%s:%s\n", location, problem)
+			status = 1
+		}
+	}
+	return status
+}
+
+func ConstructExceptions() (rules.Exceptions, error) {
+	exceptions := rules.Exceptions{}
+	if *exceptionsLocation == "" {
+		return exceptions, nil
+	}
+	yamlFile, err := ioutil.ReadFile(*exceptionsLocation)
 	if err != nil {
-		return nil, err
+		return exceptions, err
+	}
+	err = yaml.Unmarshal(yamlFile, &exceptions)
+	if err != nil {
+		return exceptions, err
+	}
+	return exceptions, nil
+}
+
+func newClient() (*kubernetes.Clientset, dynamic.Interface, error) {
+	if _, err := os.Stat(clientcmd.RecommendedHomeFile); *kubeConfig == "" && err == nil {
+		*kubeConfig = clientcmd.RecommendedHomeFile
+	}
+	kConfig, err := clientcmd.BuildConfigFromFlags(*master, *kubeConfig)
+	if err != nil {
+		return nil, nil, err
 	}
 	log.Infof("Targeting cluster at %s", kConfig.Host)
 	client, err := kubernetes.NewForConfig(kConfig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(kConfig)
+	if err != nil {
+		return nil, nil, err
 	}
-	return client, nil
+	return client, dynamicClient, nil
 }
@@ -1,15 +1,33 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 	"net/http/httptest"
 	"testing"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/alecthomas/kingpin.v2"
 	log "github.com/sirupsen/logrus"
+	"github.com/stijndehaes/kube-conformity/api"
+	"github.com/stijndehaes/kube-conformity/dashboard"
+	"github.com/stijndehaes/kube-conformity/history"
+	"github.com/stijndehaes/kube-conformity/kubeconformity"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stijndehaes/kube-conformity/config"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestConstructConfig(t *testing.T) {
-	configLocation = "config.yaml"
+	*configLocation = "config.yaml"
 	config, err := ConstructConfig()
 	assert.Nil(t, err)
 	assert.Len(t, config.PodRulesLabelsFilledIn, 1)
@@ -18,15 +36,15 @@ func TestConstructConfig(t *testing.T) {
 }
 
 func TestConfigureLogging(t *testing.T) {
-	debug = false
-	jsonLogging = false
+	*debug = false
+	*jsonLogging = false
 
 	ConfigureLogging()
 	assert.Equal(t, &log.TextFormatter{},log.StandardLogger().Formatter)
 	assert.Equal(t, log.InfoLevel,log.StandardLogger().Level)
 
-	debug = true
-	jsonLogging = true
+	*debug = true
+	*jsonLogging = true
 
 	ConfigureLogging()
 	assert.Equal(t, &log.JSONFormatter{},log.StandardLogger().Formatter)
@@ -34,25 +52,25 @@ func TestConfigureLogging(t *testing.T) {
 }
 
 func TestConstructConfig_InvalidLocation(t *testing.T) {
-	configLocation = "invalid.yaml"
+	*configLocation = "invalid.yaml"
 	_, err := ConstructConfig()
 	assert.NotNil(t, err)
 }
 
 func Test_configurePrometheus(t *testing.T) {
 	config, _ := ConstructConfig()
-	PrometheusAddr = ":8000"
-	configurePrometheus(config)
+	*PrometheusAddr = ":8000"
+	configurePrometheus(newReloader(config), api.New(), dashboard.New())
 }
 
-func Test_defaultPageHandler(t *testing.T) {
+func Test_configHandler(t *testing.T) {
 	config, _ := ConstructConfig()
-	req, err := http.NewRequest("GET", "/", nil)
+	req, err := http.NewRequest("GET", "/config", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(defaultPageHandler(config))
+	handler := http.HandlerFunc(configHandler(newReloader(config).Config))
 	handler.ServeHTTP(rr, req)
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v",
@@ -60,6 +78,21 @@ func Test_defaultPageHandler(t *testing.T) {
 	}
 }
 
+func Test_configHandler_Reloaded(t *testing.T) {
+	current := config.Config{Interval: time.Hour}
+	handler := http.HandlerFunc(configHandler(func() config.Config { return current }))
+	current.Interval = 5 * time.Minute
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/config", nil))
+
+	assert.Contains(t, rr.Body.String(), "interval: 5m0s")
+}
+
+func newReloader(kubeConfig config.Config) *config.Reloader {
+	return config.NewReloader(*configLocation, kubeConfig, ParseConfig, log.StandardLogger())
+}
+
 
 func Test_healthHandler(t *testing.T) {
 	req, err := http.NewRequest("GET", "/", nil)
@@ -74,3 +107,176 @@ func Test_healthHandler(t *testing.T) {
 			status, http.StatusOK)
 	}
 }
+
+func TestConstructExceptions(t *testing.T) {
+	*exceptionsLocation = "exceptions.yaml"
+	exceptions, err := ConstructExceptions()
+	assert.Nil(t, err)
+	assert.Equal(t, 14, exceptions.WarnDays)
+	assert.Len(t, exceptions.Exceptions, 2)
+}
+
+func TestConstructExceptions_NoLocation(t *testing.T) {
+	*exceptionsLocation = ""
+	exceptions, err := ConstructExceptions()
+	assert.Nil(t, err)
+	assert.Empty(t, exceptions.Exceptions)
+}
+
+func TestFailed(t *testing.T) {
+	results := kubeconformity.Results{
+		PodRuleResults: []rules.PodRuleResult{
+			{RuleName: "labels", Severity: rules.SeverityHigh, Pods: []v1.Pod{{}}},
+		},
+	}
+
+	*failOn = ""
+	assert.False(t, failed(results))
+	*failOn = "high"
+	assert.True(t, failed(results))
+	*failOn = "critical"
+	assert.False(t, failed(results))
+	*failOn = ""
+}
+
+func TestRunOnce(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{
+			{Name: "labels", Labels: []string{"app"}, Severity: rules.SeverityLow},
+		},
+	}
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}})
+	kubeConformity := kubeconformity.New(client, nil, log.StandardLogger(), kubeConfig)
+	reportFile, err := ioutil.TempFile("", "report")
+	assert.Nil(t, err)
+	defer os.Remove(reportFile.Name())
+	*reportLocation = reportFile.Name()
+	defer func() { *reportLocation = "" }()
+
+	*failOn = ""
+	assert.Equal(t, 1, runOnce(kubeConformity))
+	*failOn = "high"
+	assert.Equal(t, 0, runOnce(kubeConformity))
+	*failOn = ""
+
+	report := reports.Report{}
+	content, err := ioutil.ReadFile(reportFile.Name())
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(content, &report))
+	assert.Equal(t, 1, report.Summary.Violations)
+	assert.Equal(t, "foo", report.Violations[0].Name)
+	assert.Equal(t, "low", report.Violations[0].Severity)
+}
+
+func TestConstructConfig_Outputs(t *testing.T) {
+	*configLocation = "config.yaml"
+	*outputs = []string{"junit:report.xml", "json"}
+	defer func() { *outputs = nil }()
+
+	config, err := ConstructConfig()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []reports.Output{{Format: "junit", Destination: "report.xml"}, {Format: "json"}}, config.Outputs)
+
+	*outputs = []string{"html"}
+	_, err = ConstructConfig()
+	assert.NotNil(t, err)
+}
+
+func TestOpenHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	*historyLocation = filepath.Join(dir, "history.db")
+	*historyRetention = history.DefaultRetention
+	store, err := history.Open(*historyLocation, *historyRetention)
+	assert.Nil(t, err)
+	_, err = store.Record(reports.Report{}, time.Now())
+	assert.Nil(t, err)
+	store.Close()
+
+	resultsDashboard := dashboard.New()
+	store, err = openHistory(resultsDashboard)
+
+	assert.Nil(t, err)
+	store.Close()
+	mux := http.NewServeMux()
+	resultsDashboard.Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	*historyLocation = ""
+}
+
+func Test_validateConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	ioutil.WriteFile(invalid, []byte("interval: 1h\nintervall: 2h\n"), 0644)
+	out := &bytes.Buffer{}
+
+	assert.Equal(t, 0, validateConfigs([]string{"config.yaml"}, out))
+	assert.Empty(t, out.String())
+
+	assert.Equal(t, 1, validateConfigs([]string{"config.yaml", invalid, filepath.Join(dir, "missing.yaml")}, out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[0], invalid+":2:1: intervall: unknown key intervall"), lines[0])
+		assert.Contains(t, lines[1], "missing.yaml: open")
+	}
+}
+
+func Test_flags(t *testing.T) {
+	defer kingpin.CommandLine.Parse(nil)
+
+	command, err := kingpin.CommandLine.Parse([]string{"--once", "--fail-on=high", "--output=json", "--history-retention=1h"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, runCommand.FullCommand(), command)
+	assert.True(t, *once)
+	assert.Equal(t, "high", *failOn)
+	assert.Equal(t, []string{"json"}, *outputs)
+	assert.Equal(t, time.Hour, *historyRetention)
+	assert.Equal(t, 10*time.Second, *reloadInterval)
+	assert.Equal(t, "config.yaml", *configLocation)
+	assert.True(t, *prometheusEnabled)
+}
+
+func Test_run_Failed(t *testing.T) {
+	kubeConfig := config.Config{
+		PodRulesLabelsFilledIn: []rules.PodRuleLabelsFilledIn{{Name: "labels", Labels: []string{"app"}}},
+		Outputs:                []reports.Output{{Format: "json", Destination: "/nonexistent/report.json"}},
+	}
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}})
+	kubeConformity := kubeconformity.New(client, nil, log.StandardLogger(), kubeConfig)
+	logOutput := bytes.NewBuffer([]byte{})
+	log.SetOutput(logOutput)
+	defer log.SetOutput(os.Stderr)
+
+	run(kubeConformity, api.New(), dashboard.New(), nil)
+
+	assert.Contains(t, logOutput.String(), "Run failed")
+}
+
+func TestConstructConfig_Problems(t *testing.T) {
+	file, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("interval: 1h\nintervall: 2h\n")
+	file.Close()
+	*configLocation = file.Name()
+	defer func() { *configLocation = "config.yaml" }()
+
+	_, err = ConstructConfig()
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid config: 2:1: intervall: unknown key intervall")
+	}
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/history"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+)
+
+// Notification is what a run notifies about in diff mode, either a digest of
+// all violations or the violations that are new or resolved since the last
+// notification.
+type Notification struct {
+	Digest   bool
+	New      []reports.Entry
+	Resolved []reports.Entry
+}
+
+// Empty reports whether there is nothing to notify about.
+func (n Notification) Empty() bool {
+	return !n.Digest && len(n.New) == 0 && len(n.Resolved) == 0
+}
+
+// Plan compares the violations of a run with the state of the last
+// notification and returns what to notify about, together with the state to
+// save once the notification is sent. A digest is due when the digest
+// interval has passed since the last one, a zero interval never sends one.
+func Plan(state State, violations []reports.Entry, digestInterval time.Duration, now time.Time) (Notification, State) {
+	diff := history.Compare(state.Violations, violations, now)
+	next := State{LastDigest: state.LastDigest, Violations: make(map[string]history.Violation)}
+	for _, open := range [][]history.Violation{diff.New, diff.Open} {
+		for _, violation := range open {
+			next.Violations[history.Key(violation.Entry)] = violation
+		}
+	}
+	if digestInterval > 0 && now.Sub(state.LastDigest) >= digestInterval {
+		next.LastDigest = now
+		return Notification{Digest: true}, next
+	}
+	return Notification{New: entries(diff.New), Resolved: entries(diff.Resolved)}, next
+}
+
+// AtLeast returns the notification with only the violations of the severity
+// or higher.
+func (n Notification) AtLeast(severity rules.Severity) Notification {
+	return n.Filter(func(entry reports.Entry) bool {
+		return rules.Severity(entry.Severity).AtLeast(severity)
+	})
+}
+
+// Filter returns the notification with only the violations the function
+// keeps.
+func (n Notification) Filter(keep func(reports.Entry) bool) Notification {
+	return Notification{Digest: n.Digest, New: filterEntries(n.New, keep), Resolved: filterEntries(n.Resolved, keep)}
+}
+
+func entries(violations []history.Violation) []reports.Entry {
+	var entries []reports.Entry
+	for _, violation := range violations {
+		entries = append(entries, violation.Entry)
+	}
+	return entries
+}
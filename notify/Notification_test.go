@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/history"
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	fooEntry = reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"}
+	barEntry = reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: "bar", Reason: "Labels: [app] are not filled in"}
+	apiEntry = reports.Entry{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "testing", Name: "api", Reason: "Deployment replicas below the minimum: 2"}
+)
+
+func TestPlan_Diff(t *testing.T) {
+	lastDigest := time.Date(2019, 6, 15, 0, 0, 0, 0, time.UTC)
+	firstSeen := time.Date(2019, 6, 15, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	state := State{LastDigest: lastDigest, Violations: map[string]history.Violation{
+		history.Key(fooEntry): {Entry: fooEntry, FirstSeen: firstSeen, LastSeen: firstSeen},
+		history.Key(barEntry): {Entry: barEntry, FirstSeen: firstSeen, LastSeen: firstSeen},
+	}}
+
+	notification, next := Plan(state, []reports.Entry{fooEntry, apiEntry}, 24*time.Hour, now)
+
+	assert.Equal(t, Notification{New: []reports.Entry{apiEntry}, Resolved: []reports.Entry{barEntry}}, notification)
+	assert.Equal(t, lastDigest, next.LastDigest)
+	assert.Len(t, next.Violations, 2)
+	assert.Equal(t, firstSeen, next.Violations[history.Key(fooEntry)].FirstSeen)
+	assert.Equal(t, now, next.Violations[history.Key(apiEntry)].FirstSeen)
+}
+
+func TestPlan_NothingChanged(t *testing.T) {
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+	_, state := Plan(State{}, []reports.Entry{fooEntry}, 0, now)
+
+	notification, _ := Plan(state, []reports.Entry{fooEntry}, 0, now.Add(time.Hour))
+
+	assert.True(t, notification.Empty())
+}
+
+func TestPlan_Digest(t *testing.T) {
+	lastDigest := time.Date(2019, 6, 14, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	notification, next := Plan(State{LastDigest: lastDigest}, []reports.Entry{fooEntry}, 24*time.Hour, now)
+
+	assert.Equal(t, Notification{Digest: true}, notification)
+	assert.False(t, notification.Empty())
+	assert.Equal(t, now, next.LastDigest)
+	assert.Len(t, next.Violations, 1)
+}
+
+func TestPlan_FirstRunSendsDigest(t *testing.T) {
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	notification, _ := Plan(State{}, nil, 24*time.Hour, now)
+
+	assert.True(t, notification.Digest)
+}
+
+func TestNotification_AtLeast(t *testing.T) {
+	notification := Notification{New: []reports.Entry{fooEntry, apiEntry}, Resolved: []reports.Entry{barEntry}}
+
+	assert.Equal(t, Notification{New: []reports.Entry{fooEntry}, Resolved: []reports.Entry{barEntry}}, notification.AtLeast(rules.SeverityHigh))
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+)
+
+const (
+	NotifierSlack   = "slack"
+	NotifierTeams   = "teams"
+	NotifierWebhook = "webhook"
+
+	DefaultNotifierTimeout = 10 * time.Second
+)
+
+// Notifier sends a message about the violations of a run to a channel.
+type Notifier interface {
+	Notify(message Message) error
+}
+
+// Message is what a notifier sends. A full message holds all violations, it
+// is sent on every run in full mode and as digest in diff mode. Otherwise it
+// holds the violations that are new or resolved since the last notification.
+type Message struct {
+	Full       bool            `json:"full"`
+	Violations []reports.Entry `json:"violations,omitempty"`
+	New        []reports.Entry `json:"new,omitempty"`
+	Resolved   []reports.Entry `json:"resolved,omitempty"`
+}
+
+func NewMessage(notification Notification, violations []reports.Entry) Message {
+	if notification.Digest {
+		return Message{Full: true, Violations: violations}
+	}
+	return Message{New: notification.New, Resolved: notification.Resolved}
+}
+
+// Empty reports whether the message has no violations to send.
+func (m Message) Empty() bool {
+	return len(m.Violations) == 0 && len(m.New) == 0 && len(m.Resolved) == 0
+}
+
+// Title is a one line summary of the message.
+func (m Message) Title() string {
+	if m.Full {
+		return fmt.Sprintf("Kube conformity found %d violations", len(m.Violations))
+	}
+	return fmt.Sprintf("Kube conformity found %d new and %d resolved violations", len(m.New), len(m.Resolved))
+}
+
+// Filter returns the message with only the violations the function keeps.
+func (m Message) Filter(keep func(reports.Entry) bool) Message {
+	return Message{
+		Full:       m.Full,
+		Violations: filterEntries(m.Violations, keep),
+		New:        filterEntries(m.New, keep),
+		Resolved:   filterEntries(m.Resolved, keep),
+	}
+}
+
+func filterEntries(entries []reports.Entry, keep func(reports.Entry) bool) []reports.Entry {
+	var kept []reports.Entry
+	for _, entry := range entries {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// NotifierConfig configures a notifier and the violations routed to it. A
+// notifier without rules receives the violations of every rule.
+type NotifierConfig struct {
+	Name        string            `yaml:"name"`
+	Type        string            `yaml:"type"`
+	URL         string            `yaml:"url"`
+	Rules       []string          `yaml:"rules"`
+	MinSeverity rules.Severity    `yaml:"min_severity"`
+	Headers     map[string]string `yaml:"headers"`
+	Body        string            `yaml:"body"`
+	Timeout     time.Duration     `yaml:"timeout"`
+}
+
+func (n *NotifierConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain NotifierConfig
+	if err := unmarshal((*plain)(n)); err != nil {
+		return err
+	}
+	if n.Name == "" {
+		return fmt.Errorf("missing name for notifier")
+	}
+	if n.URL == "" {
+		return fmt.Errorf("missing url for notifier %s", n.Name)
+	}
+	switch n.Type {
+	case NotifierSlack, NotifierTeams:
+	case NotifierWebhook:
+		if _, err := n.bodyTemplate(); err != nil {
+			return fmt.Errorf("parsing the body of notifier %s: %v", n.Name, err)
+		}
+	default:
+		return fmt.Errorf("unknown notifier type %s for notifier %s, must be %s, %s or %s", n.Type, n.Name, NotifierSlack, NotifierTeams, NotifierWebhook)
+	}
+	return nil
+}
+
+// Route returns the violations of the message this notifier receives.
+func (n NotifierConfig) Route(message Message) Message {
+	return message.Filter(func(entry reports.Entry) bool {
+		if n.MinSeverity != "" && !rules.Severity(entry.Severity).AtLeast(n.MinSeverity) {
+			return false
+		}
+		return len(n.Rules) == 0 || contains(n.Rules, entry.RuleName)
+	})
+}
+
+// Notifier returns the notifier of the configured type.
+func (n NotifierConfig) Notifier() (Notifier, error) {
+	timeout := n.Timeout
+	if timeout == 0 {
+		timeout = DefaultNotifierTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	switch n.Type {
+	case NotifierSlack:
+		return SlackNotifier{Client: client, URL: n.URL}, nil
+	case NotifierTeams:
+		return TeamsNotifier{Client: client, URL: n.URL}, nil
+	case NotifierWebhook:
+		body, err := n.bodyTemplate()
+		if err != nil {
+			return nil, err
+		}
+		return WebhookNotifier{Client: client, URL: n.URL, Headers: n.Headers, Body: body}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type %s", n.Type)
+}
+
+func (n NotifierConfig) bodyTemplate() (*template.Template, error) {
+	if n.Body == "" {
+		return nil, nil
+	}
+	return template.New(n.Name).Funcs(bodyFuncs).Parse(n.Body)
+}
+
+// post sends the body to the url, any status other than 2xx is an error.
+func post(client *http.Client, url string, headers map[string]string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("posting to %s: %s: %s", url, response.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// line describes a violation on a single line.
+func line(entry reports.Entry) string {
+	object := entry.Name
+	if entry.Namespace != "" {
+		object = entry.Namespace + "/" + entry.Name
+	}
+	return fmt.Sprintf("[%s] %s: %s %s: %s", entry.Severity, entry.RuleName, entry.Kind, object, entry.Reason)
+}
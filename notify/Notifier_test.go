@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type request struct {
+	header http.Header
+	body   string
+}
+
+// newServer returns a server recording the requests it receives and replying
+// with the status.
+func newServer(t *testing.T, status int) (*httptest.Server, *[]request) {
+	requests := &[]request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		*requests = append(*requests, request{header: r.Header, body: string(body)})
+		w.WriteHeader(status)
+	}))
+	return server, requests
+}
+
+func TestNewMessage(t *testing.T) {
+	violations := []reports.Entry{fooEntry, apiEntry}
+
+	assert.Equal(t, Message{Full: true, Violations: violations}, NewMessage(Notification{Digest: true}, violations))
+	assert.Equal(t, Message{New: []reports.Entry{apiEntry}, Resolved: []reports.Entry{barEntry}}, NewMessage(Notification{New: []reports.Entry{apiEntry}, Resolved: []reports.Entry{barEntry}}, violations))
+}
+
+func TestMessage_Empty(t *testing.T) {
+	assert.True(t, Message{Full: true}.Empty())
+	assert.False(t, Message{Resolved: []reports.Entry{barEntry}}.Empty())
+}
+
+func TestMessage_Title(t *testing.T) {
+	assert.Equal(t, "Kube conformity found 2 violations", Message{Full: true, Violations: []reports.Entry{fooEntry, apiEntry}}.Title())
+	assert.Equal(t, "Kube conformity found 1 new and 0 resolved violations", Message{New: []reports.Entry{apiEntry}}.Title())
+}
+
+func TestNotifierConfig_UnmarshalYAML(t *testing.T) {
+	test := `
+name: platform
+type: webhook
+url: https://hooks.example.com/conformity
+rules:
+- app label
+min_severity: high
+headers:
+  Authorization: Bearer token
+body: '{"count": {{ len .Violations }}}'
+timeout: 5s`
+
+	notifierConfig := NotifierConfig{}
+
+	err := yaml.Unmarshal([]byte(test), &notifierConfig)
+
+	assert.Nil(t, err)
+	assert.Equal(t, NotifierConfig{
+		Name:        "platform",
+		Type:        NotifierWebhook,
+		URL:         "https://hooks.example.com/conformity",
+		Rules:       []string{"app label"},
+		MinSeverity: rules.SeverityHigh,
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Body:        `{"count": {{ len .Violations }}}`,
+		Timeout:     5 * time.Second,
+	}, notifierConfig)
+}
+
+func TestNotifierConfig_UnmarshalYAML_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing name for notifier":         "type: slack\nurl: https://hooks.slack.com/services/T/B/X",
+		"missing url for notifier platform": "name: platform\ntype: slack",
+		"unknown notifier type email":       "name: platform\ntype: email\nurl: https://hooks.example.com",
+		"parsing the body":                  "name: platform\ntype: webhook\nurl: https://hooks.example.com\nbody: '{{ .Violations'",
+	}
+	for message, test := range tests {
+		notifierConfig := NotifierConfig{}
+
+		err := yaml.Unmarshal([]byte(test), &notifierConfig)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+}
+
+func TestNotifierConfig_Route(t *testing.T) {
+	message := Message{New: []reports.Entry{fooEntry, apiEntry}, Resolved: []reports.Entry{barEntry}}
+
+	assert.Equal(t, message, NotifierConfig{}.Route(message))
+	assert.Equal(t, Message{New: []reports.Entry{apiEntry}}, NotifierConfig{Rules: []string{"replicas minimum"}}.Route(message))
+	assert.Equal(t, Message{New: []reports.Entry{fooEntry}, Resolved: []reports.Entry{barEntry}}, NotifierConfig{MinSeverity: rules.SeverityHigh}.Route(message))
+	assert.True(t, NotifierConfig{Rules: []string{"replicas minimum"}, MinSeverity: rules.SeverityHigh}.Route(message).Empty())
+}
+
+func TestNotifierConfig_Notifier(t *testing.T) {
+	notifier, err := NotifierConfig{Name: "slack", Type: NotifierSlack, URL: "https://hooks.slack.com/services/T/B/X"}.Notifier()
+
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultNotifierTimeout, notifier.(SlackNotifier).Client.Timeout)
+
+	_, err = NotifierConfig{Name: "email", Type: "email"}.Notifier()
+
+	assert.Error(t, err)
+}
+
+func TestPost_ErrorStatus(t *testing.T) {
+	server, _ := newServer(t, http.StatusForbidden)
+	defer server.Close()
+
+	err := post(server.Client(), server.URL, nil, []byte(`{}`))
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "403 Forbidden")
+	}
+}
+
+func TestLine(t *testing.T) {
+	assert.Equal(t, "[high] app label: Pod default/foo: Labels: [app] are not filled in", line(fooEntry))
+	assert.Equal(t, "[low] volumes: PersistentVolume data: not retained", line(reports.Entry{RuleName: "volumes", Severity: "low", Kind: "PersistentVolume", Name: "data", Reason: "not retained"}))
+}
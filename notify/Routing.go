@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const DefaultOwnerAnnotation = "kube-conformity.io/owner-email"
+
+// RoutingConfig decides who receives a violation. The owners in the owner
+// annotation of the namespace of a violation receive it by mail, next to the
+// receivers of the first route matching it. A route that continues passes the
+// violation on to the routes after it. Violations nobody receives go to the
+// fallback receiver.
+type RoutingConfig struct {
+	OwnerAnnotation string    `yaml:"owner_annotation"`
+	Routes          []Route   `yaml:"routes"`
+	Fallback        *Receiver `yaml:"fallback"`
+}
+
+func (r *RoutingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain RoutingConfig
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.OwnerAnnotation == "" {
+		r.OwnerAnnotation = DefaultOwnerAnnotation
+	}
+	return nil
+}
+
+// Notifiers returns the names of the notifiers the routing sends to.
+func (r RoutingConfig) Notifiers() []string {
+	var names []string
+	for _, route := range r.Routes {
+		names = append(names, route.Notifiers...)
+	}
+	if r.Fallback != nil {
+		names = append(names, r.Fallback.Notifiers...)
+	}
+	return names
+}
+
+// Receiver is a set of mail addresses and names of notifiers.
+type Receiver struct {
+	Email     []string `yaml:"email"`
+	Notifiers []string `yaml:"notifiers"`
+}
+
+func (r Receiver) Empty() bool {
+	return len(r.Email) == 0 && len(r.Notifiers) == 0
+}
+
+func (r Receiver) HasEmail(address string) bool {
+	return contains(r.Email, address)
+}
+
+func (r Receiver) HasNotifier(name string) bool {
+	return contains(r.Notifiers, name)
+}
+
+func (r Receiver) merge(other Receiver) Receiver {
+	return Receiver{
+		Email:     append(append([]string(nil), r.Email...), other.Email...),
+		Notifiers: append(append([]string(nil), r.Notifiers...), other.Notifiers...),
+	}
+}
+
+// Route sends the violations it matches to its receiver. A route matches the
+// violations in the namespaces it lists or in the namespaces matching its
+// namespace selector, of the rules it lists and with at least its minimum
+// severity. A route without conditions matches every violation.
+type Route struct {
+	Receiver          `yaml:",inline"`
+	Namespaces        []string       `yaml:"namespaces"`
+	NamespaceSelector string         `yaml:"namespace_selector"`
+	Rules             []string       `yaml:"rules"`
+	MinSeverity       rules.Severity `yaml:"min_severity"`
+	Continue          bool           `yaml:"continue"`
+}
+
+func (r *Route) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Route
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.Receiver.Empty() {
+		return fmt.Errorf("missing email or notifiers for route")
+	}
+	if _, err := labels.Parse(r.NamespaceSelector); err != nil {
+		return fmt.Errorf("parsing the namespace_selector of route: %v", err)
+	}
+	return nil
+}
+
+func (r Route) matches(entry reports.Entry, namespace v1.Namespace) bool {
+	if len(r.Namespaces) > 0 && !contains(r.Namespaces, entry.Namespace) {
+		return false
+	}
+	if r.NamespaceSelector != "" {
+		selector, err := labels.Parse(r.NamespaceSelector)
+		if err != nil || !selector.Matches(labels.Set(namespace.Labels)) {
+			return false
+		}
+	}
+	if len(r.Rules) > 0 && !contains(r.Rules, entry.RuleName) {
+		return false
+	}
+	return r.MinSeverity == "" || rules.Severity(entry.Severity).AtLeast(r.MinSeverity)
+}
+
+// Router routes the violations of a run with the namespaces of the cluster.
+type Router struct {
+	Config     RoutingConfig
+	Namespaces map[string]v1.Namespace
+}
+
+// Receiver returns who receives the violation.
+func (r Router) Receiver(entry reports.Entry) Receiver {
+	namespace := r.Namespaces[entry.Namespace]
+	owners, _ := r.owners(namespace)
+	receiver := Receiver{Email: owners}
+	for _, route := range r.Config.Routes {
+		if !route.matches(entry, namespace) {
+			continue
+		}
+		receiver = receiver.merge(route.Receiver)
+		if !route.Continue {
+			break
+		}
+	}
+	if receiver.Empty() && r.Config.Fallback != nil {
+		return *r.Config.Fallback
+	}
+	return receiver
+}
+
+// InvalidOwners returns per namespace the addresses in its owner annotation
+// that do not parse, receivers leave them out.
+func (r Router) InvalidOwners() map[string][]string {
+	invalidOwners := make(map[string][]string)
+	for name, namespace := range r.Namespaces {
+		if _, invalid := r.owners(namespace); len(invalid) > 0 {
+			invalidOwners[name] = invalid
+		}
+	}
+	return invalidOwners
+}
+
+// owners returns the addresses in the owner annotation of the namespace and
+// the ones that are not a valid address.
+func (r Router) owners(namespace v1.Namespace) ([]string, []string) {
+	var owners, invalid []string
+	for _, owner := range strings.Split(namespace.Annotations[r.Config.OwnerAnnotation], ",") {
+		if owner = strings.TrimSpace(owner); owner == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(owner)
+		if err != nil {
+			invalid = append(invalid, owner)
+			continue
+		}
+		owners = append(owners, address.Address)
+	}
+	return owners, invalid
+}
+
+// Emails returns the addresses receiving any of the violations of the
+// message, sorted.
+func (r Router) Emails(message Message) []string {
+	seen := make(map[string]bool)
+	var addresses []string
+	for _, entries := range [][]reports.Entry{message.Violations, message.New, message.Resolved} {
+		for _, entry := range entries {
+			for _, address := range r.Receiver(entry).Email {
+				if !seen[address] {
+					seen[address] = true
+					addresses = append(addresses, address)
+				}
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// ForEmail returns a function reporting whether the address receives a
+// violation.
+func (r Router) ForEmail(address string) func(reports.Entry) bool {
+	return func(entry reports.Entry) bool {
+		return r.Receiver(entry).HasEmail(address)
+	}
+}
+
+// ForNotifier returns a function reporting whether the notifier receives a
+// violation.
+func (r Router) ForNotifier(name string) func(reports.Entry) bool {
+	return func(entry reports.Entry) bool {
+		return r.Receiver(entry).HasNotifier(name)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
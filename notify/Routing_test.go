@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	paymentsEntry = reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "payments", Name: "checkout", Reason: "Labels: [app] are not filled in"}
+	billingEntry  = reports.Entry{RuleName: "replicas minimum", Severity: "medium", Kind: "Deployment", Namespace: "billing", Name: "invoices", Reason: "Deployment replicas below the minimum: 2"}
+)
+
+func newRouter(config RoutingConfig) Router {
+	return Router{Config: config, Namespaces: map[string]v1.Namespace{
+		"payments": {ObjectMeta: metav1.ObjectMeta{
+			Name:        "payments",
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{DefaultOwnerAnnotation: "payments@example.com, oncall@example.com"},
+		}},
+		"billing": {ObjectMeta: metav1.ObjectMeta{
+			Name:   "billing",
+			Labels: map[string]string{"team": "payments"},
+		}},
+		"default": {ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+	}}
+}
+
+func TestRoutingConfig_UnmarshalYAML(t *testing.T) {
+	test := `
+routes:
+- namespace_selector: team=payments
+  notifiers: [payments-slack]
+  continue: true
+- rules: [replicas minimum]
+  email: [platform@example.com]
+fallback:
+  email: [admin@example.com]`
+
+	routing := RoutingConfig{}
+
+	err := yaml.Unmarshal([]byte(test), &routing)
+
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultOwnerAnnotation, routing.OwnerAnnotation)
+	assert.Equal(t, Receiver{Notifiers: []string{"payments-slack"}}, routing.Routes[0].Receiver)
+	assert.True(t, routing.Routes[0].Continue)
+	assert.Equal(t, []string{"replicas minimum"}, routing.Routes[1].Rules)
+	assert.Equal(t, &Receiver{Email: []string{"admin@example.com"}}, routing.Fallback)
+	assert.Equal(t, []string{"payments-slack"}, routing.Notifiers())
+}
+
+func TestRoute_UnmarshalYAML_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing email or notifiers for route":    "namespaces: [payments]",
+		"parsing the namespace_selector of route": "namespace_selector: 'team in payments'\nemail: [payments@example.com]",
+	}
+	for message, test := range tests {
+		route := Route{}
+
+		err := yaml.Unmarshal([]byte(test), &route)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+}
+
+func TestRouter_Receiver_OwnerAnnotation(t *testing.T) {
+	router := newRouter(RoutingConfig{OwnerAnnotation: DefaultOwnerAnnotation})
+
+	assert.Equal(t, Receiver{Email: []string{"payments@example.com", "oncall@example.com"}}, router.Receiver(paymentsEntry))
+	assert.True(t, router.Receiver(billingEntry).Empty())
+}
+
+func TestRouter_Receiver_InvalidOwners(t *testing.T) {
+	router := newRouter(RoutingConfig{OwnerAnnotation: DefaultOwnerAnnotation, Fallback: &Receiver{Email: []string{"admin@example.com"}}})
+	router.Namespaces["payments"].Annotations[DefaultOwnerAnnotation] = "Payments <payments@example.com>, payments team, oncall@"
+	router.Namespaces["billing"] = v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "billing",
+		Annotations: map[string]string{DefaultOwnerAnnotation: "billing\r\nBcc: everyone@example.com"},
+	}}
+
+	assert.Equal(t, Receiver{Email: []string{"payments@example.com"}}, router.Receiver(paymentsEntry))
+	assert.Equal(t, Receiver{Email: []string{"admin@example.com"}}, router.Receiver(billingEntry), "a namespace without valid owners should fall back")
+	assert.Equal(t, map[string][]string{
+		"payments": {"payments team", "oncall@"},
+		"billing":  {"billing\r\nBcc: everyone@example.com"},
+	}, router.InvalidOwners())
+}
+
+func TestRouter_Receiver_Routes(t *testing.T) {
+	router := newRouter(RoutingConfig{
+		OwnerAnnotation: DefaultOwnerAnnotation,
+		Routes: []Route{
+			{NamespaceSelector: "team=payments", Receiver: Receiver{Notifiers: []string{"payments-slack"}}},
+			{Rules: []string{"replicas minimum"}, Receiver: Receiver{Email: []string{"platform@example.com"}}},
+		},
+		Fallback: &Receiver{Email: []string{"admin@example.com"}},
+	})
+
+	assert.Equal(t, Receiver{Email: []string{"payments@example.com", "oncall@example.com"}, Notifiers: []string{"payments-slack"}}, router.Receiver(paymentsEntry))
+	assert.Equal(t, Receiver{Notifiers: []string{"payments-slack"}}, router.Receiver(billingEntry))
+	assert.Equal(t, Receiver{Email: []string{"admin@example.com"}}, router.Receiver(fooEntry))
+}
+
+func TestRouter_Receiver_Continue(t *testing.T) {
+	router := newRouter(RoutingConfig{Routes: []Route{
+		{Namespaces: []string{"billing"}, Continue: true, Receiver: Receiver{Notifiers: []string{"billing-teams"}}},
+		{MinSeverity: "medium", Receiver: Receiver{Email: []string{"platform@example.com"}}},
+		{Receiver: Receiver{Email: []string{"unreachable@example.com"}}},
+	}})
+
+	assert.Equal(t, Receiver{Email: []string{"platform@example.com"}, Notifiers: []string{"billing-teams"}}, router.Receiver(billingEntry))
+}
+
+func TestRouter_Emails(t *testing.T) {
+	router := newRouter(RoutingConfig{
+		OwnerAnnotation: DefaultOwnerAnnotation,
+		Fallback:        &Receiver{Email: []string{"admin@example.com"}},
+	})
+
+	emails := router.Emails(Message{New: []reports.Entry{paymentsEntry}, Resolved: []reports.Entry{billingEntry}})
+
+	assert.Equal(t, []string{"admin@example.com", "oncall@example.com", "payments@example.com"}, emails)
+}
+
+func TestRouter_ForEmail(t *testing.T) {
+	router := newRouter(RoutingConfig{
+		OwnerAnnotation: DefaultOwnerAnnotation,
+		Fallback:        &Receiver{Email: []string{"admin@example.com"}},
+	})
+	message := Message{Full: true, Violations: []reports.Entry{paymentsEntry, billingEntry}}
+
+	assert.Equal(t, Message{Full: true, Violations: []reports.Entry{paymentsEntry}}, message.Filter(router.ForEmail("oncall@example.com")))
+	assert.Equal(t, Message{Full: true, Violations: []reports.Entry{billingEntry}}, message.Filter(router.ForEmail("admin@example.com")))
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+)
+
+// maxLines is the maximum number of violations listed per section of a chat
+// message, chat messages with more are truncated by the receiving service.
+const maxLines = 50
+
+// SlackNotifier posts messages to a Slack incoming webhook.
+type SlackNotifier struct {
+	Client *http.Client
+	URL    string
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (s SlackNotifier) Notify(message Message) error {
+	var text []string
+	text = append(text, "*"+message.Title()+"*")
+	for _, section := range sections(message) {
+		text = append(text, "", "*"+section.title+"*")
+		for _, line := range section.lines {
+			text = append(text, "• "+line)
+		}
+	}
+	body, err := json.Marshal(slackPayload{Text: strings.Join(text, "\n")})
+	if err != nil {
+		return err
+	}
+	return post(s.Client, s.URL, nil, body)
+}
+
+type section struct {
+	title string
+	lines []string
+}
+
+// sections groups the violations of the message for chat messages, with at
+// most maxLines lines per group.
+func sections(message Message) []section {
+	var sections []section
+	for _, group := range []struct {
+		title   string
+		entries []reports.Entry
+	}{
+		{"Violations", message.Violations},
+		{"New violations", message.New},
+		{"Resolved violations", message.Resolved},
+	} {
+		if len(group.entries) == 0 {
+			continue
+		}
+		section := section{title: group.title}
+		for idx, entry := range group.entries {
+			if idx == maxLines {
+				section.lines = append(section.lines, fmt.Sprintf("and %d more", len(group.entries)-maxLines))
+				break
+			}
+			section.lines = append(section.lines, line(entry))
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK)
+	defer server.Close()
+	notifier := SlackNotifier{Client: server.Client(), URL: server.URL}
+
+	err := notifier.Notify(Message{New: []reports.Entry{apiEntry}, Resolved: []reports.Entry{barEntry}})
+
+	assert.Nil(t, err)
+	if assert.Len(t, *requests, 1) {
+		assert.Equal(t, "application/json", (*requests)[0].header.Get("Content-Type"))
+		assert.JSONEq(t, `{"text": "*Kube conformity found 1 new and 1 resolved violations*\n\n*New violations*\n• [medium] replicas minimum: Deployment testing/api: Deployment replicas below the minimum: 2\n\n*Resolved violations*\n• [high] app label: Pod default/bar: Labels: [app] are not filled in"}`, (*requests)[0].body)
+	}
+}
+
+func TestSlackNotifier_NotifyError(t *testing.T) {
+	server, _ := newServer(t, http.StatusNotFound)
+	defer server.Close()
+	notifier := SlackNotifier{Client: server.Client(), URL: server.URL}
+
+	err := notifier.Notify(Message{Full: true, Violations: []reports.Entry{fooEntry}})
+
+	assert.Error(t, err)
+}
+
+func TestSections_Truncated(t *testing.T) {
+	var violations []reports.Entry
+	for idx := 0; idx < maxLines+5; idx++ {
+		violations = append(violations, reports.Entry{RuleName: "app label", Severity: "high", Kind: "Pod", Namespace: "default", Name: fmt.Sprintf("pod-%d", idx)})
+	}
+
+	sections := sections(Message{Full: true, Violations: violations})
+
+	if assert.Len(t, sections, 1) {
+		assert.Equal(t, "Violations", sections[0].title)
+		assert.Len(t, sections[0].lines, maxLines+1)
+		assert.Equal(t, "and 5 more", sections[0].lines[maxLines])
+	}
+}
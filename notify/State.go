@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/history"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const stateKey = "state.json"
+
+// State holds the violations of the last notification of a channel and when
+// the last digest was sent to it.
+type State struct {
+	LastDigest time.Time                    `json:"lastDigest"`
+	Violations map[string]history.Violation `json:"violations"`
+}
+
+// States holds the state of every channel, the mail to an address or a
+// notifier, so a channel that fails to send is notified again on the next run
+// without notifying the other channels twice.
+type States struct {
+	Channels map[string]State `json:"channels"`
+}
+
+// Channel returns the state of the channel, an empty state when it was not
+// notified before.
+func (s States) Channel(channel string) State {
+	return s.Channels[channel]
+}
+
+// StateStore keeps the states between runs and restarts.
+type StateStore interface {
+	Load() (States, error)
+	Save(states States) error
+}
+
+// NewStateStore returns the store for the state file or config map, the config
+// map takes precedence over the file.
+func NewStateStore(stateFile string, stateConfigMap *rules.ConfigMapReference, client kubernetes.Interface) StateStore {
+	if stateConfigMap != nil {
+		return ConfigMapStateStore{Client: client, Namespace: stateConfigMap.Namespace, Name: stateConfigMap.Name}
+	}
+	return FileStateStore{Path: stateFile}
+}
+
+// FileStateStore keeps the state as JSON in a file, a missing file is an
+// empty state.
+type FileStateStore struct {
+	Path string
+}
+
+func (f FileStateStore) Load() (States, error) {
+	state := States{}
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (f FileStateStore) Save(state States) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.Path, data, 0600)
+}
+
+// ConfigMapStateStore keeps the state as JSON in a config map, a missing
+// config map is an empty state and is created on save.
+type ConfigMapStateStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func (c ConfigMapStateStore) Load() (States, error) {
+	state := States{}
+	configMap, err := c.Client.CoreV1().ConfigMaps(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if data, exists := configMap.Data[stateKey]; exists {
+		err = json.Unmarshal([]byte(data), &state)
+	}
+	return state, err
+}
+
+func (c ConfigMapStateStore) Save(state States) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	configMaps := c.Client.CoreV1().ConfigMaps(c.Namespace)
+	configMap, err := configMaps.Get(c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.Namespace, Name: c.Name}}
+		configMap.Data = map[string]string{stateKey: string(data)}
+		_, err = configMaps.Create(configMap)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[stateKey] = string(data)
+	_, err = configMaps.Update(configMap)
+	return err
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stijndehaes/kube-conformity/history"
+	"github.com/stijndehaes/kube-conformity/rules"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newState() States {
+	firstSeen := time.Date(2019, 6, 15, 10, 0, 0, 0, time.UTC)
+	return States{Channels: map[string]State{
+		"email": {
+			LastDigest: time.Date(2019, 6, 15, 0, 0, 0, 0, time.UTC),
+			Violations: map[string]history.Violation{
+				history.Key(fooEntry): {Entry: fooEntry, FirstSeen: firstSeen, LastSeen: firstSeen},
+			},
+		},
+	}}
+}
+
+func TestFileStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileStateStore{Path: filepath.Join(dir, "state.json")}
+
+	state, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, States{}, state)
+
+	assert.Nil(t, store.Save(newState()))
+	state, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, newState(), state)
+}
+
+func TestConfigMapStateStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	store := ConfigMapStateStore{Client: client, Namespace: "kube-conformity", Name: "kube-conformity-state"}
+
+	state, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, States{}, state)
+
+	assert.Nil(t, store.Save(States{}))
+	assert.Nil(t, store.Save(newState()))
+	state, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, newState(), state)
+	configMap, err := client.CoreV1().ConfigMaps("kube-conformity").Get("kube-conformity-state", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, configMap.Data, "state.json")
+}
+
+func TestNewStateStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	assert.Equal(t, FileStateStore{Path: "/tmp/state.json"}, NewStateStore("/tmp/state.json", nil, client))
+	assert.Equal(t, ConfigMapStateStore{Client: client, Namespace: "kube-conformity", Name: "state"}, NewStateStore("/tmp/state.json", &rules.ConfigMapReference{Namespace: "kube-conformity", Name: "state"}, client))
+}
+
+func TestStates_Channel(t *testing.T) {
+	states := newState()
+
+	assert.Equal(t, states.Channels["email"], states.Channel("email"))
+	assert.Equal(t, State{}, states.Channel("notifier slack"))
+	assert.Equal(t, State{}, States{}.Channel("email"))
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// TeamsNotifier posts messages as message cards to a Microsoft Teams incoming
+// webhook.
+type TeamsNotifier struct {
+	Client *http.Client
+	URL    string
+}
+
+type teamsCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title"`
+	ThemeColor string         `json:"themeColor"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+type teamsSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+func (t TeamsNotifier) Notify(message Message) error {
+	card := teamsCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		Summary:    message.Title(),
+		Title:      message.Title(),
+		ThemeColor: "D73A49",
+		Sections:   []teamsSection{},
+	}
+	if len(message.Violations) == 0 && len(message.New) == 0 {
+		card.ThemeColor = "22863A"
+	}
+	for _, section := range sections(message) {
+		// Teams renders the text as markdown, lines need a blank line between
+		// them to show up as separate paragraphs.
+		card.Sections = append(card.Sections, teamsSection{Title: section.title, Text: strings.Join(section.lines, "\n\n")})
+	}
+	body, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return post(t.Client, t.URL, nil, body)
+}
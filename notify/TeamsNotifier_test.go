@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamsNotifier_Notify(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK)
+	defer server.Close()
+	notifier := TeamsNotifier{Client: server.Client(), URL: server.URL}
+
+	err := notifier.Notify(Message{Full: true, Violations: []reports.Entry{fooEntry, apiEntry}})
+
+	assert.Nil(t, err)
+	if assert.Len(t, *requests, 1) {
+		assert.JSONEq(t, `{
+			"@type": "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary": "Kube conformity found 2 violations",
+			"title": "Kube conformity found 2 violations",
+			"themeColor": "D73A49",
+			"sections": [{
+				"title": "Violations",
+				"text": "[high] app label: Pod default/foo: Labels: [app] are not filled in\n\n[medium] replicas minimum: Deployment testing/api: Deployment replicas below the minimum: 2"
+			}]
+		}`, (*requests)[0].body)
+	}
+}
+
+func TestTeamsNotifier_NotifyResolved(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK)
+	defer server.Close()
+	notifier := TeamsNotifier{Client: server.Client(), URL: server.URL}
+
+	err := notifier.Notify(Message{Resolved: []reports.Entry{barEntry}})
+
+	assert.Nil(t, err)
+	if assert.Len(t, *requests, 1) {
+		assert.Contains(t, (*requests)[0].body, `"themeColor":"22863A"`)
+	}
+}
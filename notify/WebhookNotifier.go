@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"text/template"
+)
+
+var bodyFuncs = template.FuncMap{"json": toJSON}
+
+// WebhookNotifier posts messages to a generic webhook. The body is the message
+// as JSON, or the body template executed with the message.
+type WebhookNotifier struct {
+	Client  *http.Client
+	URL     string
+	Headers map[string]string
+	Body    *template.Template
+}
+
+func (w WebhookNotifier) Notify(message Message) error {
+	if w.Body == nil {
+		body, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		return post(w.Client, w.URL, w.Headers, body)
+	}
+	body := new(bytes.Buffer)
+	if err := w.Body.Execute(body, message); err != nil {
+		return err
+	}
+	return post(w.Client, w.URL, w.Headers, body.Bytes())
+}
+
+func toJSON(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
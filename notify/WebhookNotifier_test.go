@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stijndehaes/kube-conformity/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	server, requests := newServer(t, http.StatusNoContent)
+	defer server.Close()
+	notifier := WebhookNotifier{Client: server.Client(), URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+
+	err := notifier.Notify(Message{New: []reports.Entry{apiEntry}})
+
+	assert.Nil(t, err)
+	if assert.Len(t, *requests, 1) {
+		assert.Equal(t, "Bearer token", (*requests)[0].header.Get("Authorization"))
+		assert.JSONEq(t, `{"full": false, "new": [{
+			"ruleName": "replicas minimum",
+			"severity": "medium",
+			"kind": "Deployment",
+			"namespace": "testing",
+			"name": "api",
+			"reason": "Deployment replicas below the minimum: 2"
+		}]}`, (*requests)[0].body)
+	}
+}
+
+func TestWebhookNotifier_NotifyTemplate(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK)
+	defer server.Close()
+	notifierConfig := NotifierConfig{
+		Name:    "pager",
+		Type:    NotifierWebhook,
+		URL:     server.URL,
+		Headers: map[string]string{"Content-Type": "application/vnd.pager+json"},
+		Body:    `{"summary": {{ json .Title }}, "names": [{{ range $idx, $entry := .New }}{{ if $idx }}, {{ end }}{{ json $entry.Name }}{{ end }}]}`,
+	}
+	notifier, err := notifierConfig.Notifier()
+	assert.Nil(t, err)
+
+	err = notifier.Notify(Message{New: []reports.Entry{fooEntry, apiEntry}})
+
+	assert.Nil(t, err)
+	if assert.Len(t, *requests, 1) {
+		assert.Equal(t, "application/vnd.pager+json", (*requests)[0].header.Get("Content-Type"))
+		assert.JSONEq(t, `{"summary": "Kube conformity found 2 new and 0 resolved violations", "names": ["foo", "api"]}`, (*requests)[0].body)
+	}
+}
@@ -0,0 +1,38 @@
+package reports
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSVFormatter writes a row for every violation and every exempted object,
+// the status column tells them apart.
+type CSVFormatter struct{}
+
+var csvHeader = []string{
+	"status", "rule_name", "severity", "kind", "namespace", "name", "reason",
+	"exempted_until", "exemption_reason", "exemption_owner", "exemption_ticket",
+}
+
+func (f CSVFormatter) Format(report Report, writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, entries := range []struct {
+		status  string
+		entries []Entry
+	}{{"violation", report.Violations}, {"exempted", report.Exempted}} {
+		for _, entry := range entries.entries {
+			row := []string{
+				entries.status, entry.RuleName, entry.Severity, entry.Kind, entry.Namespace, entry.Name, entry.Reason,
+				entry.ExemptedUntil, entry.ExemptionReason, entry.ExemptionOwner, entry.ExemptionTicket,
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
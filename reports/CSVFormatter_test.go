@@ -0,0 +1,24 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVFormatter_Format(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := CSVFormatter{}.Format(newReport(), buffer)
+
+	assert.Nil(t, err)
+	rows, err := csv.NewReader(buffer).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{"violation", "labels", "high", "Pod", "default", "foo", "Labels: [app] are not filled in", "", "", "", ""}, rows[1])
+	assert.Equal(t, "exempted", rows[3][0])
+	assert.Equal(t, "SEC-1", rows[3][10])
+}
@@ -0,0 +1,38 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Formatter writes a report in a specific format.
+type Formatter interface {
+	Format(report Report, writer io.Writer) error
+}
+
+var formatters = map[string]Formatter{
+	"json":  JSONFormatter{},
+	"yaml":  YAMLFormatter{},
+	"junit": JUnitFormatter{},
+	"sarif": SARIFFormatter{},
+	"csv":   CSVFormatter{},
+}
+
+// Formats lists the names of the available formats.
+func Formats() []string {
+	var formats []string
+	for format := range formatters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func FormatterFor(format string) (Formatter, error) {
+	formatter, exists := formatters[format]
+	if !exists {
+		return nil, fmt.Errorf("unknown report format %q, must be one of %v", format, Formats())
+	}
+	return formatter, nil
+}
@@ -0,0 +1,40 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newReport() Report {
+	return Report{
+		Summary: Summary{Rules: 2, Violations: 2, Exempted: 1, ViolationsBySeverity: map[string]int{"high": 2}},
+		Rules: []Rule{
+			{Name: "labels", Type: "pod_rules_labels_filled_in", Kind: "Pod", Severity: "high"},
+			{Name: "replicas", Type: "deployment_rules_replicas_minimum", Kind: "Deployment", Severity: "low"},
+		},
+		Violations: []Entry{
+			{RuleName: "labels", Severity: "high", Kind: "Pod", Namespace: "default", Name: "foo", Reason: "Labels: [app] are not filled in"},
+			{RuleName: "labels", Severity: "high", Kind: "Pod", Namespace: "default", Name: "bar", Reason: "Labels: [app] are not filled in"},
+		},
+		Exempted: []Entry{
+			{RuleName: "replicas", Severity: "low", Kind: "Deployment", Namespace: "default", Name: "legacy", Reason: "Deployment replicas below the minimum: 2", ExemptionReason: "Migrating", ExemptionTicket: "SEC-1"},
+		},
+		ExpiringExceptions: []Exception{},
+		ExpiredExceptions:  []Exception{},
+		StaleExceptions:    []Exception{},
+	}
+}
+
+func TestFormats(t *testing.T) {
+	assert.Equal(t, []string{"csv", "json", "junit", "sarif", "yaml"}, Formats())
+}
+
+func TestFormatterFor(t *testing.T) {
+	formatter, err := FormatterFor("junit")
+	assert.Nil(t, err)
+	assert.Equal(t, JUnitFormatter{}, formatter)
+
+	_, err = FormatterFor("html")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,14 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type JSONFormatter struct{}
+
+func (f JSONFormatter) Format(report Report, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
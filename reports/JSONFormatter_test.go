@@ -0,0 +1,20 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONFormatter_Format(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := JSONFormatter{}.Format(newReport(), buffer)
+
+	assert.Nil(t, err)
+	report := Report{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &report))
+	assert.Equal(t, newReport(), report)
+}
@@ -0,0 +1,76 @@
+package reports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// JUnitFormatter writes every rule as a testcase, every violation of the rule
+// is a failure of the testcase and exempted objects are listed as output.
+type JUnitFormatter struct{}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (f JUnitFormatter) Format(report Report, writer io.Writer) error {
+	suite := junitTestSuite{Name: "kube-conformity", Tests: len(report.Rules)}
+	for _, rule := range report.Rules {
+		testCase := junitTestCase{Name: rule.Name, ClassName: rule.Type}
+		for _, violation := range report.ViolationsOf(rule.Name) {
+			testCase.Failures = append(testCase.Failures, junitFailure{
+				Message: fmt.Sprintf("%s %s/%s: %s", violation.Kind, violation.Namespace, violation.Name, violation.Reason),
+				Type:    violation.Severity,
+				Text:    violation.Reason,
+			})
+		}
+		for _, exempted := range report.Exempted {
+			if exempted.RuleName == rule.Name {
+				testCase.SystemOut += fmt.Sprintf("%s %s/%s is exempted: %s\n", exempted.Kind, exempted.Namespace, exempted.Name, exempted.ExemptionReason)
+			}
+		}
+		if len(testCase.Failures) > 0 {
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	testSuites := junitTestSuites{
+		Tests:      suite.Tests,
+		Failures:   suite.Failures,
+		TestSuites: []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(testSuites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
@@ -0,0 +1,28 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitFormatter_Format(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := JUnitFormatter{}.Format(newReport(), buffer)
+
+	assert.Nil(t, err)
+	testSuites := junitTestSuites{}
+	assert.Nil(t, xml.Unmarshal(buffer.Bytes(), &testSuites))
+	assert.Equal(t, 2, testSuites.Tests)
+	assert.Equal(t, 1, testSuites.Failures)
+	testCases := testSuites.TestSuites[0].TestCases
+	assert.Equal(t, "labels", testCases[0].Name)
+	assert.Len(t, testCases[0].Failures, 2)
+	assert.Equal(t, "Pod default/foo: Labels: [app] are not filled in", testCases[0].Failures[0].Message)
+	assert.Equal(t, "high", testCases[0].Failures[0].Type)
+	assert.Empty(t, testCases[1].Failures)
+	assert.Equal(t, "Deployment default/legacy is exempted: Migrating\n", testCases[1].SystemOut)
+}
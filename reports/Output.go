@@ -0,0 +1,62 @@
+package reports
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const Stdout = "-"
+
+// Output writes the report in a format to a destination, a file path or
+// stdout when the destination is empty or -.
+type Output struct {
+	Format      string `yaml:"format"`
+	Destination string `yaml:"destination"`
+}
+
+// ParseOutput parses an output flag of the form format or format:destination.
+func ParseOutput(value string) (Output, error) {
+	parts := strings.SplitN(value, ":", 2)
+	output := Output{Format: parts[0]}
+	if len(parts) == 2 {
+		output.Destination = parts[1]
+	}
+	if _, err := FormatterFor(output.Format); err != nil {
+		return Output{}, err
+	}
+	return output, nil
+}
+
+func (o Output) Write(report Report) error {
+	formatter, err := FormatterFor(o.Format)
+	if err != nil {
+		return err
+	}
+	if o.Destination == "" || o.Destination == Stdout {
+		return formatter.Format(report, os.Stdout)
+	}
+	file, err := os.Create(o.Destination)
+	if err != nil {
+		return err
+	}
+	if err := formatter.Format(report, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (o *Output) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Output
+	if err := unmarshal((*plain)(o)); err != nil {
+		return err
+	}
+	if o.Format == "" {
+		return fmt.Errorf("missing format for Output")
+	}
+	if _, err := FormatterFor(o.Format); err != nil {
+		return err
+	}
+	return nil
+}
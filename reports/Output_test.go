@@ -0,0 +1,54 @@
+package reports
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseOutput(t *testing.T) {
+	output, err := ParseOutput("junit:reports/conformity.xml")
+	assert.Nil(t, err)
+	assert.Equal(t, Output{Format: "junit", Destination: "reports/conformity.xml"}, output)
+
+	output, err = ParseOutput("json")
+	assert.Nil(t, err)
+	assert.Equal(t, Output{Format: "json"}, output)
+
+	_, err = ParseOutput("html:report.html")
+	assert.NotNil(t, err)
+}
+
+func TestOutput_Write(t *testing.T) {
+	destination, err := ioutil.TempFile("", "report")
+	assert.Nil(t, err)
+	defer os.Remove(destination.Name())
+
+	err = Output{Format: "yaml", Destination: destination.Name()}.Write(newReport())
+
+	assert.Nil(t, err)
+	content, err := ioutil.ReadFile(destination.Name())
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "violations_by_severity")
+}
+
+func TestOutput_UnmarshalYAML(t *testing.T) {
+	outputs := []Output{}
+	err := yaml.Unmarshal([]byte(`
+- format: sarif
+  destination: conformity.sarif
+- format: csv`), &outputs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Output{{Format: "sarif", Destination: "conformity.sarif"}, {Format: "csv"}}, outputs)
+
+	err = yaml.Unmarshal([]byte(`
+- destination: conformity.sarif`), &outputs)
+	assert.NotNil(t, err)
+
+	err = yaml.Unmarshal([]byte(`
+- format: html`), &outputs)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,60 @@
+package reports
+
+// Report is a flat view of the results with one entry per object and rule,
+// meant to be written out for other tools to consume.
+type Report struct {
+	Summary            Summary     `json:"summary" yaml:"summary"`
+	Rules              []Rule      `json:"rules" yaml:"rules"`
+	Violations         []Entry     `json:"violations" yaml:"violations"`
+	Exempted           []Entry     `json:"exempted" yaml:"exempted"`
+	ExpiringExceptions []Exception `json:"expiringExceptions" yaml:"expiring_exceptions"`
+	ExpiredExceptions  []Exception `json:"expiredExceptions" yaml:"expired_exceptions"`
+	StaleExceptions    []Exception `json:"staleExceptions" yaml:"stale_exceptions"`
+}
+
+type Summary struct {
+	Rules                int            `json:"rules" yaml:"rules"`
+	Violations           int            `json:"violations" yaml:"violations"`
+	Exempted             int            `json:"exempted" yaml:"exempted"`
+	ViolationsBySeverity map[string]int `json:"violationsBySeverity" yaml:"violations_by_severity"`
+}
+
+// Rule is a configured rule, rules without violations are part of the report
+// as well so formats like JUnit can report them as passed.
+type Rule struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Severity string `json:"severity" yaml:"severity"`
+}
+
+type Entry struct {
+	RuleName        string `json:"ruleName" yaml:"rule_name"`
+	Severity        string `json:"severity" yaml:"severity"`
+	Kind            string `json:"kind" yaml:"kind"`
+	Namespace       string `json:"namespace" yaml:"namespace"`
+	Name            string `json:"name" yaml:"name"`
+	Reason          string `json:"reason" yaml:"reason"`
+	ExemptedUntil   string `json:"exemptedUntil,omitempty" yaml:"exempted_until,omitempty"`
+	ExemptionReason string `json:"exemptionReason,omitempty" yaml:"exemption_reason,omitempty"`
+	ExemptionOwner  string `json:"exemptionOwner,omitempty" yaml:"exemption_owner,omitempty"`
+	ExemptionTicket string `json:"exemptionTicket,omitempty" yaml:"exemption_ticket,omitempty"`
+}
+
+type Exception struct {
+	Owner   string   `json:"owner" yaml:"owner"`
+	Ticket  string   `json:"ticket" yaml:"ticket"`
+	Rules   []string `json:"rules" yaml:"rules"`
+	Expires string   `json:"expires" yaml:"expires"`
+}
+
+// ViolationsOf returns the violations of the rule.
+func (r Report) ViolationsOf(ruleName string) []Entry {
+	var violations []Entry
+	for _, violation := range r.Violations {
+		if violation.RuleName == ruleName {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,137 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	sarifSchema  = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.4.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFFormatter writes the violations as SARIF 2.1.0 results. Objects have no
+// file they live in, they are reported as logical locations.
+type SARIFFormatter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+var sarifLevels = map[string]string{
+	"critical": "error",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "note",
+	"info":     "note",
+}
+
+// sarifSecuritySeverities are the scores code scanning UIs use to rank results.
+var sarifSecuritySeverities = map[string]string{
+	"critical": "9.5",
+	"high":     "8.0",
+	"medium":   "5.5",
+	"low":      "2.0",
+	"info":     "0.0",
+}
+
+func (f SARIFFormatter) Format(report Report, writer io.Writer) error {
+	driver := sarifDriver{
+		Name:           "kube-conformity",
+		InformationURI: "https://github.com/stijndehaes/kube-conformity",
+		Rules:          []sarifRule{},
+	}
+	ruleIndexes := make(map[string]int)
+	for idx, rule := range report.Rules {
+		ruleIndexes[rule.Name] = idx
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.Name,
+			Name:                 rule.Type,
+			ShortDescription:     sarifMessage{Text: rule.Name},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevels[rule.Severity]},
+			Properties: map[string]interface{}{
+				"security-severity": sarifSecuritySeverities[rule.Severity],
+				"tags":              []string{"kubernetes", rule.Kind},
+			},
+		})
+	}
+	results := []sarifResult{}
+	for _, violation := range report.Violations {
+		var ruleIndex *int
+		if idx, ok := ruleIndexes[violation.RuleName]; ok {
+			ruleIndex = &idx
+		}
+		results = append(results, sarifResult{
+			RuleID:    violation.RuleName,
+			RuleIndex: ruleIndex,
+			Level:     sarifLevels[violation.Severity],
+			Message:   sarifMessage{Text: fmt.Sprintf("%s %s/%s: %s", violation.Kind, violation.Namespace, violation.Name, violation.Reason)},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               violation.Name,
+					FullyQualifiedName: fmt.Sprintf("%s/%s/%s", violation.Namespace, violation.Kind, violation.Name),
+					Kind:               "resource",
+				}},
+			}},
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
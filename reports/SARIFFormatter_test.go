@@ -0,0 +1,44 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSARIFFormatter_Format(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := SARIFFormatter{}.Format(newReport(), buffer)
+
+	assert.Nil(t, err)
+	log := sarifLog{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	run := log.Runs[0]
+	assert.Equal(t, "kube-conformity", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "note", run.Tool.Driver.Rules[1].DefaultConfiguration.Level)
+	assert.Len(t, run.Results, 2)
+	assert.Equal(t, "labels", run.Results[0].RuleID)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "default/Pod/foo", run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+}
+
+func TestSARIFFormatter_Format_UnknownRule(t *testing.T) {
+	report := newReport()
+	report.Violations[1].RuleName = "removed"
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := SARIFFormatter{}.Format(report, buffer)
+
+	assert.Nil(t, err)
+	log := sarifLog{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &log))
+	if assert.NotNil(t, log.Runs[0].Results[0].RuleIndex) {
+		assert.Equal(t, 0, *log.Runs[0].Results[0].RuleIndex)
+	}
+	assert.Nil(t, log.Runs[0].Results[1].RuleIndex)
+}
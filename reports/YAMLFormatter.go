@@ -0,0 +1,18 @@
+package reports
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+type YAMLFormatter struct{}
+
+func (f YAMLFormatter) Format(report Report, writer io.Writer) error {
+	content, err := yaml.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
@@ -0,0 +1,21 @@
+package reports
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestYAMLFormatter_Format(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := YAMLFormatter{}.Format(newReport(), buffer)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), "rule_name: labels")
+	report := Report{}
+	assert.Nil(t, yaml.Unmarshal(buffer.Bytes(), &report))
+	assert.Equal(t, newReport(), report)
+}
@@ -8,6 +8,7 @@ import (
 
 type DeploymentRuleReplicasMinimum struct {
 	Name            string                   `yaml:"name"`
+	Severity        Severity                 `yaml:"severity"`
 	MinimumReplicas int32                    `yaml:"minimum_replicas"`
 	Filter          filters.DeploymentFilter `yaml:"filter"`
 }
@@ -25,6 +26,7 @@ func (deploymentRuleReplicasMinimum DeploymentRuleReplicasMinimum) FindNonConfor
 		Deployments: nonConformingDeployments,
 		Reason:      fmt.Sprintf("Deployment replicas below the minimum: %v", deploymentRuleReplicasMinimum.MinimumReplicas),
 		RuleName:    deploymentRuleReplicasMinimum.Name,
+		Severity:    deploymentRuleReplicasMinimum.Severity.OrDefault(),
 	}
 }
 
@@ -34,7 +36,7 @@ func (deploymentRuleReplicasMinimum *DeploymentRuleReplicasMinimum) UnmarshalYAM
 		return err
 	}
 	if deploymentRuleReplicasMinimum.MinimumReplicas == 0 {
-		return fmt.Errorf(",issing minimum replicas")
+		return fmt.Errorf("missing minimum replicas for DeploymentRuleReplicasMinimum")
 	}
 	if deploymentRuleReplicasMinimum.Name == "" {
 		return fmt.Errorf("missing name for DeploymentRuleReplicasMinimum")
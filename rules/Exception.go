@@ -0,0 +1,195 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const DefaultExceptionWarnDays = 14
+
+// Exceptions is the content of the exceptions file, a central list of waivers
+// maintained next to the config.
+type Exceptions struct {
+	WarnDays   int         `yaml:"warn_days"`
+	Exceptions []Exception `yaml:"exceptions"`
+}
+
+// Exception waives rules for the objects it matches until it expires. Kind,
+// namespace, name and selector are optional, every one that is set has to
+// match. Namespace and name are globs like team-*.
+type Exception struct {
+	Kind      string   `yaml:"kind"`
+	Namespace string   `yaml:"namespace"`
+	Name      string   `yaml:"name"`
+	Selector  string   `yaml:"selector"`
+	Rules     []string `yaml:"rules"`
+	Owner     string   `yaml:"owner"`
+	Ticket    string   `yaml:"ticket"`
+	Reason    string   `yaml:"reason"`
+	Expires   string   `yaml:"expires"`
+	expiresAt time.Time
+}
+
+// ExpiresAt returns the moment the exception stops applying, an expires date
+// covers the whole day.
+func (e Exception) ExpiresAt() time.Time {
+	return e.expiresAt
+}
+
+// Expired reports whether the exception no longer applies.
+func (e Exception) Expired(now time.Time) bool {
+	return !now.Before(e.expiresAt)
+}
+
+// ExpiresWithin reports whether the exception still applies but expires within
+// the given number of days.
+func (e Exception) ExpiresWithin(days int, now time.Time) bool {
+	return !e.Expired(now) && now.AddDate(0, 0, days).After(e.expiresAt)
+}
+
+// Matches reports whether the exception waives the rule for the object, an
+// expired exception matches nothing.
+func (e Exception) Matches(kind, ruleName string, object metav1.Object, now time.Time) bool {
+	if e.Expired(now) || !e.Exemption().covers(ruleName) {
+		return false
+	}
+	if e.Kind != "" && e.Kind != kind {
+		return false
+	}
+	if e.Namespace != "" && !matchesGlob(e.Namespace, object.GetNamespace()) {
+		return false
+	}
+	if e.Name != "" && !matchesGlob(e.Name, object.GetName()) {
+		return false
+	}
+	if e.Selector != "" {
+		selector, err := labels.Parse(e.Selector)
+		if err != nil || !selector.Matches(labels.Set(object.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
+
+// Exemption returns the exemption the exception grants.
+func (e Exception) Exemption() *Exemption {
+	expiresAt := e.expiresAt
+	return &Exemption{
+		Rules:  e.Rules,
+		Until:  &expiresAt,
+		Reason: e.Reason,
+		Owner:  e.Owner,
+		Ticket: e.Ticket,
+	}
+}
+
+func (e Exception) String() string {
+	return fmt.Sprintf("%s owned by %s", e.Ticket, e.Owner)
+}
+
+func matchesGlob(pattern, value string) bool {
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+func findException(exceptions []Exception, kind, ruleName string, object metav1.Object, now time.Time) *Exception {
+	for idx := range exceptions {
+		if exceptions[idx].Matches(kind, ruleName, object, now) {
+			return &exceptions[idx]
+		}
+	}
+	return nil
+}
+
+// Expiring returns the exceptions that expire within the warn days.
+func (e Exceptions) Expiring(now time.Time) []Exception {
+	var expiring []Exception
+	for _, exception := range e.Exceptions {
+		if exception.ExpiresWithin(e.WarnDays, now) {
+			expiring = append(expiring, exception)
+		}
+	}
+	return expiring
+}
+
+// Expired returns the exceptions that have expired.
+func (e Exceptions) Expired(now time.Time) []Exception {
+	var expired []Exception
+	for _, exception := range e.Exceptions {
+		if exception.Expired(now) {
+			expired = append(expired, exception)
+		}
+	}
+	return expired
+}
+
+// Stale returns the exceptions that are still valid but match none of the
+// exempted violations, they can be removed. An exception matching a violation
+// that is exempted by an annotation, or by an exception before it, is in use.
+func (e Exceptions) Stale(exemptedResults []ExemptedResult, now time.Time) []Exception {
+	var stale []Exception
+	for _, exception := range e.Exceptions {
+		if !exception.Expired(now) && !exception.matchesAny(exemptedResults, now) {
+			stale = append(stale, exception)
+		}
+	}
+	return stale
+}
+
+func (e Exception) matchesAny(exemptedResults []ExemptedResult, now time.Time) bool {
+	for _, exempted := range exemptedResults {
+		if e.Matches(exempted.Kind, exempted.RuleName, exempted.Object, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Exceptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Exceptions
+	e.WarnDays = DefaultExceptionWarnDays
+	if err := unmarshal((*plain)(e)); err != nil {
+		return err
+	}
+	if e.WarnDays < 0 {
+		return fmt.Errorf("warn_days for Exceptions can not be negative")
+	}
+	return nil
+}
+
+func (e *Exception) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Exception
+	if err := unmarshal((*plain)(e)); err != nil {
+		return err
+	}
+	if len(e.Rules) == 0 {
+		return fmt.Errorf("missing rules for Exception")
+	}
+	if e.Owner == "" {
+		return fmt.Errorf("missing owner for Exception")
+	}
+	if e.Ticket == "" {
+		return fmt.Errorf("missing ticket for Exception owned by %s", e.Owner)
+	}
+	if e.Expires == "" {
+		return fmt.Errorf("missing expires for Exception %s", e)
+	}
+	expiresAt, err := parseExemptUntil(e.Expires)
+	if err != nil {
+		return fmt.Errorf("invalid expires %q for Exception %s: %v", e.Expires, e, err)
+	}
+	e.expiresAt = expiresAt
+	for _, pattern := range []string{e.Namespace, e.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q for Exception %s: %v", pattern, e, err)
+		}
+	}
+	if _, err := labels.Parse(e.Selector); err != nil {
+		return fmt.Errorf("invalid selector %q for Exception %s: %v", e.Selector, e, err)
+	}
+	return nil
+}
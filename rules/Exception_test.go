@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newException(t *testing.T, test string) Exception {
+	exception := Exception{}
+	err := yaml.Unmarshal([]byte(test), &exception)
+	assert.Nil(t, err)
+	return exception
+}
+
+func TestException_UnmarshalYAML(t *testing.T) {
+	exception := newException(t, `
+kind: Pod
+namespace: legacy-*
+rules:
+- rule-a
+owner: platform-team
+ticket: SEC-1
+expires: "2019-06-30"`)
+
+	assert.Equal(t, "Pod", exception.Kind)
+	assert.Equal(t, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC), exception.ExpiresAt())
+}
+
+func TestException_UnmarshalYAML_Errors(t *testing.T) {
+	tests := []string{
+		`
+owner: platform-team
+ticket: SEC-1
+expires: "2019-06-30"`,
+		`
+rules: [rule-a]
+ticket: SEC-1
+expires: "2019-06-30"`,
+		`
+rules: [rule-a]
+owner: platform-team
+expires: "2019-06-30"`,
+		`
+rules: [rule-a]
+owner: platform-team
+ticket: SEC-1`,
+		`
+rules: [rule-a]
+owner: platform-team
+ticket: SEC-1
+expires: next week`,
+		`
+rules: [rule-a]
+owner: platform-team
+ticket: SEC-1
+expires: "2019-06-30"
+selector: app in (`,
+	}
+	for _, test := range tests {
+		exception := Exception{}
+		assert.NotNil(t, yaml.Unmarshal([]byte(test), &exception), test)
+	}
+}
+
+func TestException_Matches(t *testing.T) {
+	exception := newException(t, `
+kind: Pod
+namespace: legacy-*
+name: batch-*
+selector: tier=batch
+rules:
+- rule-a
+owner: platform-team
+ticket: SEC-1
+expires: "2019-06-30"`)
+	pod := &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "batch-1",
+		Namespace: "legacy-a",
+		Labels:    map[string]string{"tier": "batch"},
+	}}
+
+	assert.True(t, exception.Matches("Pod", "rule-a", pod, exemptionNow))
+	assert.False(t, exception.Matches("Deployment", "rule-a", pod, exemptionNow))
+	assert.False(t, exception.Matches("Pod", "rule-b", pod, exemptionNow))
+	assert.False(t, exception.Matches("Pod", "rule-a", pod, exemptionNow.AddDate(0, 1, 0)))
+	pod.Labels["tier"] = "web"
+	assert.False(t, exception.Matches("Pod", "rule-a", pod, exemptionNow))
+	pod.Labels["tier"] = "batch"
+	pod.Namespace = "default"
+	assert.False(t, exception.Matches("Pod", "rule-a", pod, exemptionNow))
+}
+
+func TestExceptions_UnmarshalYAML_DefaultWarnDays(t *testing.T) {
+	exceptions := Exceptions{}
+	err := yaml.Unmarshal([]byte(`exceptions: []`), &exceptions)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultExceptionWarnDays, exceptions.WarnDays)
+}
+
+func TestExceptions_ExpiringExpiredStale(t *testing.T) {
+	exceptions := Exceptions{}
+	err := yaml.Unmarshal([]byte(`
+warn_days: 30
+exceptions:
+- rules: [rule-a]
+  owner: platform-team
+  ticket: SEC-1
+  expires: "2019-06-30"
+- rules: [rule-b]
+  owner: platform-team
+  ticket: SEC-2
+  expires: "2019-12-31"
+- rules: [rule-c]
+  owner: platform-team
+  ticket: SEC-3
+  expires: "2019-01-31"`), &exceptions)
+	assert.Nil(t, err)
+
+	expiring := exceptions.Expiring(exemptionNow)
+	assert.Len(t, expiring, 1)
+	assert.Equal(t, "SEC-1", expiring[0].Ticket)
+
+	expired := exceptions.Expired(exemptionNow)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "SEC-3", expired[0].Ticket)
+
+	ruleResult := PodRuleResult{
+		Pods:     []apiv1.Pod{newExemptedPod("foo", nil)},
+		Reason:   "A reason",
+		RuleName: "rule-a",
+	}
+	result, exempted := ruleResult.WithoutExempted(exemptionNow, exceptions.Exceptions)
+	assert.Empty(t, result.Pods)
+	assert.Len(t, exempted, 1)
+	assert.Equal(t, "SEC-1", exempted[0].Exemption.Ticket)
+
+	stale := exceptions.Stale(exempted, exemptionNow)
+	assert.Len(t, stale, 1)
+	assert.Equal(t, "SEC-2", stale[0].Ticket)
+}
+
+func TestExceptions_Stale_AnnotationPrecedence(t *testing.T) {
+	exceptions := Exceptions{}
+	err := yaml.Unmarshal([]byte(`
+exceptions:
+- rules: [rule-a]
+  name: foo
+  owner: platform-team
+  ticket: SEC-1
+  expires: "2019-12-31"
+- rules: [rule-a]
+  namespace: default
+  owner: platform-team
+  ticket: SEC-2
+  expires: "2019-12-31"`), &exceptions)
+	assert.Nil(t, err)
+	ruleResult := PodRuleResult{
+		Pods:     []apiv1.Pod{newExemptedPod("foo", map[string]string{ExemptAnnotation: "rule-a"})},
+		Reason:   "A reason",
+		RuleName: "rule-a",
+	}
+
+	_, exempted := ruleResult.WithoutExempted(exemptionNow, exceptions.Exceptions)
+
+	assert.Nil(t, exempted[0].Exception, "the annotation takes precedence")
+	assert.Empty(t, exceptions.Stale(exempted, exemptionNow), "exceptions matching an exempted object are in use")
+}
@@ -0,0 +1,182 @@
+package rules
+
+import (
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ExemptAnnotation       = "kube-conformity.io/exempt"
+	ExemptUntilAnnotation  = "kube-conformity.io/exempt-until"
+	ExemptReasonAnnotation = "kube-conformity.io/exempt-reason"
+	exemptAllRules         = "*"
+	exemptUntilDateLayout  = "2006-01-02"
+)
+
+// Exemption is read from the exempt annotations of an object. The exempt
+// annotation holds a comma separated list of rule names, or * for every rule.
+// The exemption can be limited in time with exempt-until, holding an RFC3339
+// timestamp or a date that is exempted up to and including that day.
+type Exemption struct {
+	Rules  []string
+	Until  *time.Time
+	Reason string
+	Owner  string
+	Ticket string
+}
+
+// ExemptedResult is an object that violates a rule but is exempted from it,
+// Exception is set when the exemption comes from the exceptions file.
+type ExemptedResult struct {
+	Kind      string
+	Object    metav1.Object
+	Reason    string
+	RuleName  string
+	Severity  Severity
+	Exemption Exemption
+	Exception *Exception
+}
+
+// GetExemption returns the exemption of the object, or nil when the object is
+// not annotated or the annotations can not be parsed.
+func GetExemption(object metav1.Object) *Exemption {
+	annotations := object.GetAnnotations()
+	value, exists := annotations[ExemptAnnotation]
+	if !exists {
+		return nil
+	}
+	exemption := Exemption{Reason: annotations[ExemptReasonAnnotation]}
+	for _, rule := range strings.Split(value, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			exemption.Rules = append(exemption.Rules, rule)
+		}
+	}
+	if until, exists := annotations[ExemptUntilAnnotation]; exists {
+		parsedUntil, err := parseExemptUntil(until)
+		if err != nil {
+			return nil
+		}
+		exemption.Until = &parsedUntil
+	}
+	return &exemption
+}
+
+func parseExemptUntil(value string) (time.Time, error) {
+	until, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return until, nil
+	}
+	until, err = time.Parse(exemptUntilDateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.AddDate(0, 0, 1), nil
+}
+
+// Exempts reports whether the exemption covers the rule at the given time, an
+// expired exemption covers nothing.
+func (e Exemption) Exempts(ruleName string, now time.Time) bool {
+	return e.covers(ruleName) && !e.expired(now)
+}
+
+// Expired reports whether the exemption covered the rule but has expired.
+func (e Exemption) Expired(ruleName string, now time.Time) bool {
+	return e.covers(ruleName) && e.expired(now)
+}
+
+func (e Exemption) covers(ruleName string) bool {
+	for _, rule := range e.Rules {
+		if rule == exemptAllRules || rule == ruleName {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Exemption) expired(now time.Time) bool {
+	return e.Until != nil && !now.Before(*e.Until)
+}
+
+// partitionExempted returns the indexes of the objects that are not exempted
+// from the rule and the exempted results of the others. The annotations of an
+// object take precedence over the exceptions.
+func partitionExempted(kind, ruleName, reason string, severity Severity, objects []metav1.Object, now time.Time, exceptions []Exception) ([]int, []ExemptedResult) {
+	var kept []int
+	var exempted []ExemptedResult
+	for idx, object := range objects {
+		var exception *Exception
+		exemption := GetExemption(object)
+		if exemption == nil || !exemption.Exempts(ruleName, now) {
+			if exception = findException(exceptions, kind, ruleName, object, now); exception == nil {
+				kept = append(kept, idx)
+				continue
+			}
+			exemption = exception.Exemption()
+		}
+		exempted = append(exempted, ExemptedResult{
+			Kind:      kind,
+			Object:    object,
+			Reason:    reason,
+			RuleName:  ruleName,
+			Severity:  severity.OrDefault(),
+			Exemption: *exemption,
+			Exception: exception,
+		})
+	}
+	return kept, exempted
+}
+
+// WithoutExempted splits the pods exempted through annotations or exceptions
+// from the result.
+func (r PodRuleResult) WithoutExempted(now time.Time, exceptions []Exception) (PodRuleResult, []ExemptedResult) {
+	var objects []metav1.Object
+	for idx := range r.Pods {
+		objects = append(objects, &r.Pods[idx])
+	}
+	kept, exempted := partitionExempted("Pod", r.RuleName, r.Reason, r.Severity, objects, now, exceptions)
+	result := PodRuleResult{Reason: r.Reason, RuleName: r.RuleName, Severity: r.Severity}
+	for _, idx := range kept {
+		result.Pods = append(result.Pods, r.Pods[idx])
+	}
+	return result, exempted
+}
+
+// WithoutExempted splits the exempted deployments from the result.
+func (r DeploymentRuleResult) WithoutExempted(now time.Time, exceptions []Exception) (DeploymentRuleResult, []ExemptedResult) {
+	var objects []metav1.Object
+	for idx := range r.Deployments {
+		objects = append(objects, &r.Deployments[idx])
+	}
+	kept, exempted := partitionExempted("Deployment", r.RuleName, r.Reason, r.Severity, objects, now, exceptions)
+	result := DeploymentRuleResult{Reason: r.Reason, RuleName: r.RuleName, Severity: r.Severity}
+	for _, idx := range kept {
+		result.Deployments = append(result.Deployments, r.Deployments[idx])
+	}
+	return result, exempted
+}
+
+// WithoutExempted splits the exempted statefulsets from the result.
+func (r StatefulSetRuleResult) WithoutExempted(now time.Time, exceptions []Exception) (StatefulSetRuleResult, []ExemptedResult) {
+	var objects []metav1.Object
+	for idx := range r.StatefulSets {
+		objects = append(objects, &r.StatefulSets[idx])
+	}
+	kept, exempted := partitionExempted("StatefulSet", r.RuleName, r.Reason, r.Severity, objects, now, exceptions)
+	result := StatefulSetRuleResult{Reason: r.Reason, RuleName: r.RuleName, Severity: r.Severity}
+	for _, idx := range kept {
+		result.StatefulSets = append(result.StatefulSets, r.StatefulSets[idx])
+	}
+	return result, exempted
+}
+
+// WithoutExempted splits the exempted objects from the result.
+func (r ObjectRuleResult) WithoutExempted(now time.Time, exceptions []Exception) (ObjectRuleResult, []ExemptedResult) {
+	kept, exempted := partitionExempted(r.Kind, r.RuleName, r.Reason, r.Severity, r.Objects, now, exceptions)
+	result := ObjectRuleResult{Kind: r.Kind, Reason: r.Reason, RuleName: r.RuleName, Severity: r.Severity}
+	for _, idx := range kept {
+		result.Objects = append(result.Objects, r.Objects[idx])
+	}
+	return result, exempted
+}
@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var exemptionNow = time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func newExemptedPod(name string, annotations map[string]string) apiv1.Pod {
+	return apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			UID:         types.UID(name),
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestGetExemption(t *testing.T) {
+	pod := newExemptedPod("foo", map[string]string{
+		ExemptAnnotation:       "rule-a, rule-b",
+		ExemptUntilAnnotation:  "2019-06-30",
+		ExemptReasonAnnotation: "Migrating to a new chart",
+	})
+
+	exemption := GetExemption(&pod)
+
+	assert.NotNil(t, exemption)
+	assert.Equal(t, []string{"rule-a", "rule-b"}, exemption.Rules)
+	assert.Equal(t, "Migrating to a new chart", exemption.Reason)
+	assert.Equal(t, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC), *exemption.Until)
+}
+
+func TestGetExemption_NotAnnotated(t *testing.T) {
+	pod := newExemptedPod("foo", nil)
+	assert.Nil(t, GetExemption(&pod))
+}
+
+func TestGetExemption_InvalidUntil(t *testing.T) {
+	pod := newExemptedPod("foo", map[string]string{
+		ExemptAnnotation:      "rule-a",
+		ExemptUntilAnnotation: "next week",
+	})
+	assert.Nil(t, GetExemption(&pod))
+}
+
+func TestExemption_Exempts(t *testing.T) {
+	until := time.Date(2019, 6, 15, 13, 0, 0, 0, time.UTC)
+	exemption := Exemption{Rules: []string{"rule-a"}, Until: &until}
+
+	assert.True(t, exemption.Exempts("rule-a", exemptionNow))
+	assert.False(t, exemption.Exempts("rule-b", exemptionNow))
+	assert.False(t, exemption.Exempts("rule-a", exemptionNow.Add(time.Hour)))
+	assert.False(t, exemption.Expired("rule-a", exemptionNow))
+	assert.True(t, exemption.Expired("rule-a", exemptionNow.Add(time.Hour)))
+	assert.True(t, Exemption{Rules: []string{"*"}}.Exempts("rule-b", exemptionNow))
+}
+
+func TestPodRuleResult_WithoutExempted(t *testing.T) {
+	ruleResult := PodRuleResult{
+		Pods: []apiv1.Pod{
+			newExemptedPod("exempted", map[string]string{ExemptAnnotation: "rule-a"}),
+			newExemptedPod("expired", map[string]string{
+				ExemptAnnotation:      "rule-a",
+				ExemptUntilAnnotation: "2019-06-14",
+			}),
+			newExemptedPod("other-rule", map[string]string{ExemptAnnotation: "rule-b"}),
+			newExemptedPod("plain", nil),
+		},
+		Reason:   "A reason",
+		RuleName: "rule-a",
+	}
+
+	result, exempted := ruleResult.WithoutExempted(exemptionNow, nil)
+
+	assert.Len(t, result.Pods, 3)
+	assert.Equal(t, "expired", result.Pods[0].Name)
+	assert.Len(t, exempted, 1)
+	assert.Equal(t, "exempted", exempted[0].Object.GetName())
+	assert.Equal(t, "Pod", exempted[0].Kind)
+	assert.Equal(t, "A reason", exempted[0].Reason)
+}
+
+func TestObjectRuleResult_WithoutExempted(t *testing.T) {
+	exemptedPod := newExemptedPod("exempted", map[string]string{ExemptAnnotation: "*"})
+	ruleResult := ObjectRuleResult{
+		Kind:     "Pod",
+		Objects:  []metav1.Object{&exemptedPod},
+		Reason:   "A reason",
+		RuleName: "rule-a",
+	}
+
+	result, exempted := ruleResult.WithoutExempted(exemptionNow, nil)
+
+	assert.Empty(t, result.Objects)
+	assert.Len(t, exempted, 1)
+}
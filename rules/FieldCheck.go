@@ -0,0 +1,291 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	OperatorExists    = "exists"
+	OperatorNotExists = "notExists"
+	OperatorEquals    = "equals"
+	OperatorNotEquals = "notEquals"
+	OperatorIn        = "in"
+	OperatorNotIn     = "notIn"
+	OperatorMatches   = "matches"
+	OperatorGte       = "gte"
+	OperatorLte       = "lte"
+)
+
+var fieldOperators = []string{
+	OperatorExists, OperatorNotExists, OperatorEquals, OperatorNotEquals,
+	OperatorIn, OperatorNotIn, OperatorMatches, OperatorGte, OperatorLte,
+}
+
+// FieldCheck asserts on the values a JSONPath selects in an object. When the
+// path selects multiple values, for example every container of a pod, all of
+// them have to pass and a value missing in one of them fails the check, unless
+// the field is optional. Equals is a shorthand for the equals operator.
+type FieldCheck struct {
+	Path     string        `yaml:"path"`
+	Operator string        `yaml:"operator"`
+	Value    interface{}   `yaml:"value"`
+	Values   []interface{} `yaml:"values"`
+	Equals   interface{}   `yaml:"equals"`
+	Optional bool          `yaml:"optional"`
+}
+
+// jsonPathTemplate turns a path like spec.template.spec.priorityClassName into
+// the {.spec.template.spec.priorityClassName} template the jsonpath package
+// expects. Paths that already are a template are left untouched.
+func jsonPathTemplate(path string) string {
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if strings.HasPrefix(path, "[") {
+		return "{" + path + "}"
+	}
+	return "{." + strings.TrimPrefix(path, ".") + "}"
+}
+
+// splitPath splits a path after its first list selector, like
+// spec.containers[*] and resources.limits.cpu. The rest is empty when the path
+// has no list selector followed by more fields, or is a template with more
+// than a single expression.
+func splitPath(path string) (string, string) {
+	if strings.HasPrefix(path, "{") {
+		if !strings.HasSuffix(path, "}") || strings.Count(path, "{") > 1 {
+			return path, ""
+		}
+		path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	}
+	start := strings.Index(path, "[")
+	if start < 0 {
+		return path, ""
+	}
+	depth := 0
+	for idx := start; idx < len(path); idx++ {
+		switch path[idx] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			return path[:idx+1], strings.TrimPrefix(path[idx+1:], ".")
+		}
+	}
+	return path, ""
+}
+
+// findFieldValues returns all values the path selects in the object and
+// whether the path is missing in the object or in one of the elements a list
+// selector of the path selects, like a container without limits.
+func findFieldValues(path string, object interface{}) ([]interface{}, bool, error) {
+	list, rest := splitPath(path)
+	if rest == "" {
+		values, err := FindFieldValues(path, object)
+		return values, len(values) == 0, err
+	}
+	elements, err := FindFieldValues(list, object)
+	if err != nil || len(elements) == 0 {
+		return nil, true, err
+	}
+	var values []interface{}
+	missing := false
+	for _, element := range elements {
+		elementValues, elementMissing, err := findFieldValues(rest, element)
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, elementValues...)
+		missing = missing || elementMissing
+	}
+	return values, missing, nil
+}
+
+// FindFieldValues returns all values the path selects in the object, an empty
+// slice means the field is not present.
+func FindFieldValues(path string, object interface{}) ([]interface{}, error) {
+	parser := jsonpath.New(path)
+	parser.AllowMissingKeys(true)
+	if err := parser.Parse(jsonPathTemplate(path)); err != nil {
+		return nil, err
+	}
+	results, err := parser.FindResults(object)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
+
+func (c FieldCheck) operator() string {
+	if c.Operator != "" {
+		return c.Operator
+	}
+	if c.Equals != nil {
+		return OperatorEquals
+	}
+	return OperatorExists
+}
+
+func (c FieldCheck) value() interface{} {
+	if c.Value == nil {
+		return c.Equals
+	}
+	return c.Value
+}
+
+// Violation returns the reason the object does not pass the check, or an empty
+// string when it does.
+func (c FieldCheck) Violation(object map[string]interface{}) (string, error) {
+	values, missing, err := findFieldValues(c.Path, object)
+	if err != nil {
+		return "", err
+	}
+	operator := c.operator()
+	if operator == OperatorNotExists {
+		if len(values) > 0 {
+			return fmt.Sprintf("Field %s is present", c.Path), nil
+		}
+		return "", nil
+	}
+	if missing && !c.Optional {
+		return fmt.Sprintf("Field %s is not present", c.Path), nil
+	}
+	for _, value := range values {
+		passes, err := c.passes(operator, value)
+		if err != nil {
+			return "", err
+		}
+		if !passes {
+			return c.reason(operator), nil
+		}
+	}
+	return "", nil
+}
+
+func (c FieldCheck) passes(operator string, value interface{}) (bool, error) {
+	switch operator {
+	case OperatorEquals:
+		return fmt.Sprint(value) == fmt.Sprint(c.value()), nil
+	case OperatorNotEquals:
+		return fmt.Sprint(value) != fmt.Sprint(c.value()), nil
+	case OperatorIn, OperatorNotIn:
+		in := false
+		for _, allowed := range c.Values {
+			in = in || fmt.Sprint(value) == fmt.Sprint(allowed)
+		}
+		return in == (operator == OperatorIn), nil
+	case OperatorMatches:
+		return regexp.MatchString(fmt.Sprint(c.value()), fmt.Sprint(value))
+	case OperatorGte, OperatorLte:
+		comparison, err := compareValues(value, c.value())
+		if err != nil {
+			return false, fmt.Errorf("field %s: %v", c.Path, err)
+		}
+		if operator == OperatorGte {
+			return comparison >= 0, nil
+		}
+		return comparison <= 0, nil
+	}
+	return true, nil
+}
+
+func (c FieldCheck) reason(operator string) string {
+	switch operator {
+	case OperatorEquals:
+		return fmt.Sprintf("Field %s is not equal to %v", c.Path, c.value())
+	case OperatorNotEquals:
+		return fmt.Sprintf("Field %s is equal to %v", c.Path, c.value())
+	case OperatorIn:
+		return fmt.Sprintf("Field %s is not one of %v", c.Path, c.Values)
+	case OperatorNotIn:
+		return fmt.Sprintf("Field %s is one of %v", c.Path, c.Values)
+	case OperatorMatches:
+		return fmt.Sprintf("Field %s does not match %v", c.Path, c.value())
+	case OperatorGte:
+		return fmt.Sprintf("Field %s is lower than %v", c.Path, c.value())
+	case OperatorLte:
+		return fmt.Sprintf("Field %s is greater than %v", c.Path, c.value())
+	}
+	return fmt.Sprintf("Field %s is not present", c.Path)
+}
+
+// compareValues compares two values as numbers, falling back to resource
+// quantities so limits like 500m or 2Gi can be compared as well.
+func compareValues(value, reference interface{}) (int, error) {
+	valueNumber, valueErr := strconv.ParseFloat(fmt.Sprint(value), 64)
+	referenceNumber, referenceErr := strconv.ParseFloat(fmt.Sprint(reference), 64)
+	if valueErr == nil && referenceErr == nil {
+		switch {
+		case valueNumber < referenceNumber:
+			return -1, nil
+		case valueNumber > referenceNumber:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	valueQuantity, err := resource.ParseQuantity(fmt.Sprint(value))
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a number or quantity", value)
+	}
+	referenceQuantity, err := resource.ParseQuantity(fmt.Sprint(reference))
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a number or quantity", reference)
+	}
+	return valueQuantity.Cmp(referenceQuantity), nil
+}
+
+func (c *FieldCheck) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain FieldCheck
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Path == "" {
+		return fmt.Errorf("missing path for FieldCheck")
+	}
+	if err := jsonpath.New(c.Path).Parse(jsonPathTemplate(c.Path)); err != nil {
+		return fmt.Errorf("invalid path %s for FieldCheck: %v", c.Path, err)
+	}
+	operator := c.operator()
+	validOperator := false
+	for _, fieldOperator := range fieldOperators {
+		validOperator = validOperator || operator == fieldOperator
+	}
+	if !validOperator {
+		return fmt.Errorf("invalid operator %s for FieldCheck %s, must be one of %v", operator, c.Path, fieldOperators)
+	}
+	switch operator {
+	case OperatorIn, OperatorNotIn:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("missing values for operator %s of FieldCheck %s", operator, c.Path)
+		}
+	case OperatorEquals, OperatorNotEquals, OperatorMatches, OperatorGte, OperatorLte:
+		if c.value() == nil {
+			return fmt.Errorf("missing value for operator %s of FieldCheck %s", operator, c.Path)
+		}
+	}
+	if operator == OperatorMatches {
+		if _, err := regexp.Compile(fmt.Sprint(c.value())); err != nil {
+			return fmt.Errorf("invalid regex for FieldCheck %s: %v", c.Path, err)
+		}
+	}
+	if operator == OperatorGte || operator == OperatorLte {
+		if _, err := compareValues(c.value(), c.value()); err != nil {
+			return fmt.Errorf("invalid value for operator %s of FieldCheck %s: %v", operator, c.Path, err)
+		}
+	}
+	return nil
+}
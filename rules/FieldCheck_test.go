@@ -0,0 +1,296 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+var deploymentContent = map[string]interface{}{
+	"spec": map[string]interface{}{
+		"replicas": int64(2),
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"serviceAccountName": "default",
+				"containers": []interface{}{
+					map[string]interface{}{"name": "app", "image": "app:1.0"},
+					map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0"},
+				},
+			},
+		},
+	},
+}
+
+func TestFindFieldValues(t *testing.T) {
+	values, err := FindFieldValues("spec.template.spec.containers[*].name", deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"app", "sidecar"}, values)
+}
+
+func TestFindFieldValues_Template(t *testing.T) {
+	values, err := FindFieldValues("{.spec.replicas}", deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(2)}, values)
+}
+
+func TestFindFieldValues_Missing(t *testing.T) {
+	values, err := FindFieldValues("spec.template.spec.priorityClassName", deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Len(t, values, 0)
+}
+
+func TestFieldCheck_Violation_Present(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.serviceAccountName"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_NotPresent(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.priorityClassName"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.priorityClassName is not present", reason)
+}
+
+func TestFieldCheck_Violation_Equals(t *testing.T) {
+	check := FieldCheck{Path: "spec.replicas", Equals: 2}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_NotEquals(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.containers[*].image", Equals: "app:1.0"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.containers[*].image is not equal to app:1.0", reason)
+}
+
+func TestFieldCheck_Violation_NotExists(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.serviceAccountName", Operator: OperatorNotExists}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.serviceAccountName is present", reason)
+}
+
+func TestFieldCheck_Violation_NotExists_Missing(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.priorityClassName", Operator: OperatorNotExists}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+var limitsContent = map[string]interface{}{
+	"spec": map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}}},
+			map[string]interface{}{"name": "sidecar"},
+		},
+	},
+}
+
+func TestFieldCheck_Violation_MissingInElement(t *testing.T) {
+	check := FieldCheck{Path: "spec.containers[*].resources.limits.cpu", Operator: OperatorLte, Value: "1"}
+
+	reason, err := check.Violation(limitsContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.containers[*].resources.limits.cpu is not present", reason)
+}
+
+func TestFieldCheck_Violation_MissingInElementTemplate(t *testing.T) {
+	check := FieldCheck{Path: "{.spec.containers[*].resources.limits.cpu}", Operator: OperatorExists}
+
+	reason, err := check.Violation(limitsContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field {.spec.containers[*].resources.limits.cpu} is not present", reason)
+}
+
+func TestFieldCheck_Violation_Optional(t *testing.T) {
+	check := FieldCheck{Path: "spec.containers[*].resources.limits.cpu", Operator: OperatorLte, Value: "1", Optional: true}
+
+	reason, err := check.Violation(limitsContent)
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+
+	check.Value = "100m"
+	reason, err = check.Violation(limitsContent)
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.containers[*].resources.limits.cpu is greater than 100m", reason)
+
+	check.Path = "spec.template.spec.priorityClassName"
+	reason, err = check.Violation(deploymentContent)
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_NotEqualsOperator(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.serviceAccountName", Operator: OperatorNotEquals, Value: "default"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.serviceAccountName is equal to default", reason)
+}
+
+func TestFieldCheck_Violation_In(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.containers[*].name", Operator: OperatorIn, Values: []interface{}{"app", "sidecar"}}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_NotIn(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.containers[*].name", Operator: OperatorNotIn, Values: []interface{}{"sidecar"}}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.containers[*].name is one of [sidecar]", reason)
+}
+
+func TestFieldCheck_Violation_Matches(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.containers[*].image", Operator: OperatorMatches, Value: ":[0-9.]+$"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_NotMatches(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.containers[*].image", Operator: OperatorMatches, Value: "^registry.local/"}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.template.spec.containers[*].image does not match ^registry.local/", reason)
+}
+
+func TestFieldCheck_Violation_Gte(t *testing.T) {
+	check := FieldCheck{Path: "spec.replicas", Operator: OperatorGte, Value: 3}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field spec.replicas is lower than 3", reason)
+}
+
+func TestFieldCheck_Violation_Lte(t *testing.T) {
+	check := FieldCheck{Path: "spec.replicas", Operator: OperatorLte, Value: 2}
+
+	reason, err := check.Violation(deploymentContent)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", reason)
+}
+
+func TestFieldCheck_Violation_LteQuantity(t *testing.T) {
+	content := map[string]interface{}{"limits": map[string]interface{}{"memory": "2Gi"}}
+	check := FieldCheck{Path: "limits.memory", Operator: OperatorLte, Value: "1Gi"}
+
+	reason, err := check.Violation(content)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Field limits.memory is greater than 1Gi", reason)
+}
+
+func TestFieldCheck_Violation_GteNotANumber(t *testing.T) {
+	check := FieldCheck{Path: "spec.template.spec.serviceAccountName", Operator: OperatorGte, Value: 1}
+
+	_, err := check.Violation(deploymentContent)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldCheck_UnmarshalYAML_InvalidOperator(t *testing.T) {
+	yamlString := `
+path: spec.replicas
+operator: contains
+value: 2`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldCheck_UnmarshalYAML_MissingValues(t *testing.T) {
+	yamlString := `
+path: spec.replicas
+operator: in`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldCheck_UnmarshalYAML_InvalidRegex(t *testing.T) {
+	yamlString := `
+path: spec.replicas
+operator: matches
+value: "["`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldCheck_UnmarshalYAML(t *testing.T) {
+	yamlString := `
+path: spec.replicas
+equals: 2`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "spec.replicas", check.Path)
+	assert.Equal(t, 2, check.Equals)
+}
+
+func TestFieldCheck_UnmarshalYAML_MissingPath(t *testing.T) {
+	yamlString := `equals: 2`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldCheck_UnmarshalYAML_InvalidPath(t *testing.T) {
+	yamlString := `path: spec.containers[`
+
+	check := FieldCheck{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &check)
+
+	assert.NotNil(t, err)
+}
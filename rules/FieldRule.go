@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/stijndehaes/kube-conformity/filters"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type FieldRule struct {
+	Name     string               `yaml:"name"`
+	Severity Severity             `yaml:"severity"`
+	Kind     string               `yaml:"kind"`
+	Fields   []FieldCheck         `yaml:"fields"`
+	Filter   filters.ObjectFilter `yaml:"filter"`
+}
+
+func (r FieldRule) FindNonConformingObjects(objects []metav1.Object) ([]ObjectRuleResult, error) {
+	objectsByReason := make(map[string][]metav1.Object)
+	for _, object := range r.Filter.FilterObjects(objects) {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range r.Fields {
+			reason, err := field.Violation(content)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				objectsByReason[reason] = append(objectsByReason[reason], object)
+			}
+		}
+	}
+
+	return newObjectRuleResults(r.Kind, r.Name, r.Severity, objectsByReason), nil
+}
+
+func (r *FieldRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain FieldRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.Name == "" {
+		return fmt.Errorf("missing name for FieldRule")
+	}
+	validKind := false
+	for _, kind := range objectKinds {
+		validKind = validKind || r.Kind == kind
+	}
+	if !validKind {
+		return fmt.Errorf("invalid kind %q for FieldRule %s, must be one of %v", r.Kind, r.Name, objectKinds)
+	}
+	if len(r.Fields) == 0 {
+		return fmt.Errorf("missing fields for FieldRule %s", r.Name)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPodWithServiceAccount(namespace, name, serviceAccountName string, automountToken *bool) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName:           serviceAccountName,
+			AutomountServiceAccountToken: automountToken,
+		},
+	}
+}
+
+func TestFieldRule_FindNonConformingObjects(t *testing.T) {
+	automount := false
+	rule := FieldRule{
+		Name: "service account hygiene",
+		Kind: "Pod",
+		Fields: []FieldCheck{
+			{Path: "spec.serviceAccountName", Operator: OperatorNotEquals, Value: "default"},
+			{Path: "spec.automountServiceAccountToken", Operator: OperatorEquals, Value: false},
+		},
+	}
+	pod1 := newPodWithServiceAccount("default", "foo", "default", nil)
+	pod2 := newPodWithServiceAccount("default", "bar", "bar", &automount)
+
+	results, err := rule.FindNonConformingObjects([]metav1.Object{&pod1, &pod2})
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Field spec.automountServiceAccountToken is not present", results[0].Reason)
+	assert.Equal(t, "Field spec.serviceAccountName is equal to default", results[1].Reason)
+	assert.Equal(t, "Pod", results[1].Kind)
+	assert.Len(t, results[1].Objects, 1)
+	assert.Equal(t, "foo", results[1].Objects[0].GetName())
+}
+
+func TestFieldRule_FindNonConformingObjects_Filter(t *testing.T) {
+	rule := FieldRule{
+		Name:   "priority class",
+		Kind:   "Pod",
+		Fields: []FieldCheck{{Path: "spec.priorityClassName"}},
+	}
+	rule.Filter.IncludeNamespaces = []string{"production"}
+	pod := newPodWithServiceAccount("default", "foo", "default", nil)
+
+	results, err := rule.FindNonConformingObjects([]metav1.Object{&pod})
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 0)
+}
+
+func TestFieldRule_UnmarshalYAML(t *testing.T) {
+	yamlString := `
+name: priority class
+kind: Deployment
+fields:
+- path: spec.template.spec.priorityClassName
+  operator: in
+  values:
+  - high
+  - low`
+
+	rule := FieldRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.Nil(t, err)
+	assert.Len(t, rule.Fields, 1)
+	assert.Equal(t, OperatorIn, rule.Fields[0].Operator)
+}
+
+func TestFieldRule_UnmarshalYAML_InvalidKind(t *testing.T) {
+	yamlString := `
+name: priority class
+kind: Service
+fields:
+- path: spec.clusterIP`
+
+	rule := FieldRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldRule_UnmarshalYAML_MissingFields(t *testing.T) {
+	yamlString := `
+name: priority class
+kind: Pod`
+
+	rule := FieldRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldRule_UnmarshalYAML_InvalidFilter(t *testing.T) {
+	yamlString := `
+name: priority class
+kind: Pod
+fields:
+- path: spec.priorityClassName
+filter:
+  include_selector: app in (`
+
+	rule := FieldRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
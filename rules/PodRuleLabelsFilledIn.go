@@ -7,9 +7,10 @@ import (
 )
 
 type PodRuleLabelsFilledIn struct {
-	Name   string            `yaml:"name"`
-	Labels []string          `yaml:"labels"`
-	Filter filters.PodFilter `yaml:"filter"`
+	Name     string            `yaml:"name"`
+	Severity Severity          `yaml:"severity"`
+	Labels   []string          `yaml:"labels"`
+	Filter   filters.PodFilter `yaml:"filter"`
 }
 
 func (r PodRuleLabelsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleResult {
@@ -34,6 +35,7 @@ func (r PodRuleLabelsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleResul
 		Pods:     nonConformingPods,
 		Reason:   fmt.Sprintf("Labels: %v are not filled in", r.Labels),
 		RuleName: r.Name,
+		Severity: r.Severity.OrDefault(),
 	}
 }
 
@@ -7,8 +7,9 @@ import (
 )
 
 type PodRuleLimitsFilledIn struct {
-	Name   string            `yaml:"name"`
-	Filter filters.PodFilter `yaml:"filter"`
+	Name     string            `yaml:"name"`
+	Severity Severity          `yaml:"severity"`
+	Filter   filters.PodFilter `yaml:"filter"`
 }
 
 func (r PodRuleLimitsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleResult {
@@ -31,6 +32,7 @@ func (r PodRuleLimitsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleResul
 		Pods:     nonConformingPods,
 		Reason:   "Limits are not filled in",
 		RuleName: r.Name,
+		Severity: r.Severity.OrDefault(),
 	}
 }
 
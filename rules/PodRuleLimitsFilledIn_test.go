@@ -54,7 +54,8 @@ func newPodWithLimits(namespace, name string, uid types.UID, limitCpu, limitMemo
 func TestPodRuleLimitsFilledIn_UnmarshalYAML_NameNotFilledIn(t *testing.T) {
 	yamlString := `
 filter:
-  namespaces: test`
+  include_namespaces:
+  - test`
 
 	rule := PodRuleLimitsFilledIn{}
 
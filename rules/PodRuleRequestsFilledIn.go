@@ -7,8 +7,9 @@ import (
 )
 
 type PodRuleRequestsFilledIn struct {
-	Name   string            `yaml:"name"`
-	Filter filters.PodFilter `yaml:"filter"`
+	Name     string            `yaml:"name"`
+	Severity Severity          `yaml:"severity"`
+	Filter   filters.PodFilter `yaml:"filter"`
 }
 
 func (r PodRuleRequestsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleResult {
@@ -30,6 +31,7 @@ func (r PodRuleRequestsFilledIn) FindNonConformingPods(pods []v1.Pod) PodRuleRes
 		Pods:     nonConformingPods,
 		Reason:   "Requests are not filled in",
 		RuleName: r.Name,
+		Severity: r.Severity.OrDefault(),
 	}
 }
 
@@ -54,7 +54,8 @@ func newPodWithRequests(namespace, name string, uid types.UID, requestCpu, reque
 func TestPodRuleRequestsFilledIn_UnmarshalYAML_NameNotFilledIn(t *testing.T) {
 	yamlString := `
 filter:
-  namespaces: test`
+  include_namespaces:
+  - test`
 
 	rule := PodRuleRequestsFilledIn{}
 
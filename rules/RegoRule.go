@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stijndehaes/kube-conformity/filters"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const DefaultRegoPackage = "kubeconformity"
+
+type ConfigMapReference struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+type RegoRule struct {
+	Name       string                 `yaml:"name"`
+	Severity   Severity               `yaml:"severity"`
+	Kind       string                 `yaml:"kind"`
+	Package    string                 `yaml:"package"`
+	Modules    []string               `yaml:"modules"`
+	ConfigMap  *ConfigMapReference    `yaml:"config_map"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+	Filter     filters.ObjectFilter   `yaml:"filter"`
+}
+
+// LoadModules reads the rego modules referenced by the rule from disk. Paths
+// can point to a single file or to a directory, in which case every .rego
+// file that is not a test file is loaded.
+func (r RegoRule) LoadModules() (map[string]string, error) {
+	modules := make(map[string]string)
+	for _, path := range r.Modules {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.rego"))
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.rego") {
+				continue
+			}
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			modules[file] = string(content)
+		}
+	}
+	return modules, nil
+}
+
+// ConfigMapModules returns every .rego entry of a ConfigMap's data.
+func ConfigMapModules(ref ConfigMapReference, data map[string]string) map[string]string {
+	modules := make(map[string]string)
+	for key, content := range data {
+		if strings.HasSuffix(key, ".rego") && !strings.HasSuffix(key, "_test.rego") {
+			modules[ref.Namespace+"/"+ref.Name+"/"+key] = content
+		}
+	}
+	return modules
+}
+
+// FindNonConformingObjects evaluates the violation rule of the rule's package
+// against each object. The input document mirrors the one Gatekeeper offers,
+// so existing constraint templates can be reused: the object is available as
+// input.review.object and the rule parameters as input.parameters. Objects
+// are grouped per violation message, every message becomes a result.
+func (r RegoRule) FindNonConformingObjects(modules map[string]string, objects []metav1.Object) ([]ObjectRuleResult, error) {
+	compiler, err := ast.CompileModules(modules)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	query, err := rego.New(
+		rego.Query(fmt.Sprintf("data.%s.violation", r.regoPackage())),
+		rego.Compiler(compiler),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objectsByMessage := make(map[string][]metav1.Object)
+	for _, object := range r.Filter.FilterObjects(objects) {
+		input, err := r.regoInput(object)
+		if err != nil {
+			return nil, err
+		}
+		resultSet, err := query.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range violationMessages(resultSet) {
+			objectsByMessage[message] = append(objectsByMessage[message], object)
+		}
+	}
+
+	return newObjectRuleResults(r.Kind, r.Name, r.Severity, objectsByMessage), nil
+}
+
+func (r RegoRule) regoPackage() string {
+	if r.Package == "" {
+		return DefaultRegoPackage
+	}
+	return r.Package
+}
+
+func (r RegoRule) regoInput(object metav1.Object) (map[string]interface{}, error) {
+	unstructuredObject, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := unstructuredObject["kind"]; !exists {
+		unstructuredObject["kind"] = r.Kind
+	}
+	parameters := map[string]interface{}{}
+	for key, value := range r.Parameters {
+		parameters[key] = normalizeYAMLValue(value)
+	}
+	return map[string]interface{}{
+		"review": map[string]interface{}{
+			"kind":      map[string]interface{}{"kind": r.Kind},
+			"name":      object.GetName(),
+			"namespace": object.GetNamespace(),
+			"object":    unstructuredObject,
+		},
+		"parameters": parameters,
+	}, nil
+}
+
+// normalizeYAMLValue converts the map[interface{}]interface{} values yaml.v2
+// produces into map[string]interface{} so they can be used as rego input.
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{})
+		for key, nested := range typed {
+			normalized[fmt.Sprint(key)] = normalizeYAMLValue(nested)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(typed))
+		for idx, nested := range typed {
+			normalized[idx] = normalizeYAMLValue(nested)
+		}
+		return normalized
+	}
+	return value
+}
+
+func violationMessages(resultSet rego.ResultSet) []string {
+	var messages []string
+	for _, result := range resultSet {
+		for _, expression := range result.Expressions {
+			violations, ok := expression.Value.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, violation := range violations {
+				if fields, ok := violation.(map[string]interface{}); ok {
+					if message, ok := fields["msg"].(string); ok {
+						messages = append(messages, message)
+					}
+				}
+			}
+		}
+	}
+	return messages
+}
+
+func (r *RegoRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain RegoRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.Name == "" {
+		return fmt.Errorf("missing name for RegoRule")
+	}
+	validKind := false
+	for _, kind := range objectKinds {
+		validKind = validKind || r.Kind == kind
+	}
+	if !validKind {
+		return fmt.Errorf("invalid kind %q for RegoRule %s, must be one of %v", r.Kind, r.Name, objectKinds)
+	}
+	if len(r.Modules) == 0 && r.ConfigMap == nil {
+		return fmt.Errorf("missing modules or config_map for RegoRule %s", r.Name)
+	}
+	if r.ConfigMap != nil && (r.ConfigMap.Namespace == "" || r.ConfigMap.Name == "") {
+		return fmt.Errorf("config_map for RegoRule %s needs a namespace and a name", r.Name)
+	}
+	return nil
+}
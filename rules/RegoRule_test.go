@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/tester"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRequiredLabelsRegoRule() RegoRule {
+	return RegoRule{
+		Name:       "required labels",
+		Kind:       "Pod",
+		Modules:    []string{"testdata/rego"},
+		Parameters: map[string]interface{}{"labels": []interface{}{"app"}},
+	}
+}
+
+func TestRegoRule_LoadModules(t *testing.T) {
+	rule := newRequiredLabelsRegoRule()
+
+	modules, err := rule.LoadModules()
+
+	assert.Nil(t, err)
+	assert.Len(t, modules, 1)
+	assert.Contains(t, modules, "testdata/rego/required_labels.rego")
+}
+
+func TestRegoRule_LoadModules_NotExisting(t *testing.T) {
+	rule := newRequiredLabelsRegoRule()
+	rule.Modules = []string{"testdata/not-existing.rego"}
+
+	_, err := rule.LoadModules()
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigMapModules(t *testing.T) {
+	data := map[string]string{
+		"labels.rego":      "package kubeconformity",
+		"labels_test.rego": "package kubeconformity",
+		"README.md":        "docs",
+	}
+
+	modules := ConfigMapModules(ConfigMapReference{Namespace: "policies", Name: "rego"}, data)
+
+	assert.Len(t, modules, 1)
+	assert.Equal(t, "package kubeconformity", modules["policies/rego/labels.rego"])
+}
+
+func TestRegoRule_Policies(t *testing.T) {
+	results, err := tester.Run(context.Background(), "testdata/rego")
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.Pass(), result.String())
+	}
+}
+
+func TestRegoRule_FindNonConformingObjects(t *testing.T) {
+	rule := newRequiredLabelsRegoRule()
+	modules, _ := rule.LoadModules()
+	pod1 := newPodWithLabels("default", "foo", "uid1", []string{})
+	pod2 := newPodWithLabels("default", "bar", "uid2", []string{"app"})
+	objects := []metav1.Object{&pod1, &pod2}
+
+	results, err := rule.FindNonConformingObjects(modules, objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "missing required labels: app", results[0].Reason)
+	assert.Equal(t, "required labels", results[0].RuleName)
+	assert.Equal(t, "Pod", results[0].Kind)
+	assert.Len(t, results[0].Objects, 1)
+	assert.Equal(t, "foo", results[0].Objects[0].GetName())
+}
+
+func TestRegoRule_FindNonConformingObjects_Filter(t *testing.T) {
+	rule := newRequiredLabelsRegoRule()
+	rule.Filter.ExcludeNamespaces = []string{"kube-system"}
+	modules, _ := rule.LoadModules()
+	pod := newPodWithLabels("kube-system", "foo", "uid1", []string{})
+
+	results, err := rule.FindNonConformingObjects(modules, []metav1.Object{&pod})
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 0)
+}
+
+func TestRegoRule_FindNonConformingObjects_InvalidModule(t *testing.T) {
+	rule := newRequiredLabelsRegoRule()
+	modules := map[string]string{"invalid.rego": "package"}
+
+	_, err := rule.FindNonConformingObjects(modules, nil)
+
+	assert.NotNil(t, err)
+}
+
+func TestRegoRule_UnmarshalYAML(t *testing.T) {
+	yamlString := `
+name: required labels
+kind: Pod
+modules:
+- policies/required_labels.rego
+parameters:
+  labels:
+  - app`
+
+	rule := RegoRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Pod", rule.Kind)
+	assert.Len(t, rule.Modules, 1)
+	assert.Equal(t, DefaultRegoPackage, rule.regoPackage())
+}
+
+func TestRegoRule_UnmarshalYAML_InvalidKind(t *testing.T) {
+	yamlString := `
+name: required labels
+kind: Service
+modules:
+- policies/required_labels.rego`
+
+	rule := RegoRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestRegoRule_UnmarshalYAML_MissingModules(t *testing.T) {
+	yamlString := `
+name: required labels
+kind: Pod`
+
+	rule := RegoRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestRegoRule_UnmarshalYAML_IncompleteConfigMap(t *testing.T) {
+	yamlString := `
+name: required labels
+kind: Pod
+config_map:
+  name: policies`
+
+	rule := RegoRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
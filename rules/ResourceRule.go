@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/stijndehaes/kube-conformity/filters"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type ResourceSelector struct {
+	Group   string `yaml:"group"`
+	Version string `yaml:"version"`
+	Kind    string `yaml:"kind"`
+}
+
+func (s ResourceSelector) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: s.Group, Version: s.Version, Kind: s.Kind}
+}
+
+type ResourceRule struct {
+	Name        string               `yaml:"name"`
+	Severity    Severity             `yaml:"severity"`
+	Resource    ResourceSelector     `yaml:"resource"`
+	Labels      []string             `yaml:"labels"`
+	Annotations []string             `yaml:"annotations"`
+	Fields      []FieldCheck         `yaml:"fields"`
+	CEL         string               `yaml:"cel"`
+	Filter      filters.ObjectFilter `yaml:"filter"`
+}
+
+func compileCEL(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("object", decls.NewMapType(decls.String, decls.Dyn)),
+	))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	return env.Program(ast)
+}
+
+// FindNonConformingObjects runs every check of the rule against the objects.
+// An object can fail several checks, each failing check becomes a result.
+func (r ResourceRule) FindNonConformingObjects(objects []unstructured.Unstructured) ([]ObjectRuleResult, error) {
+	var program cel.Program
+	if r.CEL != "" {
+		var err error
+		if program, err = compileCEL(r.CEL); err != nil {
+			return nil, err
+		}
+	}
+
+	var filterObjects []metav1.Object
+	for idx := range objects {
+		filterObjects = append(filterObjects, &objects[idx])
+	}
+
+	objectsByReason := make(map[string][]metav1.Object)
+	for _, object := range r.Filter.FilterObjects(filterObjects) {
+		content := object.(*unstructured.Unstructured).Object
+		var reasons []string
+		if missing := missingKeys(r.Labels, object.GetLabels()); len(missing) > 0 {
+			reasons = append(reasons, fmt.Sprintf("Labels: %v are not filled in", missing))
+		}
+		if missing := missingKeys(r.Annotations, object.GetAnnotations()); len(missing) > 0 {
+			reasons = append(reasons, fmt.Sprintf("Annotations: %v are not filled in", missing))
+		}
+		for _, field := range r.Fields {
+			reason, err := field.Violation(content)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				reasons = append(reasons, reason)
+			}
+		}
+		if program != nil {
+			out, _, err := program.Eval(map[string]interface{}{"object": content})
+			if err != nil {
+				reasons = append(reasons, fmt.Sprintf("CEL expression %q could not be evaluated: %v", r.CEL, err))
+			} else if satisfied, ok := out.Value().(bool); !ok || !satisfied {
+				reasons = append(reasons, fmt.Sprintf("CEL expression %q is not satisfied", r.CEL))
+			}
+		}
+		for _, reason := range reasons {
+			objectsByReason[reason] = append(objectsByReason[reason], object)
+		}
+	}
+
+	return newObjectRuleResults(r.Resource.Kind, r.Name, r.Severity, objectsByReason), nil
+}
+
+func missingKeys(keys []string, values map[string]string) []string {
+	var missing []string
+	for _, key := range keys {
+		if _, exists := values[key]; !exists {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func (r *ResourceRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ResourceRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.Name == "" {
+		return fmt.Errorf("missing name for ResourceRule")
+	}
+	if r.Resource.Version == "" || r.Resource.Kind == "" {
+		return fmt.Errorf("missing resource version or kind for ResourceRule %s", r.Name)
+	}
+	if len(r.Labels) == 0 && len(r.Annotations) == 0 && len(r.Fields) == 0 && r.CEL == "" {
+		return fmt.Errorf("missing labels, annotations, fields or cel for ResourceRule %s", r.Name)
+	}
+	if r.CEL != "" {
+		if _, err := compileCEL(r.CEL); err != nil {
+			return fmt.Errorf("invalid cel expression for ResourceRule %s: %v", r.Name, err)
+		}
+	}
+	return nil
+}
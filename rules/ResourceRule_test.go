@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newUnstructured(namespace, name string, labels map[string]interface{}, spec map[string]interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+			"labels":    labels,
+		},
+		"spec": spec,
+	}}
+}
+
+func TestResourceRule_FindNonConformingObjects_Labels(t *testing.T) {
+	rule := ResourceRule{
+		Name:     "owner label",
+		Resource: ResourceSelector{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+		Labels:   []string{"owner"},
+	}
+	objects := []unstructured.Unstructured{
+		newUnstructured("default", "foo", map[string]interface{}{}, nil),
+		newUnstructured("default", "bar", map[string]interface{}{"owner": "team-a"}, nil),
+	}
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Labels: [owner] are not filled in", results[0].Reason)
+	assert.Equal(t, "Certificate", results[0].Kind)
+	assert.Len(t, results[0].Objects, 1)
+	assert.Equal(t, "foo", results[0].Objects[0].GetName())
+}
+
+func TestResourceRule_FindNonConformingObjects_Annotations(t *testing.T) {
+	rule := ResourceRule{
+		Name:        "description annotation",
+		Annotations: []string{"description"},
+	}
+	objects := []unstructured.Unstructured{
+		newUnstructured("default", "foo", nil, nil),
+	}
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Annotations: [description] are not filled in", results[0].Reason)
+}
+
+func TestResourceRule_FindNonConformingObjects_OnlyMissingKeys(t *testing.T) {
+	rule := ResourceRule{
+		Name:        "owner and team",
+		Labels:      []string{"owner", "team"},
+		Annotations: []string{"description", "runbook"},
+	}
+	objects := []unstructured.Unstructured{
+		newUnstructured("default", "foo", map[string]interface{}{"owner": "team-a"}, nil),
+	}
+	objects[0].SetAnnotations(map[string]string{"runbook": "https://runbooks/foo"})
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	var reasons []string
+	for _, result := range results {
+		reasons = append(reasons, result.Reason)
+	}
+	assert.ElementsMatch(t, []string{"Labels: [team] are not filled in", "Annotations: [description] are not filled in"}, reasons)
+}
+
+func TestResourceRule_FindNonConformingObjects_Fields(t *testing.T) {
+	rule := ResourceRule{
+		Name:   "secret name filled in",
+		Fields: []FieldCheck{{Path: "spec.secretName"}},
+	}
+	objects := []unstructured.Unstructured{
+		newUnstructured("default", "foo", nil, map[string]interface{}{}),
+		newUnstructured("default", "bar", nil, map[string]interface{}{"secretName": "bar-tls"}),
+	}
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Field spec.secretName is not present", results[0].Reason)
+	assert.Equal(t, "foo", results[0].Objects[0].GetName())
+}
+
+func TestResourceRule_FindNonConformingObjects_CEL(t *testing.T) {
+	rule := ResourceRule{
+		Name: "short duration",
+		CEL:  "object.spec.duration == '2160h'",
+	}
+	objects := []unstructured.Unstructured{
+		newUnstructured("default", "foo", nil, map[string]interface{}{"duration": "8760h"}),
+		newUnstructured("default", "bar", nil, map[string]interface{}{"duration": "2160h"}),
+	}
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "CEL expression \"object.spec.duration == '2160h'\" is not satisfied", results[0].Reason)
+	assert.Equal(t, "foo", results[0].Objects[0].GetName())
+}
+
+func TestResourceRule_FindNonConformingObjects_Filter(t *testing.T) {
+	rule := ResourceRule{
+		Name:   "owner label",
+		Labels: []string{"owner"},
+	}
+	rule.Filter.ExcludeNamespaces = []string{"kube-system"}
+	objects := []unstructured.Unstructured{
+		newUnstructured("kube-system", "foo", nil, nil),
+	}
+
+	results, err := rule.FindNonConformingObjects(objects)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 0)
+}
+
+func TestResourceRule_UnmarshalYAML(t *testing.T) {
+	yamlString := `
+name: certificates
+resource:
+  group: cert-manager.io
+  version: v1
+  kind: Certificate
+labels:
+- owner
+fields:
+- path: spec.secretName
+cel: object.spec.duration == '2160h'`
+
+	rule := ResourceRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "cert-manager.io", rule.Resource.Group)
+	assert.Len(t, rule.Fields, 1)
+}
+
+func TestResourceRule_UnmarshalYAML_MissingResource(t *testing.T) {
+	yamlString := `
+name: certificates
+labels:
+- owner`
+
+	rule := ResourceRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestResourceRule_UnmarshalYAML_MissingChecks(t *testing.T) {
+	yamlString := `
+name: certificates
+resource:
+  version: v1
+  kind: ConfigMap`
+
+	rule := ResourceRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
+
+func TestResourceRule_UnmarshalYAML_InvalidCEL(t *testing.T) {
+	yamlString := `
+name: certificates
+resource:
+  version: v1
+  kind: ConfigMap
+cel: object.data ==`
+
+	rule := ResourceRule{}
+
+	err := yaml.Unmarshal([]byte(yamlString), &rule)
+
+	assert.NotNil(t, err)
+}
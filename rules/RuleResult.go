@@ -1,24 +1,62 @@
 package rules
 
 import (
+	"sort"
+
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type PodRuleResult struct {
 	Pods     []apiv1.Pod
 	Reason   string
 	RuleName string
+	Severity Severity
 }
 
 type DeploymentRuleResult struct {
 	Deployments []appsv1.Deployment
 	Reason      string
 	RuleName    string
+	Severity    Severity
 }
 
 type StatefulSetRuleResult struct {
 	StatefulSets []appsv1.StatefulSet
 	Reason       string
 	RuleName     string
+	Severity     Severity
+}
+
+type ObjectRuleResult struct {
+	Kind     string
+	Objects  []metav1.Object
+	Reason   string
+	RuleName string
+	Severity Severity
+}
+
+var objectKinds = []string{"Pod", "Deployment", "StatefulSet"}
+
+// newObjectRuleResults creates a result for every reason, sorted on the reason
+// so the output is stable between runs.
+func newObjectRuleResults(kind, ruleName string, severity Severity, objectsByReason map[string][]metav1.Object) []ObjectRuleResult {
+	var reasons []string
+	for reason := range objectsByReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	var ruleResults []ObjectRuleResult
+	for _, reason := range reasons {
+		ruleResults = append(ruleResults, ObjectRuleResult{
+			Kind:     kind,
+			Objects:  objectsByReason[reason],
+			Reason:   reason,
+			RuleName: ruleName,
+			Severity: severity.OrDefault(),
+		})
+	}
+	return ruleResults
 }
@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+	DefaultSeverity           = SeverityMedium
+)
+
+// Severities lists the severities from the lowest to the highest.
+var Severities = []Severity{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+func ParseSeverity(value string) (Severity, error) {
+	for _, severity := range Severities {
+		if string(severity) == value {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("invalid severity %q, must be one of %v", value, Severities)
+}
+
+// OrDefault returns the severity, or the default severity for rules that do
+// not set one.
+func (s Severity) OrDefault() Severity {
+	if s == "" {
+		return DefaultSeverity
+	}
+	return s
+}
+
+func (s Severity) Level() int {
+	for level, severity := range Severities {
+		if severity == s.OrDefault() {
+			return level
+		}
+	}
+	return -1
+}
+
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.Level() >= threshold.Level()
+}
+
+func (s *Severity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// SortPodRuleResults sorts the results from the highest to the lowest
+// severity, results of the same severity keep their order.
+func SortPodRuleResults(results []PodRuleResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Severity.Level() > results[j].Severity.Level()
+	})
+}
+
+func SortDeploymentRuleResults(results []DeploymentRuleResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Severity.Level() > results[j].Severity.Level()
+	})
+}
+
+func SortStatefulSetRuleResults(results []StatefulSetRuleResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Severity.Level() > results[j].Severity.Level()
+	})
+}
+
+func SortObjectRuleResults(results []ObjectRuleResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Severity.Level() > results[j].Severity.Level()
+	})
+}
@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("high")
+	assert.Nil(t, err)
+	assert.Equal(t, SeverityHigh, severity)
+
+	_, err = ParseSeverity("urgent")
+	assert.NotNil(t, err)
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	assert.True(t, SeverityCritical.AtLeast(SeverityHigh))
+	assert.True(t, SeverityHigh.AtLeast(SeverityHigh))
+	assert.False(t, SeverityLow.AtLeast(SeverityMedium))
+	assert.True(t, Severity("").AtLeast(SeverityMedium))
+	assert.False(t, Severity("").AtLeast(SeverityHigh))
+}
+
+func TestSeverity_UnmarshalYAML(t *testing.T) {
+	rule := PodRuleLimitsFilledIn{}
+	err := yaml.Unmarshal([]byte(`
+name: limits filled in
+severity: critical`), &rule)
+	assert.Nil(t, err)
+	assert.Equal(t, SeverityCritical, rule.Severity)
+
+	err = yaml.Unmarshal([]byte(`
+name: limits filled in
+severity: urgent`), &rule)
+	assert.NotNil(t, err)
+}
+
+func TestSortPodRuleResults(t *testing.T) {
+	results := []PodRuleResult{
+		{RuleName: "low", Severity: SeverityLow},
+		{RuleName: "default"},
+		{RuleName: "critical", Severity: SeverityCritical},
+		{RuleName: "medium", Severity: SeverityMedium},
+	}
+
+	SortPodRuleResults(results)
+
+	assert.Equal(t, "critical", results[0].RuleName)
+	assert.Equal(t, "default", results[1].RuleName)
+	assert.Equal(t, "medium", results[2].RuleName)
+	assert.Equal(t, "low", results[3].RuleName)
+}
+
+func TestFieldRule_FindNonConformingObjects_Severity(t *testing.T) {
+	rule := FieldRule{
+		Name:     "priority class",
+		Kind:     "Pod",
+		Severity: SeverityHigh,
+		Fields:   []FieldCheck{{Path: "spec.priorityClassName"}},
+	}
+	pod := newExemptedPod("foo", nil)
+
+	results, err := rule.FindNonConformingObjects([]metav1.Object{&pod})
+
+	assert.Nil(t, err)
+	assert.Equal(t, SeverityHigh, results[0].Severity)
+}
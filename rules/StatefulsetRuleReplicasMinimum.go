@@ -8,6 +8,7 @@ import (
 
 type StatefulSetRuleReplicasMinimum struct {
 	Name            string                    `yaml:"name"`
+	Severity        Severity                  `yaml:"severity"`
 	MinimumReplicas int32                     `yaml:"minimum_replicas"`
 	Filter          filters.StatefulsetFilter `yaml:"filter"`
 }
@@ -25,6 +26,7 @@ func (statefulSetRuleReplicasMinimum StatefulSetRuleReplicasMinimum) FindNonConf
 		StatefulSets: nonConformingStatefulSets,
 		Reason:       fmt.Sprintf("StatefulSet replicas below the minimum: %v", statefulSetRuleReplicasMinimum.MinimumReplicas),
 		RuleName:     statefulSetRuleReplicasMinimum.Name,
+		Severity:     statefulSetRuleReplicasMinimum.Severity.OrDefault(),
 	}
 }
 
@@ -34,7 +36,7 @@ func (statefulSetRuleReplicasMinimum *StatefulSetRuleReplicasMinimum) UnmarshalY
 		return err
 	}
 	if statefulSetRuleReplicasMinimum.MinimumReplicas == 0 {
-		return fmt.Errorf("missing minimum replicas")
+		return fmt.Errorf("missing minimum replicas for StatefulSetRuleReplicasMinimum")
 	}
 	if statefulSetRuleReplicasMinimum.Name == "" {
 		return fmt.Errorf("missing name for StatefulSetRuleReplicasMinimum")
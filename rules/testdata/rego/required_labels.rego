@@ -0,0 +1,9 @@
+package kubeconformity
+
+violation[{"msg": msg}] {
+	provided := {label | input.review.object.metadata.labels[label]}
+	required := {label | label := input.parameters.labels[_]}
+	missing := required - provided
+	count(missing) > 0
+	msg := sprintf("missing required labels: %v", [concat(", ", missing)])
+}
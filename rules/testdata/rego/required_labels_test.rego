@@ -0,0 +1,15 @@
+package kubeconformity
+
+test_violation_when_label_missing {
+	violation[{"msg": "missing required labels: app"}] with input as {
+		"review": {"object": {"metadata": {"name": "foo", "labels": {"team": "a"}}}},
+		"parameters": {"labels": ["app"]},
+	}
+}
+
+test_no_violation_when_labels_present {
+	count(violation) == 0 with input as {
+		"review": {"object": {"metadata": {"name": "foo", "labels": {"app": "foo"}}}},
+		"parameters": {"labels": ["app"]},
+	}
+}